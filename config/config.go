@@ -0,0 +1,423 @@
+// Package config loads the configuration of the app. Every setting has a name such as
+// GITHUB_CLIENT_ID, which is its key in the config file, its env variable and, lower
+// cased with dashes, its command-line flag (-github-client-id). The sources override
+// each other in this order: the defaults, the YAML or JSON file given by -config or
+// CONFIG_FILE, the env variables, the flags.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"local/sidharthjs/todo/handlers/validate"
+	"local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/notestore"
+	"local/sidharthjs/todo/notestore/cache"
+	"local/sidharthjs/todo/sessionstore"
+
+	"gopkg.in/yaml.v3"
+)
+
+//Config is the configuration of the app
+type Config struct {
+	// Addr is the address the app listens on
+	Addr string
+	// Store is the note store: postgres, sqlite, git or memory
+	Store       string
+	Postgres    Postgres
+	SQLitePath  string
+	GitRepoPath string
+	// SessionsPath is the SQLite file of the sessions of the stores without a database
+	SessionsPath string
+	Cache        Cache
+
+	GitHub GitHub
+	JWT    JWT
+	// UsersServiceEndpoint is the base URL of the users service
+	UsersServiceEndpoint string
+	// AdminUsers are the IDs of the users allowed to use the admin API
+	AdminUsers []string
+
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+	IdempotencyWindow  time.Duration
+	// Limits are the limits of the validation of the requests, see validate.DefaultLimits
+	Limits validate.Limits
+}
+
+//Postgres is the connection to Postgres, given as a URL or as its parts
+type Postgres struct {
+	URL      string
+	User     string
+	Password string
+	DB       string
+	Host     string
+	Port     string
+}
+
+//Cache is the cache of the notes, disabled when Backend is empty
+type Cache struct {
+	// Backend is memory or redis
+	Backend  string
+	TTL      time.Duration
+	Size     int
+	RedisURL string
+}
+
+//GitHub is the OAuth app used to log in with GitHub
+type GitHub struct {
+	ClientID       string
+	ClientSecret   string
+	LoginURI       string
+	AccessTokenURI string
+	RedirectURI    string
+	ProfileURI     string
+}
+
+//JWT is the keys of the JWT tokens of the users, given as ID:secret or as files named
+//after their ID holding a secret or a private key
+type JWT struct {
+	Keys     []string
+	KeyFiles []string
+	// SigningKey is the ID of the key signing the new tokens, the first key by default
+	SigningKey string
+	// Issuer is the iss claim of the tokens and the issuer of the discovery document
+	Issuer string
+	// AccessTokenTTL is the lifetime of the access tokens, SessionTTL the time a session
+	// lasts without refreshing them
+	AccessTokenTTL time.Duration
+	SessionTTL     time.Duration
+}
+
+//KeySet returns the keys given in the settings followed by the keys of the files
+func (j JWT) KeySet() (*jwt.KeySet, error) {
+	var keys []jwt.Key
+	for _, s := range j.Keys {
+		key, err := jwt.ParseKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_KEYS: %s", err)
+		}
+		keys = append(keys, key)
+	}
+	for _, path := range j.KeyFiles {
+		key, err := jwt.ReadKeyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_KEY_FILES: %s", err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWT_KEYS or JWT_KEY_FILES is required")
+	}
+
+	ks, err := jwt.NewKeySet(j.SigningKey, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("JWT keys: %s", err)
+	}
+	ks.Issuer = j.Issuer
+	ks.AccessTokenTTL = j.AccessTokenTTL
+	return ks, nil
+}
+
+//ConnString returns the URL of the Postgres database, built from its parts when URL is not set
+func (p Postgres) ConnString() string {
+	if p.URL != "" {
+		return p.URL
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(p.User, p.Password),
+		Host:     p.Host + ":" + p.Port,
+		Path:     "/" + p.DB,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
+}
+
+//Default returns the configuration with the default of every setting
+func Default() *Config {
+	return &Config{
+		Addr:               ":4010",
+		Store:              "postgres",
+		Cache:              Cache{TTL: cache.DefaultTTL, Size: cache.DefaultSize},
+		JWT:                JWT{AccessTokenTTL: jwt.DefaultAccessTokenTTL, SessionTTL: sessionstore.DefaultSessionTTL},
+		TrashRetention:     notestore.DefaultTrashRetention,
+		TrashPurgeInterval: notestore.DefaultPurgeInterval,
+		IdempotencyWindow:  notestore.DefaultIdempotencyWindow,
+		Limits:             validate.DefaultLimits(),
+	}
+}
+
+// setting is a setting of the configuration bound to its field
+type setting struct {
+	name  string
+	usage string
+	value flag.Value
+	// redact hides the secret parts of the value in the effective configuration
+	redact func(string) string
+}
+
+// settings returns the settings bound to the fields of the configuration
+func (c *Config) settings() []setting {
+	settings := []setting{
+		{name: "LISTEN_ADDR", usage: "address the app listens on", value: (*stringValue)(&c.Addr)},
+		{name: "STORE", usage: "note store: postgres, sqlite, git or memory", value: (*stringValue)(&c.Store)},
+		{name: "DB_URL", usage: "URL of the Postgres database, replaces the POSTGRES_* settings", value: (*stringValue)(&c.Postgres.URL), redact: redactURL},
+		{name: "POSTGRES_USER", usage: "Postgres user", value: (*stringValue)(&c.Postgres.User)},
+		{name: "POSTGRES_PASSWORD", usage: "Postgres password", value: (*stringValue)(&c.Postgres.Password), redact: redactSecret},
+		{name: "POSTGRES_DB", usage: "Postgres database", value: (*stringValue)(&c.Postgres.DB)},
+		{name: "POSTGRES_HOST", usage: "Postgres host", value: (*stringValue)(&c.Postgres.Host)},
+		{name: "POSTGRES_PORT", usage: "Postgres port", value: (*stringValue)(&c.Postgres.Port)},
+		{name: "SQLITE_PATH", usage: "SQLite database file of STORE=sqlite", value: (*stringValue)(&c.SQLitePath)},
+		{name: "GIT_REPO_PATH", usage: "git repository of STORE=git", value: (*stringValue)(&c.GitRepoPath)},
+		{name: "SESSIONS_SQLITE_PATH", usage: "SQLite database file of the sessions of STORE=git or memory, the sessions of STORE=memory are kept in memory when empty", value: (*stringValue)(&c.SessionsPath)},
+		{name: "CACHE", usage: "cache of the notes: memory or redis, disabled when empty", value: (*stringValue)(&c.Cache.Backend)},
+		{name: "CACHE_TTL", usage: "lifetime of the cached notes", value: (*durationValue)(&c.Cache.TTL)},
+		{name: "CACHE_SIZE", usage: "number of entries of CACHE=memory", value: (*intValue)(&c.Cache.Size)},
+		{name: "REDIS_URL", usage: "URL of the Redis of CACHE=redis", value: (*stringValue)(&c.Cache.RedisURL), redact: redactURL},
+		{name: "GITHUB_CLIENT_ID", usage: "client ID of the GitHub OAuth app", value: (*stringValue)(&c.GitHub.ClientID)},
+		{name: "GITHUB_CLIENT_SECRET", usage: "client secret of the GitHub OAuth app", value: (*stringValue)(&c.GitHub.ClientSecret), redact: redactSecret},
+		{name: "LOGIN_URI", usage: "GitHub OAuth authorization URL", value: (*stringValue)(&c.GitHub.LoginURI)},
+		{name: "ACCESS_TOKEN_URI", usage: "GitHub OAuth access token URL", value: (*stringValue)(&c.GitHub.AccessTokenURI)},
+		{name: "REDIRECT_URI", usage: "callback URL of the GitHub login", value: (*stringValue)(&c.GitHub.RedirectURI)},
+		{name: "PROFILE_URI", usage: "GitHub API URL", value: (*stringValue)(&c.GitHub.ProfileURI)},
+		{name: "JWT_KEYS", usage: "comma-separated keys of the JWT tokens given as ID:secret", value: (*listValue)(&c.JWT.Keys), redact: redactKeys},
+		{name: "JWT_KEY_FILES", usage: "comma-separated files holding the secrets or the PEM private keys of the JWT keys, the IDs are the file names without extension", value: (*listValue)(&c.JWT.KeyFiles)},
+		{name: "JWT_SIGNING_KEY", usage: "ID of the JWT key signing the new tokens, the first key by default", value: (*stringValue)(&c.JWT.SigningKey)},
+		{name: "JWT_ISSUER", usage: "public base URL of the app, the issuer of the JWT tokens", value: (*stringValue)(&c.JWT.Issuer)},
+		{name: "ACCESS_TOKEN_TTL", usage: "lifetime of the access tokens", value: (*durationValue)(&c.JWT.AccessTokenTTL)},
+		{name: "SESSION_TTL", usage: "time a session lasts without refreshing its access token", value: (*durationValue)(&c.JWT.SessionTTL)},
+		{name: "USERS_SVC_ENDPOINT", usage: "base URL of the users service", value: (*stringValue)(&c.UsersServiceEndpoint)},
+		{name: "ADMIN_USERS", usage: "comma-separated IDs of the users allowed to use the admin API", value: (*listValue)(&c.AdminUsers)},
+		{name: "TRASH_RETENTION", usage: "time the deleted notes are kept in the trash", value: (*durationValue)(&c.TrashRetention)},
+		{name: "TRASH_PURGE_INTERVAL", usage: "interval of the purges of the trash", value: (*durationValue)(&c.TrashPurgeInterval)},
+		{name: "IDEMPOTENCY_WINDOW", usage: "time the idempotency keys are kept", value: (*durationValue)(&c.IdempotencyWindow)},
+	}
+
+	names := make([]string, 0, len(c.Limits))
+	for name := range c.Limits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		settings = append(settings, setting{
+			name:  "LIMIT_" + strings.ToUpper(name),
+			usage: "limit " + name + " of the requests",
+			value: &limitValue{limits: c.Limits, name: name},
+		})
+	}
+	return settings
+}
+
+// flagName returns the command-line flag of a setting
+func flagName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
+
+//Error lists every problem of an invalid configuration
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+//Load loads the configuration from the command-line arguments without the program
+//name, the env variables read with getenv and the config file, and validates it.
+//An *Error lists every problem of the configuration, flag.ErrHelp is returned when
+//the usage is requested.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	c := Default()
+	settings := c.settings()
+
+	// The flags are recorded first, they override the other sources
+	flags := flag.NewFlagSet("todo", flag.ContinueOnError)
+	configFile := flags.String("config", "", "YAML or JSON config file, CONFIG_FILE by default")
+	flagValues := map[string]string{}
+	for _, s := range settings {
+		flags.Var(&recordedValue{values: flagValues, name: s.name}, flagName(s.name), s.usage)
+	}
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	problems := []string{}
+	if flags.NArg() > 0 {
+		problems = append(problems, fmt.Sprintf("unexpected arguments: %s", strings.Join(flags.Args(), " ")))
+	}
+
+	if *configFile == "" {
+		*configFile = getenv("CONFIG_FILE")
+	}
+	fileValues := map[string]string{}
+	if *configFile != "" {
+		fileValues, err = readFile(*configFile)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	known := map[string]bool{}
+	for _, s := range settings {
+		known[s.name] = true
+		value, source := fileValues[s.name], "in "+*configFile
+		if env := getenv(s.name); env != "" {
+			value, source = env, "from env"
+		}
+		if flagValue, ok := flagValues[s.name]; ok {
+			value, source = flagValue, "from flag -"+flagName(s.name)
+		}
+		if value == "" {
+			continue
+		}
+		err := s.value.Set(value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s %s: %s", s.name, source, err))
+		}
+	}
+	for name := range fileValues {
+		if !known[name] {
+			problems = append(problems, fmt.Sprintf("%s in %s: unknown setting", name, *configFile))
+		}
+	}
+
+	problems = append(problems, c.validate()...)
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, &Error{Problems: problems}
+	}
+	return c, nil
+}
+
+// readFile reads the settings of a YAML or JSON file, a map of the setting names to
+// their values. JSON is read as YAML.
+func readFile(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the config file: %s", err)
+	}
+	var raw map[string]interface{}
+	err = yaml.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the config file %s: %s", path, err)
+	}
+
+	values := map[string]string{}
+	for name, value := range raw {
+		switch value := value.(type) {
+		case nil:
+		case string, int, float64, bool:
+			values[name] = fmt.Sprint(value)
+		default:
+			return nil, fmt.Errorf("%s in %s: not a single value", name, path)
+		}
+	}
+	return values, nil
+}
+
+// validate returns the problems of the loaded settings
+func (c *Config) validate() []string {
+	var problems []string
+	required := func(name, value string) {
+		if value == "" {
+			problems = append(problems, name+" is required")
+		}
+	}
+
+	switch c.Store {
+	case "postgres":
+		if c.Postgres.URL == "" {
+			required("POSTGRES_USER", c.Postgres.User)
+			required("POSTGRES_PASSWORD", c.Postgres.Password)
+			required("POSTGRES_DB", c.Postgres.DB)
+			required("POSTGRES_HOST", c.Postgres.Host)
+			required("POSTGRES_PORT", c.Postgres.Port)
+		}
+	case "sqlite":
+		required("SQLITE_PATH", c.SQLitePath)
+	case "git":
+		required("GIT_REPO_PATH", c.GitRepoPath)
+		required("SESSIONS_SQLITE_PATH", c.SessionsPath)
+	case "memory":
+	default:
+		problems = append(problems, fmt.Sprintf("STORE: %q is not a note store, use postgres, sqlite, git or memory", c.Store))
+	}
+
+	if c.SessionsPath != "" && (c.Store == "postgres" || c.Store == "sqlite") {
+		problems = append(problems, fmt.Sprintf("SESSIONS_SQLITE_PATH: the sessions of STORE=%s are kept in its database", c.Store))
+	}
+
+	switch c.Cache.Backend {
+	case "", "memory":
+	case "redis":
+		required("REDIS_URL", c.Cache.RedisURL)
+		if u, err := url.Parse(c.Cache.RedisURL); c.Cache.RedisURL != "" && (err != nil || (u.Scheme != "redis" && u.Scheme != "rediss")) {
+			problems = append(problems, "REDIS_URL: not a redis:// or rediss:// URL")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("CACHE: %q is not a cache, use memory or redis", c.Cache.Backend))
+	}
+	if c.Cache.Backend != "" && c.Store == "git" {
+		problems = append(problems, "CACHE: the cache cannot be used with STORE=git")
+	}
+
+	required("GITHUB_CLIENT_ID", c.GitHub.ClientID)
+	required("GITHUB_CLIENT_SECRET", c.GitHub.ClientSecret)
+	required("LOGIN_URI", c.GitHub.LoginURI)
+	required("ACCESS_TOKEN_URI", c.GitHub.AccessTokenURI)
+	required("REDIRECT_URI", c.GitHub.RedirectURI)
+	required("PROFILE_URI", c.GitHub.ProfileURI)
+	required("USERS_SVC_ENDPOINT", c.UsersServiceEndpoint)
+	if _, err := c.JWT.KeySet(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if u, err := url.Parse(c.JWT.Issuer); c.JWT.Issuer != "" && (err != nil || u.Scheme == "" || u.Host == "") {
+		problems = append(problems, "JWT_ISSUER: not an absolute URL")
+	}
+	if c.JWT.AccessTokenTTL >= c.JWT.SessionTTL {
+		problems = append(problems, "ACCESS_TOKEN_TTL: the access tokens must be shorter-lived than the sessions of SESSION_TTL")
+	}
+	return problems
+}
+
+//Redacted returns the effective value of every setting by name, with the secrets and
+//the passwords of the URLs hidden
+func (c *Config) Redacted() map[string]string {
+	values := map[string]string{}
+	for _, s := range c.settings() {
+		value := s.value.String()
+		if value != "" && s.redact != nil {
+			value = s.redact(value)
+		}
+		values[s.name] = value
+	}
+	return values
+}
+
+func redactSecret(string) string {
+	return "******"
+}
+
+// redactKeys hides the secrets of the keys given as ID:secret, keeping their IDs
+func redactKeys(value string) string {
+	keys := strings.Split(value, ",")
+	for i, key := range keys {
+		keys[i] = strings.SplitN(key, ":", 2)[0] + ":" + redactSecret(key)
+	}
+	return strings.Join(keys, ",")
+}
+
+// redactURL hides the password of a URL, URLs which cannot be parsed are hidden entirely
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil {
+		return redactSecret(value)
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"local/sidharthjs/todo/handlers/validate"
+)
+
+// The values of the settings implement flag.Value
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+func (v *stringValue) String() string {
+	return string(*v)
+}
+
+// listValue is a comma-separated list, the empty items are dropped
+type listValue []string
+
+func (v *listValue) Set(s string) error {
+	*v = nil
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*v = append(*v, item)
+		}
+	}
+	return nil
+}
+
+func (v *listValue) String() string {
+	return strings.Join(*v, ",")
+}
+
+// durationValue is a positive duration such as 720h
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("%q is not a positive duration such as 90s or 720h", s)
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+func (v *durationValue) String() string {
+	return time.Duration(*v).String()
+}
+
+// intValue is a positive integer
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return fmt.Errorf("%q is not a positive integer", s)
+	}
+	*v = intValue(n)
+	return nil
+}
+
+func (v *intValue) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+// limitValue is a positive integer in the limits of the requests
+type limitValue struct {
+	limits validate.Limits
+	name   string
+}
+
+func (v *limitValue) Set(s string) error {
+	var n intValue
+	err := n.Set(s)
+	if err != nil {
+		return err
+	}
+	v.limits[v.name] = int(n)
+	return nil
+}
+
+func (v *limitValue) String() string {
+	if v.limits == nil {
+		return ""
+	}
+	return strconv.Itoa(v.limits[v.name])
+}
+
+// recordedValue records the values of the flags, which are applied over the other sources
+type recordedValue struct {
+	values map[string]string
+	name   string
+}
+
+func (v *recordedValue) Set(s string) error {
+	v.values[v.name] = s
+	return nil
+}
+
+func (v *recordedValue) String() string {
+	if v.values == nil {
+		return ""
+	}
+	return v.values[v.name]
+}
@@ -5,6 +5,7 @@ import (
 
 	gomigrate "github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
@@ -20,3 +21,22 @@ func Migrate(dbURL, migrationFilesPath string) error {
 	}
 	return nil
 }
+
+// Version returns the version of the last migration applied to the database, zero when
+// no migration was applied, and whether that migration failed half-way
+func Version(dbURL, migrationFilesPath string) (uint, bool, error) {
+	m, err := gomigrate.New(migrationFilesPath, dbURL)
+	if err != nil {
+		return 0, false, fmt.Errorf("error while initializing migration: %s", err)
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if err == gomigrate.ErrNilVersion {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("error while reading the migration version: %s", err)
+	}
+	return version, dirty, nil
+}
@@ -0,0 +1,7 @@
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+UPDATE notes SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE notes ALTER COLUMN updated_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS notes_user_created_idx ON notes (user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS notes_user_updated_idx ON notes (user_id, updated_at, id);
+CREATE INDEX IF NOT EXISTS notes_user_title_idx ON notes (user_id, title, id);
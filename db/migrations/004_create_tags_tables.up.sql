@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS tags
+(
+    id serial PRIMARY KEY,
+    user_id VARCHAR (50) NOT NULL,
+    name VARCHAR (50) NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS note_tags
+(
+    note_s_no INTEGER NOT NULL REFERENCES notes (s_no) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (note_s_no, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS note_tags_tag_idx ON note_tags (tag_id);
@@ -0,0 +1,6 @@
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS status VARCHAR (20) NOT NULL DEFAULT 'open';
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS due_date TIMESTAMP;
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS notes_user_due_date_idx ON notes (user_id, due_date) WHERE due_date IS NOT NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS note_items
+(
+    id VARCHAR ( 50 ) PRIMARY KEY,
+    note_s_no INTEGER NOT NULL REFERENCES notes (s_no) ON DELETE CASCADE,
+    text TEXT NOT NULL,
+    checked BOOLEAN NOT NULL DEFAULT false,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS note_items_note_idx ON note_items (note_s_no, position);
@@ -0,0 +1,2 @@
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS recurrence VARCHAR (200) NOT NULL DEFAULT '';
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS occurrence INTEGER NOT NULL DEFAULT 0;
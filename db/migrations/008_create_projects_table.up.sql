@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS projects
+(
+    id VARCHAR ( 50 ) PRIMARY KEY,
+    user_id VARCHAR (50) NOT NULL,
+    name VARCHAR (100) NOT NULL,
+    archived BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS project_id VARCHAR (50) REFERENCES projects (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS notes_user_project_idx ON notes (user_id, project_id);
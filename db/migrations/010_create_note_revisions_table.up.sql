@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS note_revisions
+(
+    note_s_no INTEGER NOT NULL REFERENCES notes (s_no) ON DELETE CASCADE,
+    number INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    user_id VARCHAR (50) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (note_s_no, number)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    user_id VARCHAR (50) NOT NULL,
+    key VARCHAR (255) NOT NULL,
+    request_hash VARCHAR (64) NOT NULL,
+    status INTEGER NOT NULL DEFAULT 0,
+    content_type VARCHAR (255) NOT NULL DEFAULT '',
+    body BYTEA,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
@@ -0,0 +1,121 @@
+-- Times are stored as text by the store in a fixed width UTC format, so that they sort as text.
+-- The cascades are triggers as foreign keys are only enforced on connections enabling them.
+CREATE TABLE IF NOT EXISTS projects
+(
+    id VARCHAR (50) PRIMARY KEY,
+    user_id VARCHAR (50) NOT NULL,
+    name VARCHAR (100) NOT NULL,
+    archived BOOLEAN NOT NULL DEFAULT false,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS notes
+(
+    s_no INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR (50) NOT NULL,
+    id VARCHAR (50) NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    status VARCHAR (20) NOT NULL DEFAULT 'open',
+    priority SMALLINT NOT NULL DEFAULT 0,
+    due_date TEXT,
+    completed_at TEXT,
+    recurrence VARCHAR (200) NOT NULL DEFAULT '',
+    occurrence INTEGER NOT NULL DEFAULT 0,
+    project_id VARCHAR (50) REFERENCES projects (id) ON DELETE SET NULL,
+    deleted_at TEXT,
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS notes_user_created_idx ON notes (user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS notes_user_updated_idx ON notes (user_id, updated_at, id);
+CREATE INDEX IF NOT EXISTS notes_user_title_idx ON notes (user_id, title, id);
+CREATE INDEX IF NOT EXISTS notes_user_due_date_idx ON notes (user_id, due_date) WHERE due_date IS NOT NULL;
+CREATE INDEX IF NOT EXISTS notes_user_project_idx ON notes (user_id, project_id);
+CREATE INDEX IF NOT EXISTS notes_deleted_at_idx ON notes (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- The search index holds the title and body of the notes, words are matched without stemming
+CREATE VIRTUAL TABLE IF NOT EXISTS notes_search USING fts5
+(
+    title, body, content='notes', content_rowid='s_no', tokenize='unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS notes_search_insert AFTER INSERT ON notes BEGIN
+    INSERT INTO notes_search(rowid, title, body) VALUES (new.s_no, new.title, new.body);
+END;
+
+CREATE TRIGGER IF NOT EXISTS notes_search_update AFTER UPDATE OF title, body ON notes BEGIN
+    INSERT INTO notes_search(notes_search, rowid, title, body) VALUES ('delete', old.s_no, old.title, old.body);
+    INSERT INTO notes_search(rowid, title, body) VALUES (new.s_no, new.title, new.body);
+END;
+
+CREATE TABLE IF NOT EXISTS tags
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR (50) NOT NULL,
+    name VARCHAR (50) NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS note_tags
+(
+    note_s_no INTEGER NOT NULL REFERENCES notes (s_no) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (note_s_no, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS note_tags_tag_idx ON note_tags (tag_id);
+
+CREATE TABLE IF NOT EXISTS note_items
+(
+    id VARCHAR (50) PRIMARY KEY,
+    note_s_no INTEGER NOT NULL REFERENCES notes (s_no) ON DELETE CASCADE,
+    text TEXT NOT NULL,
+    checked BOOLEAN NOT NULL DEFAULT false,
+    position INTEGER NOT NULL,
+    created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS note_items_note_idx ON note_items (note_s_no, position);
+
+CREATE TABLE IF NOT EXISTS note_revisions
+(
+    note_s_no INTEGER NOT NULL REFERENCES notes (s_no) ON DELETE CASCADE,
+    number INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    user_id VARCHAR (50) NOT NULL,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (note_s_no, number)
+);
+
+CREATE TRIGGER IF NOT EXISTS notes_delete AFTER DELETE ON notes BEGIN
+    INSERT INTO notes_search(notes_search, rowid, title, body) VALUES ('delete', old.s_no, old.title, old.body);
+    DELETE FROM note_tags WHERE note_s_no=old.s_no;
+    DELETE FROM note_items WHERE note_s_no=old.s_no;
+    DELETE FROM note_revisions WHERE note_s_no=old.s_no;
+END;
+
+CREATE TRIGGER IF NOT EXISTS tags_delete AFTER DELETE ON tags BEGIN
+    DELETE FROM note_tags WHERE tag_id=old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS projects_delete AFTER DELETE ON projects BEGIN
+    UPDATE notes SET project_id=NULL WHERE project_id=old.id;
+END;
+
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    user_id VARCHAR (50) NOT NULL,
+    key VARCHAR (255) NOT NULL,
+    request_hash VARCHAR (64) NOT NULL,
+    status INTEGER NOT NULL DEFAULT 0,
+    content_type VARCHAR (255) NOT NULL DEFAULT '',
+    body BLOB,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
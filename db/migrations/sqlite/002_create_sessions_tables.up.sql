@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS sessions
+(
+    id VARCHAR (50) PRIMARY KEY,
+    user_id VARCHAR (50) NOT NULL,
+    username VARCHAR (255) NOT NULL,
+    user_agent VARCHAR (255) NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL,
+    last_used_at TEXT NOT NULL,
+    expires_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_idx ON sessions (user_id);
+
+-- The refresh tokens are kept as SHA-256 hashes, the replaced ones detect the reuse of a token
+CREATE TABLE IF NOT EXISTS refresh_tokens
+(
+    token_hash VARCHAR (64) PRIMARY KEY,
+    session_id VARCHAR (50) NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+    created_at TEXT NOT NULL,
+    replaced_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_session_idx ON refresh_tokens (session_id);
+
+CREATE TRIGGER IF NOT EXISTS sessions_delete AFTER DELETE ON sessions BEGIN
+    DELETE FROM refresh_tokens WHERE session_id=old.id;
+END;
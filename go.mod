@@ -3,6 +3,8 @@ module local/sidharthjs/todo
 go 1.15
 
 require (
+	github.com/alicebob/miniredis/v2 v2.16.0
+	github.com/go-redis/redis/v8 v8.11.4
 	github.com/gofiber/fiber/v2 v2.20.2
 	github.com/gofiber/jwt/v3 v3.2.0
 	github.com/golang-jwt/jwt/v4 v4.1.0
@@ -14,5 +16,6 @@ require (
 	github.com/sirupsen/logrus v1.8.1
 	github.com/stretchr/testify v1.7.0
 	go.uber.org/atomic v1.7.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
+	modernc.org/sqlite v1.10.6
 )
@@ -0,0 +1,177 @@
+// Package adminhandler serves the diagnostics of the app to the admins: the build, the
+// effective configuration, the database of the note store and the health of the
+// dependencies. The routes are expected behind middleware.RequireAdmin.
+package adminhandler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"expvar"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// checkTimeout bounds every health check
+const checkTimeout = 5 * time.Second
+
+// Check checks the health of a dependency, such as the database or the cache
+type Check func(ctx context.Context) error
+
+// MigrationVersion returns the version of the last migration of the database and
+// whether it failed half-way
+type MigrationVersion func() (uint, bool, error)
+
+//AdminHandler struct definition
+type AdminHandler struct {
+	// Version is the version the app was built as
+	Version string
+	// Settings are the effective configuration with the secrets hidden
+	Settings map[string]string
+	// Store is the name of the note store
+	Store string
+	// DB is the database of the note store, nil for the stores without one
+	DB *sql.DB
+	// Migrations reads the migration version of DB
+	Migrations MigrationVersion
+	// Checks are the health checks of the dependencies by name
+	Checks map[string]Check
+
+	startedAt time.Time
+}
+
+// New returns AdminHandler
+func New(version string, settings map[string]string, store string, db *sql.DB, migrations MigrationVersion, checks map[string]Check) *AdminHandler {
+	return &AdminHandler{
+		Version:    version,
+		Settings:   settings,
+		Store:      store,
+		DB:         db,
+		Migrations: migrations,
+		Checks:     checks,
+		startedAt:  time.Now(),
+	}
+}
+
+//Build is the handler method for the build of the app
+func (ah *AdminHandler) Build(c *fiber.Ctx) error {
+	response := fiber.Map{
+		"version":    ah.Version,
+		"go_version": runtime.Version(),
+		"started_at": ah.startedAt.UTC().Format(time.RFC3339),
+		"uptime":     time.Since(ah.startedAt).Round(time.Second).String(),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		response["module"] = info.Main.Path
+		response["module_version"] = info.Main.Version
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+//Config is the handler method for the effective configuration, secrets hidden
+func (ah *AdminHandler) Config(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(ah.Settings)
+}
+
+//Database is the handler method for the migration version and connection pool of the
+//database of the note store
+func (ah *AdminHandler) Database(c *fiber.Ctx) error {
+	response := fiber.Map{"store": ah.Store}
+	if ah.DB == nil {
+		return c.Status(fiber.StatusOK).JSON(response)
+	}
+
+	if ah.Migrations != nil {
+		version, dirty, err := ah.Migrations()
+		if err != nil {
+			response["migration"] = fiber.Map{"error": err.Error()}
+		} else {
+			response["migration"] = fiber.Map{"version": version, "dirty": dirty}
+		}
+	}
+
+	stats := ah.DB.Stats()
+	response["pool"] = fiber.Map{
+		"max_open_connections": stats.MaxOpenConnections,
+		"open_connections":     stats.OpenConnections,
+		"in_use":               stats.InUse,
+		"idle":                 stats.Idle,
+		"wait_count":           stats.WaitCount,
+		"wait_duration":        stats.WaitDuration.String(),
+		"max_idle_closed":      stats.MaxIdleClosed,
+		"max_idle_time_closed": stats.MaxIdleTimeClosed,
+		"max_lifetime_closed":  stats.MaxLifetimeClosed,
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// checkResult is the result of a health check
+type checkResult struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+//Health is the handler method for the health of the dependencies. The checks run
+//concurrently, the status is 503 when any of them fails.
+func (ah *AdminHandler) Health(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), checkTimeout)
+	defer cancel()
+
+	names := make([]string, 0, len(ah.Checks))
+	for name := range ah.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]checkResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			start := time.Now()
+			err := check(ctx)
+			results[i] = checkResult{Status: "ok", Latency: time.Since(start).String()}
+			if err != nil {
+				results[i].Status, results[i].Error = "failing", err.Error()
+			}
+		}(i, ah.Checks[name])
+	}
+	wg.Wait()
+
+	status, code := "ok", fiber.StatusOK
+	checks := map[string]checkResult{}
+	for i, name := range names {
+		checks[name] = results[i]
+		if results[i].Error != "" {
+			status, code = "failing", fiber.StatusServiceUnavailable
+		}
+	}
+	return c.Status(code).JSON(fiber.Map{"status": status, "checks": checks})
+}
+
+// hiddenVars are the vars published by default that are not metrics. The cmdline holds
+// the flags of the settings, secrets included.
+var hiddenVars = map[string]bool{"cmdline": true}
+
+//Metrics is the handler method for the metrics of the app published with expvar
+func (ah *AdminHandler) Metrics(c *fiber.Ctx) error {
+	vars := map[string]json.RawMessage{}
+	expvar.Do(func(kv expvar.KeyValue) {
+		if !hiddenVars[kv.Key] {
+			vars[kv.Key] = json.RawMessage(kv.Value.String())
+		}
+	})
+	body, err := json.Marshal(vars)
+	if err != nil {
+		return err
+	}
+	c.Type("json")
+	return c.Status(fiber.StatusOK).Send(body)
+}
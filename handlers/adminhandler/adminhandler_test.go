@@ -0,0 +1,124 @@
+package adminhandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"local/sidharthjs/todo/handlers/problem"
+	"local/sidharthjs/todo/notestore/sqlite"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// testApp returns an app serving the diagnostics of the handler
+func testApp(ah *AdminHandler) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler, JSONEncoder: json.Marshal})
+	app.Get("/admin/build", ah.Build)
+	app.Get("/admin/config", ah.Config)
+	app.Get("/admin/db", ah.Database)
+	app.Get("/admin/health", ah.Health)
+	app.Get("/admin/metrics", ah.Metrics)
+	return app
+}
+
+// get sends a GET request and returns the status and decoded body of the response
+func get(t *testing.T, app *fiber.App, path string) (int, map[string]interface{}) {
+	resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+	assert.Nil(t, err)
+	b, _ := ioutil.ReadAll(resp.Body)
+	body := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(b, &body), string(b))
+	return resp.StatusCode, body
+}
+
+func TestAdminHandler(t *testing.T) {
+	db, err := sqlite.NewClient(":memory:")
+	if err != nil {
+		t.Fatalf("error opening the db: %s", err)
+	}
+	defer db.Close()
+	migrations := func() (uint, bool, error) {
+		return 12, false, nil
+	}
+	// The check of the SQLite store, as served by the app
+	checks := map[string]Check{"store": db.Check}
+	settings := map[string]string{"GITHUB_CLIENT_SECRET": "******", "STORE": "sqlite"}
+	app := testApp(New("1.2.0", settings, "sqlite", db.DB, migrations, checks))
+
+	assert := assert.New(t)
+	status, body := get(t, app, "/admin/build")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal("1.2.0", body["version"])
+	assert.NotEmpty(body["go_version"])
+
+	status, body = get(t, app, "/admin/config")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal(map[string]interface{}{"GITHUB_CLIENT_SECRET": "******", "STORE": "sqlite"}, body)
+
+	status, body = get(t, app, "/admin/db")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal("sqlite", body["store"])
+	assert.Equal(map[string]interface{}{"version": float64(12), "dirty": false}, body["migration"])
+	assert.Contains(body["pool"], "open_connections")
+
+	status, body = get(t, app, "/admin/health")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal("ok", body["status"])
+
+	expvar.NewInt("admin_test_requests").Set(3)
+	status, body = get(t, app, "/admin/metrics")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal(float64(3), body["admin_test_requests"])
+	assert.Contains(body, "memstats")
+}
+
+func TestMetricsHideCmdline(t *testing.T) {
+	args := os.Args
+	os.Args = append([]string{"todo"}, "-github-client-secret", "a github secret")
+	defer func() {
+		os.Args = args
+	}()
+	app := testApp(New("dev", nil, "memory", nil, nil, nil))
+
+	// The flags of the settings are not metrics and hold secrets
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/metrics", nil))
+	assert := assert.New(t)
+	if assert.Nil(err) {
+		b, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(fiber.StatusOK, resp.StatusCode)
+		assert.NotContains(string(b), "a github secret")
+		assert.NotContains(string(b), `"cmdline"`)
+		assert.Contains(string(b), `"memstats"`)
+	}
+}
+
+func TestHealthFailing(t *testing.T) {
+	checks := map[string]Check{
+		"store": func(ctx context.Context) error {
+			return nil
+		},
+		"cache": func(ctx context.Context) error {
+			return errors.New("connection refused")
+		},
+	}
+	app := testApp(New("dev", nil, "memory", nil, nil, checks))
+
+	assert := assert.New(t)
+	status, body := get(t, app, "/admin/health")
+	assert.Equal(fiber.StatusServiceUnavailable, status)
+	assert.Equal("failing", body["status"])
+	cache := body["checks"].(map[string]interface{})["cache"].(map[string]interface{})
+	assert.Equal("connection refused", cache["error"])
+
+	// The stores without a database only report their name
+	status, body = get(t, app, "/admin/db")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal(map[string]interface{}{"store": "memory"}, body)
+}
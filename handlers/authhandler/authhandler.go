@@ -9,15 +9,16 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
-	"local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/handlers/validate"
+	jwtutil "local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/sessionstore"
 
 	"github.com/gofiber/fiber/v2"
 	log "github.com/sirupsen/logrus"
 )
 
-const jwtSecret = "aJWTSecret"
-
 //AuthHandler struct definition
 type AuthHandler struct {
 	ClientID       string
@@ -27,10 +28,17 @@ type AuthHandler struct {
 	RedirectURI    string
 	ProfileURI     string
 	UsersService   string
+	// Keys sign the JWT tokens of the users
+	Keys *jwtutil.KeySet
+	// Sessions store the sessions of the users, which last SessionTTL without a refresh
+	Sessions   sessionstore.Store
+	SessionTTL time.Duration
+	Validator  *validate.Validator
 }
 
 // New returns AuthHandler
-func New(clientID, clientSecret, loginURI, accessTokenURI, redirectURI, profileURI, usersService string) *AuthHandler {
+func New(clientID, clientSecret, loginURI, accessTokenURI, redirectURI, profileURI, usersService string,
+	keys *jwtutil.KeySet, sessions sessionstore.Store, sessionTTL time.Duration) *AuthHandler {
 	return &AuthHandler{
 		ClientID:       clientID,
 		ClientSecret:   clientSecret,
@@ -39,6 +47,10 @@ func New(clientID, clientSecret, loginURI, accessTokenURI, redirectURI, profileU
 		RedirectURI:    redirectURI,
 		ProfileURI:     profileURI,
 		UsersService:   usersService,
+		Keys:           keys,
+		Sessions:       sessions,
+		SessionTTL:     sessionTTL,
+		Validator:      validate.New(nil),
 	}
 }
 
@@ -60,71 +72,42 @@ func (ah *AuthHandler) ProcessCallback(c *fiber.Ctx) error {
 
 	req, err := http.NewRequest("POST", ah.AccessTokenURI, strings.NewReader(data.Encode()))
 	if err != nil {
-		log.Errorf("error while creating the token request: %s", err)
-
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error during authentication",
-		})
+		return fmt.Errorf("error while creating the token request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		log.Errorf("error while making token request: %s", err)
-
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error during authentication",
-		})
+		return fmt.Errorf("error while making token request: %w", err)
 	}
 
 	b, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.Errorf("error while reading token response: %s", err)
-
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error during authentication",
-		})
-
+		return fmt.Errorf("error while reading token response: %w", err)
 	}
 
 	m := make(map[string]string)
 	err = json.Unmarshal(b, &m)
 	if err != nil {
-		log.Errorf("error while unmarshaling token response: %s", err)
-
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error during authentication",
-		})
+		return fmt.Errorf("error while unmarshaling token response: %w", err)
 	}
 	log.Debugf("access_token: %s", m["access_token"])
 
 	// Get profile id, username by hitting github user API
 	req, err = http.NewRequest("GET", ah.ProfileURI+"/user", strings.NewReader(data.Encode()))
 	if err != nil {
-		log.Errorf("error while creating the token request: %s", err)
-
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error during authentication",
-		})
+		return fmt.Errorf("error while creating the token request: %w", err)
 	}
 	req.Header.Add("Authorization", "Bearer "+m["access_token"])
 
 	resp, err = http.DefaultClient.Do(req)
 	if err != nil {
-		log.Errorf("error while making github user request: %s", err)
-
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error during authentication",
-		})
+		return fmt.Errorf("error while making github user request: %w", err)
 	}
 
 	b, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.Errorf("error while reading github user response: %s", err)
-
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error during authentication",
-		})
+		return fmt.Errorf("error while reading github user response: %w", err)
 	}
 
 	user := struct {
@@ -133,23 +116,15 @@ func (ah *AuthHandler) ProcessCallback(c *fiber.Ctx) error {
 	}{}
 	err = json.Unmarshal(b, &user)
 	if err != nil {
-		log.Errorf("error while unmarshaling github user response: %s", err)
-
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error during authentication",
-		})
+		return fmt.Errorf("error while unmarshaling github user response: %w", err)
 	}
 
-	// Create JWT token
-	token, err := jwt.CreateJWTToken(strconv.FormatInt(user.ID, 10), user.Username)
+	// Start a session of the user
+	tokens, err := ah.startSession(c, strconv.FormatInt(user.ID, 10), user.Username)
 	if err != nil {
-		log.Errorf("error while generating JWT token: %s", err)
-
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error during authentication",
-		})
+		return fmt.Errorf("error while starting the session: %w", err)
 	}
-	log.Debugf("JWT token: %s", token)
+	log.Debugf("JWT token: %s", tokens.AccessToken)
 
 	// Create User
 	postBody, _ := json.Marshal(map[string]string{
@@ -159,24 +134,19 @@ func (ah *AuthHandler) ProcessCallback(c *fiber.Ctx) error {
 
 	resp, err = http.Post(ah.UsersService+"/users/"+strconv.FormatInt(user.ID, 10), "application/json", responseBody)
 	if err != nil {
-		log.Errorf("error while creating user: %s", err)
-
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error while creating user",
-		})
+		return fmt.Errorf("error while creating user: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.Errorf("error while reading create user response: %s", err)
-
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error while reading create user response",
-		})
+		return fmt.Errorf("error while reading create user response: %w", err)
 	}
 	log.Printf(string(body))
 	log.Debugf("User %s successfully created", user.Username)
 
-	return c.Status(fiber.StatusOK).SendString(fmt.Sprintf("Welcome %s!\nYour JWT token: %s\n\nPlease refer to README.md for curl commands to test the app.", user.Username, token))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusOK).SendString(fmt.Sprintf("Welcome %s!\nYour JWT token: %s\nYour refresh token: %s\n\n"+
+		"The JWT token expires in %d seconds, get a new one from POST /auth/refresh with the refresh token.\n"+
+		"Please refer to README.md for curl commands to test the app.", user.Username, tokens.AccessToken, tokens.RefreshToken, tokens.ExpiresIn))
 }
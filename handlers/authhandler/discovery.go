@@ -0,0 +1,41 @@
+package authhandler
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Paths of the documents the other services read to verify the tokens
+const (
+	JWKSPath      = "/.well-known/jwks.json"
+	DiscoveryPath = "/.well-known/openid-configuration"
+)
+
+// keysMaxAge is how long the other services may cache the public keys, short enough
+// for a new signing key to be fetched before it is used
+const keysMaxAge = "public, max-age=300"
+
+//JWKS is the handler method for the public keys verifying the tokens
+func (ah *AuthHandler) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, keysMaxAge)
+	return c.Status(fiber.StatusOK).JSON(ah.Keys.JWKS())
+}
+
+//Discovery is the handler method for the OpenID Connect style discovery document,
+//which points to the login and to the public keys. The issuer is the base URL of
+//the request when JWT_ISSUER is not set.
+func (ah *AuthHandler) Discovery(c *fiber.Ctx) error {
+	issuer := ah.Keys.Issuer
+	if issuer == "" {
+		issuer = c.BaseURL()
+	}
+	c.Set(fiber.HeaderCacheControl, keysMaxAge)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/login/github",
+		"jwks_uri":                              issuer + JWKSPath,
+		"response_types_supported":              []string{"token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": ah.Keys.Algorithms(),
+		"claims_supported":                      []string{"sub", "username", "iss", "exp"},
+	})
+}
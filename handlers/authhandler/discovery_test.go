@@ -0,0 +1,66 @@
+package authhandler
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/sessionstore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// get sends a GET request and decodes the body of the response into v
+func get(t *testing.T, app *fiber.App, path string, v interface{}) {
+	resp, err := app.Test(httptest.NewRequest("GET", "http://todo.local"+path, nil))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "public, max-age=300", resp.Header.Get(fiber.HeaderCacheControl))
+	b, _ := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, json.Unmarshal(b, v), string(b))
+}
+
+func TestDiscovery(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating the key: %s", err)
+	}
+	keys, err := jwt.NewKeySet("", jwt.Key{ID: "2021-12", Private: private}, jwt.Key{ID: "2021-11", Secret: []byte("secret")})
+	if err != nil {
+		t.Fatalf("error creating the key set: %s", err)
+	}
+	ah := New("id", "secret", "", "", "", "", "", keys, sessionstore.NewMemory(), time.Hour)
+	app := fiber.New(fiber.Config{JSONEncoder: json.Marshal})
+	app.Get(JWKSPath, ah.JWKS)
+	app.Get(DiscoveryPath, ah.Discovery)
+
+	// The secret keys are not published
+	assert := assert.New(t)
+	var jwks jwt.JWKS
+	get(t, app, JWKSPath, &jwks)
+	if assert.Len(jwks.Keys, 1) {
+		assert.Equal("2021-12", jwks.Keys[0].Kid)
+		assert.Equal("OKP", jwks.Keys[0].Kty)
+		assert.Equal("EdDSA", jwks.Keys[0].Alg)
+	}
+
+	// The issuer is the base URL of the request unless it is configured
+	var discovery map[string]interface{}
+	get(t, app, DiscoveryPath, &discovery)
+	assert.Equal("http://todo.local", discovery["issuer"])
+	assert.Equal("http://todo.local/.well-known/jwks.json", discovery["jwks_uri"])
+	assert.Equal([]interface{}{"EdDSA"}, discovery["id_token_signing_alg_values_supported"])
+
+	keys.Issuer = "https://todo.example.com"
+	get(t, app, DiscoveryPath, &discovery)
+	assert.Equal("https://todo.example.com", discovery["issuer"])
+	assert.Equal("https://todo.example.com/login/github", discovery["authorization_endpoint"])
+}
@@ -0,0 +1,177 @@
+package authhandler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	jwtutil "local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/sessionstore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxUserAgentLength is the number of characters of the user agent kept in a session
+const maxUserAgentLength = 255
+
+// tokenResponse is the body of the responses issuing tokens
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	// ExpiresIn is the lifetime of the access token in seconds
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// refreshTokenRequest is the body of the requests sending a refresh token
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=100"`
+}
+
+// tokens returns the access token of the session along with its refresh token
+func (ah *AuthHandler) tokens(session sessionstore.Session, refreshToken string) (tokenResponse, error) {
+	accessToken, err := ah.Keys.CreateJWTToken(session.UserID, session.Username, session.ID)
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("error while generating JWT token: %w", err)
+	}
+	ttl := ah.Keys.AccessTokenTTL
+	if ttl == 0 {
+		ttl = jwtutil.DefaultAccessTokenTTL
+	}
+	return tokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(ttl.Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// startSession starts a session of the user logged in with the request
+func (ah *AuthHandler) startSession(c *fiber.Ctx, userID, username string) (tokenResponse, error) {
+	refreshToken, hash, err := jwtutil.NewRefreshToken()
+	if err != nil {
+		return tokenResponse{}, err
+	}
+
+	userAgent := []rune(c.Get(fiber.HeaderUserAgent))
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	now := time.Now()
+	session := sessionstore.Session{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		Username:   username,
+		UserAgent:  string(userAgent),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(ah.SessionTTL),
+	}
+	err = ah.Sessions.CreateSession(c.UserContext(), session, hash)
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("error in creating the session: %w", err)
+	}
+	return ah.tokens(session, refreshToken)
+}
+
+//Refresh is the handler method for exchanging a refresh token for a new access token
+//and a new refresh token. The refresh token can only be used once: using it again
+//revokes its session, as it may have been stolen.
+func (ah *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req refreshTokenRequest
+	err := ah.Validator.DecodeBody(c, &req)
+	if err != nil {
+		return err
+	}
+
+	refreshToken, hash, err := jwtutil.NewRefreshToken()
+	if err != nil {
+		return err
+	}
+	session, err := ah.Sessions.RotateRefreshToken(c.UserContext(), jwtutil.HashRefreshToken(req.RefreshToken), hash, time.Now().Add(ah.SessionTTL))
+	switch {
+	case errors.Is(err, sessionstore.ErrTokenReused):
+		log.Warnf("a refresh token was used twice, its session is revoked")
+		return fiber.NewError(fiber.StatusUnauthorized, "the refresh token was already used, its session has been revoked")
+	case errors.Is(err, sessionstore.ErrNotFound):
+		return fiber.NewError(fiber.StatusUnauthorized, "the refresh token is not valid or its session has ended")
+	case err != nil:
+		return fmt.Errorf("error in refreshing the session: %w", err)
+	}
+
+	tokens, err := ah.tokens(session, refreshToken)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusOK).JSON(tokens)
+}
+
+//Logout is the handler method for ending the session of a refresh token. The access
+//tokens of the session stay valid until they expire.
+func (ah *AuthHandler) Logout(c *fiber.Ctx) error {
+	var req refreshTokenRequest
+	err := ah.Validator.DecodeBody(c, &req)
+	if err != nil {
+		return err
+	}
+
+	// Logging out of an ended session is not an error
+	err = ah.Sessions.RevokeRefreshToken(c.UserContext(), jwtutil.HashRefreshToken(req.RefreshToken))
+	if err != nil && !errors.Is(err, sessionstore.ErrNotFound) {
+		return fmt.Errorf("error in ending the session: %w", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": "logged out successfully",
+	})
+}
+
+//ListSessions is the handler method for listing the active sessions of the user, the
+//session of the access token is the current one
+func (ah *AuthHandler) ListSessions(c *fiber.Ctx) error {
+
+	token := c.Locals("user").(*jwt.Token)
+	userID, _, err := jwtutil.GetUserFromJWTToken(token)
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	sessions, err := ah.Sessions.ListSessions(c.UserContext(), userID)
+	if err != nil {
+		return fmt.Errorf("error in listing sessions: %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"sessions": sessions,
+		"current":  jwtutil.GetSessionFromJWTToken(token),
+	})
+}
+
+//RevokeSession is the handler method for ending a session of the user, e.g. on a lost
+//device. Its refresh tokens are refused from then on.
+func (ah *AuthHandler) RevokeSession(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	sessionID := c.Params("session_id")
+	err = ah.Sessions.RevokeSession(c.UserContext(), sessionID, userID)
+	if errors.Is(err, sessionstore.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("Session '%s' is not found", sessionID))
+	}
+	if err != nil {
+		return fmt.Errorf("error in revoking session: %w", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("session '%s' revoked successfully", sessionID),
+	})
+}
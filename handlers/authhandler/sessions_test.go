@@ -0,0 +1,169 @@
+package authhandler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"local/sidharthjs/todo/handlers/problem"
+	"local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/middleware"
+	"local/sidharthjs/todo/sessionstore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// sessionsApp returns an app serving the sessions of the handler, GET /login starts a
+// session of the user 1001 as the GitHub callback does
+func sessionsApp(t *testing.T) *fiber.App {
+	keys, err := jwt.NewKeySet("", jwt.Key{ID: "2021-11", Secret: []byte("a test secret")})
+	if err != nil {
+		t.Fatalf("error creating the key set: %s", err)
+	}
+	ah := New("id", "secret", "", "", "", "", "", keys, sessionstore.NewMemory(), time.Hour)
+
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler, JSONEncoder: json.Marshal})
+	app.Get("/login", func(c *fiber.Ctx) error {
+		tokens, err := ah.startSession(c, "1001", "john101")
+		if err != nil {
+			return err
+		}
+		return c.JSON(tokens)
+	})
+	app.Post("/auth/refresh", ah.Refresh)
+	app.Post("/auth/logout", ah.Logout)
+	middleware.SetupAuthentication(app, keys)
+	app.Get("/sessions", ah.ListSessions)
+	app.Delete("/sessions/:session_id", ah.RevokeSession)
+	return app
+}
+
+// send sends a request with the JSON body and the access token, if any, and decodes
+// the body of the response into v
+func send(t *testing.T, app *fiber.App, method, path, body, accessToken string, v interface{}) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(fiber.HeaderUserAgent, "curl/7.79.1")
+	if accessToken != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+accessToken)
+	}
+	resp, err := app.Test(req)
+	if !assert.Nil(t, err) {
+		return 0
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	if v != nil {
+		assert.Nil(t, json.Unmarshal(b, v), string(b))
+	}
+	return resp.StatusCode
+}
+
+// login starts a session
+func login(t *testing.T, app *fiber.App) tokenResponse {
+	var tokens tokenResponse
+	send(t, app, "GET", "/login", "", "", &tokens)
+	return tokens
+}
+
+// refresh sends a refresh token to POST /auth/refresh
+func refresh(t *testing.T, app *fiber.App, refreshToken string) (int, tokenResponse) {
+	var tokens tokenResponse
+	status := send(t, app, "POST", "/auth/refresh", `{"refresh_token": "`+refreshToken+`"}`, "", &tokens)
+	return status, tokens
+}
+
+func TestRefresh(t *testing.T) {
+	app := sessionsApp(t)
+	first := login(t, app)
+
+	assert := assert.New(t)
+	assert.Equal("Bearer", first.TokenType)
+	assert.Equal(900, first.ExpiresIn)
+
+	// A refresh token gives new tokens once
+	status, second := refresh(t, app, first.RefreshToken)
+	assert.Equal(fiber.StatusOK, status)
+	assert.NotEqual(first.RefreshToken, second.RefreshToken)
+	assert.NotEmpty(second.AccessToken)
+
+	var list struct {
+		Sessions []struct {
+			ID        string
+			UserAgent string
+		}
+		Current string
+	}
+	status = send(t, app, "GET", "/sessions", "", second.AccessToken, &list)
+	assert.Equal(fiber.StatusOK, status)
+	if assert.Len(list.Sessions, 1) {
+		assert.Equal(list.Sessions[0].ID, list.Current)
+		assert.Equal("curl/7.79.1", list.Sessions[0].UserAgent)
+	}
+
+	// Reusing it revokes the session, the latest refresh token included
+	var details problem.Details
+	status, _ = refresh(t, app, first.RefreshToken)
+	assert.Equal(fiber.StatusUnauthorized, status)
+	status = send(t, app, "POST", "/auth/refresh", `{"refresh_token": "`+second.RefreshToken+`"}`, "", &details)
+	assert.Equal(fiber.StatusUnauthorized, status)
+	assert.Equal("the refresh token is not valid or its session has ended", details.Detail)
+
+	status = send(t, app, "GET", "/sessions", "", second.AccessToken, &list)
+	assert.Equal(fiber.StatusOK, status)
+	assert.Empty(list.Sessions)
+
+	status = send(t, app, "POST", "/auth/refresh", `{}`, "", &details)
+	assert.Equal(fiber.StatusBadRequest, status)
+}
+
+func TestLogout(t *testing.T) {
+	app := sessionsApp(t)
+	tokens := login(t, app)
+
+	assert := assert.New(t)
+	body := `{"refresh_token": "` + tokens.RefreshToken + `"}`
+	status := send(t, app, "POST", "/auth/logout", body, "", nil)
+	assert.Equal(fiber.StatusOK, status)
+	status, _ = refresh(t, app, tokens.RefreshToken)
+	assert.Equal(fiber.StatusUnauthorized, status)
+
+	// Logging out twice is fine
+	status = send(t, app, "POST", "/auth/logout", body, "", nil)
+	assert.Equal(fiber.StatusOK, status)
+}
+
+func TestRevokeSession(t *testing.T) {
+	app := sessionsApp(t)
+	lost := login(t, app)
+	current := login(t, app)
+
+	assert := assert.New(t)
+	var list struct {
+		Sessions []struct{ ID string }
+		Current  string
+	}
+	send(t, app, "GET", "/sessions", "", current.AccessToken, &list)
+	if !assert.Len(list.Sessions, 2) {
+		return
+	}
+	lostID := list.Sessions[0].ID
+	if lostID == list.Current {
+		lostID = list.Sessions[1].ID
+	}
+
+	status := send(t, app, "DELETE", "/sessions/"+lostID, "", current.AccessToken, nil)
+	assert.Equal(fiber.StatusOK, status)
+	status, _ = refresh(t, app, lost.RefreshToken)
+	assert.Equal(fiber.StatusUnauthorized, status)
+	status, _ = refresh(t, app, current.RefreshToken)
+	assert.Equal(fiber.StatusOK, status)
+
+	status = send(t, app, "DELETE", "/sessions/"+lostID, "", current.AccessToken, nil)
+	assert.Equal(fiber.StatusNotFound, status)
+	status = send(t, app, "GET", "/sessions", "", "", nil)
+	assert.Equal(fiber.StatusUnauthorized, status)
+}
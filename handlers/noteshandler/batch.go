@@ -0,0 +1,163 @@
+package noteshandler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"local/sidharthjs/todo/handlers/problem"
+	jwtutil "local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// Modes of a batch
+const (
+	batchAtomic     = "atomic"
+	batchBestEffort = "best_effort"
+)
+
+// batchRequest is the request of BatchNotes
+type batchRequest struct {
+	Mode       string           `json:"mode" validate:"oneof=atomic best_effort"`
+	Operations []batchOperation `json:"operations" validate:"required,max=batch"`
+}
+
+// batchOperation is an operation of a batch request. Note is the note to create
+// or the JSON merge patch of the note to update.
+type batchOperation struct {
+	Op      string          `json:"op" validate:"required,oneof=create update delete"`
+	ID      string          `json:"id" validate:"max=id"`
+	Version int             `json:"version" validate:"min=0"`
+	Note    json.RawMessage `json:"note"`
+}
+
+// batchResult is the result of an operation of a batch request
+type batchResult struct {
+	Index  int              `json:"index"`
+	Op     string           `json:"op"`
+	ID     string           `json:"id,omitempty"`
+	Status int              `json:"status"`
+	Error  *problem.Details `json:"error,omitempty"`
+}
+
+//BatchNotes is the handler method for creating, updating and deleting several notes
+//in a transaction. All the operations of an atomic batch, the default, are rolled
+//back when one fails. A best effort batch only skips the failed operations.
+func (nh *NotesHandler) BatchNotes(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	var req batchRequest
+	err = nh.Validator.DecodeBody(c, &req)
+	if err != nil {
+		return err
+	}
+	atomic := req.Mode != batchBestEffort
+
+	// Invalid operations fail without reaching the store
+	results := make([]notestore.BatchResult, len(req.Operations))
+	var ops []notestore.BatchOperation
+	var indexes []int
+	for i, operation := range req.Operations {
+		op, err := nh.batchOperation(operation, userID)
+		results[i].NoteID = op.Note.ID
+		if err != nil && atomic {
+			results = notestore.AbortBatch(results, i, err)
+			ops = nil
+			break
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		ops = append(ops, op)
+		indexes = append(indexes, i)
+	}
+
+	if len(ops) > 0 {
+		stored, err := nh.Store.Batch(c.UserContext(), userID, ops, atomic)
+		if err != nil {
+			return fmt.Errorf("unable to run batch of %d operations: %w", len(ops), err)
+		}
+		for i, result := range stored {
+			results[indexes[i]] = result
+		}
+	}
+
+	resp := make([]batchResult, len(results))
+	for i, result := range results {
+		resp[i] = batchResult{Index: i, Op: req.Operations[i].Op, ID: result.NoteID, Status: fiber.StatusOK}
+		if req.Operations[i].Op == string(notestore.BatchCreate) {
+			resp[i].Status = fiber.StatusCreated
+		}
+		if result.Err != nil {
+			d := problem.New(result.Err)
+			problem.LogInternal(c, d, fmt.Errorf("batch operation %d: %w", i, result.Err))
+			resp[i].Status, resp[i].Error = d.Status, &d
+		}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"results": resp,
+	})
+}
+
+// batchOperation validates an operation of a batch request and returns the
+// operation to run on the store
+func (nh *NotesHandler) batchOperation(operation batchOperation, userID string) (notestore.BatchOperation, error) {
+	op := notestore.BatchOperation{
+		Action: notestore.BatchAction(operation.Op),
+		Note:   notestore.Note{ID: operation.ID, UserID: userID, Version: operation.Version},
+	}
+	err := nh.Validator.Struct(operation)
+	if err != nil {
+		return op, err
+	}
+
+	switch op.Action {
+	case notestore.BatchCreate:
+		if operation.ID != "" || operation.Version != 0 {
+			return op, notestore.Invalidf("id and version must not be set to create a note")
+		}
+		var req createRequest
+		err = nh.Validator.Decode(operation.Note, &req)
+		if err != nil {
+			return op, err
+		}
+		op.Note, err = req.note(userID)
+		return op, err
+	case notestore.BatchUpdate:
+		if operation.ID == "" {
+			return op, notestore.Invalidf("id is required to update a note")
+		}
+		patch, fields, err := notestore.ApplyMergePatch(notestore.Note{}, operation.Note)
+		if err != nil {
+			return op, err
+		}
+		if len(fields) == 0 {
+			return op, notestore.Invalidf("note must change at least one field")
+		}
+		err = validatePatch(nh.Validator, patch, fields)
+		if err != nil {
+			return op, err
+		}
+		patch.ID, patch.UserID, patch.Version = operation.ID, userID, operation.Version
+		op.Note, op.Fields = patch, fields
+		return op, nil
+	}
+
+	if operation.ID == "" {
+		return op, notestore.Invalidf("id is required to delete a note")
+	}
+	if len(operation.Note) > 0 {
+		return op, notestore.Invalidf("note must not be set to delete a note")
+	}
+	return op, nil
+}
@@ -0,0 +1,77 @@
+package noteshandler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// etag formats the version of a note as an entity tag
+func etag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// parseETags parses the entity tags of an If-Match or If-None-Match header into
+// note versions, any is set for *. Weak tags are compared like strong ones and
+// tags that are not versions are ignored.
+func parseETags(header string) (versions []int, any bool) {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" {
+			any = true
+			continue
+		}
+		version, err := strconv.Atoi(strings.Trim(tag, `"`))
+		if err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+	return versions, any
+}
+
+// matchesETags reports whether the header lists the version of the note
+func matchesETags(header string, version int) bool {
+	versions, any := parseETags(header)
+	if any {
+		return true
+	}
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion returns the version of the note required by the If-Match header,
+// 0 when the header is absent or is *. When the header lists several versions the
+// current one is returned if it is listed, ErrVersionMismatch otherwise.
+func (nh *NotesHandler) ifMatchVersion(c *fiber.Ctx, noteID, userID string) (int, error) {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return 0, nil
+	}
+
+	versions, any := parseETags(header)
+	switch {
+	case any:
+		return 0, nil
+	case len(versions) == 0:
+		return 0, notestore.ErrVersionMismatch
+	case len(versions) == 1:
+		return versions[0], nil
+	}
+
+	note, err := nh.Store.Read(c.UserContext(), noteID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !matchesETags(header, note.Version) {
+		return 0, notestore.ErrVersionMismatch
+	}
+	return note.Version, nil
+}
@@ -0,0 +1,33 @@
+package noteshandler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseETags(t *testing.T) {
+	testCases := []struct {
+		description      string
+		header           string
+		expectedVersions []int
+		expectedAny      bool
+	}{
+		{description: "Single tag", header: `"3"`, expectedVersions: []int{3}},
+		{description: "Weak tags and spaces", header: ` W/"3", "5" `, expectedVersions: []int{3, 5}},
+		{description: "Any", header: "*", expectedAny: true},
+		{description: "Unknown tags are ignored", header: `"abc", "0", "7"`, expectedVersions: []int{7}},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		versions, any := parseETags(testCase.header)
+		assert.Equal(testCase.expectedVersions, versions, testCase.description)
+		assert.Equal(testCase.expectedAny, any, testCase.description)
+	}
+
+	assert.True(matchesETags(`"1", "2"`, 2))
+	assert.False(matchesETags(`"1", "2"`, 3))
+	assert.True(matchesETags("*", 3))
+	assert.Equal(`"4"`, etag(4))
+}
@@ -0,0 +1,209 @@
+package noteshandler
+
+import (
+	"fmt"
+
+	jwtutil "local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// itemStore returns the store as an ItemStore if the backend supports checklists
+func (nh *NotesHandler) itemStore() (notestore.ItemStore, error) {
+	store, ok := nh.Store.(notestore.ItemStore)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusNotImplemented, "checklists are not supported by the note store")
+	}
+	return store, nil
+}
+
+//ListItems is the handler method for listing the checklist items of a note
+func (nh *NotesHandler) ListItems(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.itemStore()
+	if err != nil {
+		return err
+	}
+
+	noteID := c.Params("note_id")
+	items, err := store.ListItems(c.UserContext(), noteID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to list the items of note '%s': %w", noteID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"items":    items,
+		"progress": notestore.ItemProgress(items).String(),
+	})
+}
+
+//CreateItem is the handler method for adding an item to the checklist of a note
+func (nh *NotesHandler) CreateItem(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.itemStore()
+	if err != nil {
+		return err
+	}
+
+	type request struct {
+		Text    string `json:"text" validate:"required,max=item"`
+		Checked bool   `json:"checked"`
+	}
+
+	var req request
+	err = nh.Validator.DecodeBody(c, &req)
+	if err != nil {
+		return err
+	}
+
+	text, err := notestore.NormalizeItemText(req.Text)
+	if err != nil {
+		return err
+	}
+
+	item := notestore.Item{
+		ID:      uuid.New().String(),
+		NoteID:  c.Params("note_id"),
+		Text:    text,
+		Checked: req.Checked,
+	}
+
+	err = store.CreateItem(c.UserContext(), userID, item)
+	if err != nil {
+		return fmt.Errorf("unable to create an item in note '%s': %w", item.NoteID, err)
+	}
+
+	log.Infof("item %s created successfully", item.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"msg": fmt.Sprintf("item '%s' created successfully", item.ID),
+	})
+}
+
+//UpdateItem is the handler method for updating a checklist item
+func (nh *NotesHandler) UpdateItem(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.itemStore()
+	if err != nil {
+		return err
+	}
+
+	type request struct {
+		Text    string `json:"text" validate:"required,max=item"`
+		Checked bool   `json:"checked"`
+	}
+
+	var req request
+	err = nh.Validator.DecodeBody(c, &req)
+	if err != nil {
+		return err
+	}
+
+	text, err := notestore.NormalizeItemText(req.Text)
+	if err != nil {
+		return err
+	}
+
+	item := notestore.Item{
+		ID:      c.Params("item_id"),
+		NoteID:  c.Params("note_id"),
+		Text:    text,
+		Checked: req.Checked,
+	}
+
+	err = store.UpdateItem(c.UserContext(), userID, item)
+	if err != nil {
+		return fmt.Errorf("unable to update item '%s': %w", item.ID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("item '%s' is updated successfully", item.ID),
+	})
+}
+
+//DeleteItem is the handler method for deleting a checklist item
+func (nh *NotesHandler) DeleteItem(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.itemStore()
+	if err != nil {
+		return err
+	}
+
+	itemID := c.Params("item_id")
+	err = store.DeleteItem(c.UserContext(), c.Params("note_id"), itemID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to delete the item '%s': %w", itemID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("item '%s' deleted successfully", itemID),
+	})
+}
+
+//ReorderItems is the handler method for reordering the checklist of a note
+func (nh *NotesHandler) ReorderItems(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.itemStore()
+	if err != nil {
+		return err
+	}
+
+	type request struct {
+		ItemIDs []string `json:"item_ids" validate:"required,max=items,eachmax=id"`
+	}
+
+	var req request
+	err = nh.Validator.DecodeBody(c, &req)
+	if err != nil {
+		return err
+	}
+
+	noteID := c.Params("note_id")
+	err = store.ReorderItems(c.UserContext(), noteID, userID, req.ItemIDs)
+	if err != nil {
+		return fmt.Errorf("unable to reorder the items of note '%s': %w", noteID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("items of note '%s' are reordered successfully", noteID),
+	})
+}
@@ -1,8 +1,12 @@
 package noteshandler
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
+	"local/sidharthjs/todo/handlers/validate"
 	jwtutil "local/sidharthjs/todo/jwt"
 	"local/sidharthjs/todo/notestore"
 
@@ -14,56 +18,79 @@ import (
 
 //NotesHandler struct defintion
 type NotesHandler struct {
-	Store notestore.NoteStore
+	Store     notestore.NoteStore
+	Validator *validate.Validator
 }
 
-//New returns NotesHandler
-func New(notestore notestore.NoteStore) *NotesHandler {
+//New returns NotesHandler validating the requests with the given limits
+func New(notestore notestore.NoteStore, limits validate.Limits) *NotesHandler {
 	return &NotesHandler{
-		Store: notestore,
+		Store:     notestore,
+		Validator: validate.New(limits),
 	}
 }
 
+// noteFields are the fields of the note requests
+type noteFields struct {
+	Title      string `json:"title" validate:"required,max=title"`
+	Body       string `json:"body" validate:"max=body"`
+	Status     string `json:"status" validate:"oneof=open in-progress done"`
+	Priority   int    `json:"priority" validate:"min=0,max=3"`
+	DueDate    string `json:"due_date" validate:"max=date"`
+	Recurrence string `json:"recurrence" validate:"max=recurrence"`
+}
+
+// createRequest is the request creating a note
+type createRequest struct {
+	noteFields
+	Tags      []string `json:"tags" validate:"max=tags,eachmax=tag"`
+	ProjectID string   `json:"project_id" validate:"max=id"`
+}
+
+// note returns the normalized note created by the request with a new ID
+func (req createRequest) note(userID string) (notestore.Note, error) {
+	tags, err := notestore.NormalizeTags(req.Tags)
+	if err != nil {
+		return notestore.Note{}, err
+	}
+
+	return notestore.NormalizeTodo(notestore.Note{
+		ID:         uuid.New().String(),
+		Title:      req.Title,
+		Body:       req.Body,
+		UserID:     userID,
+		Tags:       tags,
+		ProjectID:  req.ProjectID,
+		Status:     notestore.Status(req.Status),
+		Priority:   req.Priority,
+		DueDate:    req.DueDate,
+		Recurrence: req.Recurrence,
+	})
+}
+
 //CreateNote is the handler method for creating a note
 func (nh *NotesHandler) CreateNote(c *fiber.Ctx) error {
 	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
 	if err != nil {
 		log.Errorf("error in reading user details in jwt token: %s", err)
 
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return fiber.ErrUnauthorized
 	}
 
-	type request struct {
-		Title string `json:"title"`
-		Body  string `json:"body"`
-	}
-
-	var req request
-	err = c.BodyParser(&req)
+	var req createRequest
+	err = nh.Validator.DecodeBody(c, &req)
 	if err != nil {
-		log.Errorf("unable to parse the request: %s", err)
-
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "unable to create a note",
-		})
+		return err
 	}
 
-	note := notestore.Note{
-		ID:     uuid.New().String(),
-		Title:  req.Title,
-		Body:   req.Body,
-		UserID: userID,
+	note, err := req.note(userID)
+	if err != nil {
+		return err
 	}
 
 	err = nh.Store.Create(c.UserContext(), note)
 	if err != nil {
-		log.Errorf("unable to create a note: %s", err)
-
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "unable to create a note",
-		})
+		return fmt.Errorf("unable to create a note: %w", err)
 	}
 
 	log.Infof("note %s created successfully", note.ID)
@@ -80,22 +107,38 @@ func (nh *NotesHandler) ReadNote(c *fiber.Ctx) error {
 	if err != nil {
 		log.Errorf("error in reading user details in jwt token: %s", err)
 
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return fiber.ErrUnauthorized
 	}
 
 	noteID := c.Params("note_id")
 	note, err := nh.Store.Read(c.UserContext(), noteID, userID)
 	if err != nil {
-		log.Errorf("unable to read note '%s': %s", noteID, err)
+		return fmt.Errorf("unable to read note '%s': %w", noteID, err)
+	}
 
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error in reading the note",
-		})
+	c.Set(fiber.HeaderETag, etag(note.Version))
+	if header := c.Get(fiber.HeaderIfNoneMatch); header != "" && matchesETags(header, note.Version) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	items, ok := nh.Store.(notestore.ItemStore)
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(note)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(note)
+	// Include the checklist of the note
+	response := struct {
+		notestore.Note
+		Items    []notestore.Item
+		Progress string
+	}{Note: note}
+	response.Items, err = items.ListItems(c.UserContext(), noteID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to list the items of note '%s': %w", noteID, err)
+	}
+	response.Progress = notestore.ItemProgress(response.Items).String()
+
+	return c.Status(fiber.StatusOK).JSON(response)
 }
 
 //ReadNotes is the handler method for reading multiple notes
@@ -105,21 +148,115 @@ func (nh *NotesHandler) ReadNotes(c *fiber.Ctx) error {
 	if err != nil {
 		log.Errorf("error in reading user details in jwt token: %s", err)
 
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return fiber.ErrUnauthorized
 	}
 
-	notes, err := nh.Store.ReadAll(c.UserContext(), userID)
+	opts, err := parseReadAllOptions(c)
 	if err != nil {
-		log.Errorf("error in reading all notes: %s", err)
+		return err
+	}
 
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error in reading the notes",
-		})
+	page, err := nh.Store.ReadAll(c.UserContext(), userID, opts)
+	if err != nil {
+		return fmt.Errorf("error in reading all notes: %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"notes":       page.Notes,
+		"next_cursor": page.NextCursor,
+		"prev_cursor": page.PrevCursor,
+	})
+}
+
+// parseReadAllOptions reads the pagination, sorting and filtering query parameters.
+// The project is taken from the project_id path parameter when listing the notes of a project.
+func parseReadAllOptions(c *fiber.Ctx) (notestore.ReadAllOptions, error) {
+	opts := notestore.ReadAllOptions{
+		Cursor:    c.Query("cursor"),
+		SortBy:    notestore.SortField(c.Query("sort")),
+		Order:     notestore.SortOrder(c.Query("order")),
+		Due:       notestore.DueFilter(c.Query("due")),
+		ProjectID: c.Query("project"),
+	}
+	if projectID := c.Params("project_id"); projectID != "" {
+		opts.ProjectID = projectID
+	}
+
+	for _, status := range c.Context().QueryArgs().PeekMulti("status") {
+		opts.Statuses = append(opts.Statuses, notestore.Status(status))
+	}
+
+	for _, tag := range c.Context().QueryArgs().PeekMulti("tag") {
+		opts.Tags = append(opts.Tags, string(tag))
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return opts, notestore.Invalidf("invalid limit '%s'", limit)
+		}
+		opts.Limit = n
+	}
+
+	dates := []struct {
+		param string
+		dst   *time.Time
+	}{
+		{"created_after", &opts.CreatedAfter},
+		{"created_before", &opts.CreatedBefore},
+		{"updated_after", &opts.UpdatedAfter},
+		{"updated_before", &opts.UpdatedBefore},
+		{"due_after", &opts.DueAfter},
+		{"due_before", &opts.DueBefore},
+	}
+	for _, d := range dates {
+		v := c.Query(d.param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return opts, notestore.Invalidf("invalid %s '%s', expected RFC 3339 timestamp", d.param, v)
+		}
+		*d.dst = t
 	}
 
-	return c.Status(fiber.StatusOK).JSON(notes)
+	_, _, err := opts.Normalize()
+	return opts, err
+}
+
+//SearchNotes is the handler method for searching the notes
+func (nh *NotesHandler) SearchNotes(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	query := c.Query("q")
+	_, err = notestore.ParseSearchQuery(query)
+	if err != nil {
+		return err
+	}
+
+	limit := 0
+	if l := c.Query("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			return notestore.Invalidf("invalid limit '%s'", l)
+		}
+	}
+
+	results, err := nh.Store.Search(c.UserContext(), userID, query, limit)
+	if err != nil {
+		return fmt.Errorf("error in searching notes: %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"results": results,
+	})
 }
 
 //UpdateNote is the handler method for updating a note
@@ -129,44 +266,81 @@ func (nh *NotesHandler) UpdateNote(c *fiber.Ctx) error {
 	if err != nil {
 		log.Errorf("error in reading user details in jwt token: %s", err)
 
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return fiber.ErrUnauthorized
 	}
 
-	type request struct {
-		Title string `json:"title"`
-		Body  string `json:"body"`
+	var req noteFields
+	err = nh.Validator.DecodeBody(c, &req)
+	if err != nil {
+		return err
 	}
 
-	var req request
-	err = c.BodyParser(&req)
+	note, err := notestore.NormalizeTodo(notestore.Note{
+		Title:      req.Title,
+		ID:         c.Params("note_id"),
+		Body:       req.Body,
+		UserID:     userID,
+		Status:     notestore.Status(req.Status),
+		Priority:   req.Priority,
+		DueDate:    req.DueDate,
+		Recurrence: req.Recurrence,
+	})
 	if err != nil {
-		log.Errorf("unable to parse the request: %s", err)
+		return err
+	}
 
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "unable to create a note",
-		})
+	version, err := nh.ifMatchVersion(c, note.ID, userID)
+	note.Version = version
+	// Without a version to match, the note is updated from its current version so
+	// that the version of the update is known for its ETag
+	if err == nil && version == 0 {
+		var current notestore.Note
+		current, err = nh.Store.Read(c.UserContext(), note.ID, userID)
+		note.Version = current.Version
+	}
+	if err == nil {
+		err = nh.Store.Update(c.UserContext(), note)
+	}
+	if errors.Is(err, notestore.ErrVersionMismatch) && version == 0 {
+		err = notestore.Conflictf("note '%s' was changed concurrently, try again", note.ID)
 	}
+	if err != nil {
+		return fmt.Errorf("unable to update note '%s': %w", note.ID, err)
+	}
+
+	c.Set(fiber.HeaderETag, etag(note.Version+1))
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("note '%s' is updated successfully", note.ID),
+	})
+}
+
+//CompleteNote is the handler method for marking a note as done
+func (nh *NotesHandler) CompleteNote(c *fiber.Ctx) error {
 
-	note := notestore.Note{
-		Title:  req.Title,
-		ID:     c.Params("note_id"),
-		Body:   req.Body,
-		UserID: userID,
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
 	}
+	noteID := c.Params("note_id")
 
-	err = nh.Store.Update(c.UserContext(), note)
+	next, err := nh.Store.Complete(c.UserContext(), noteID, userID)
 	if err != nil {
-		log.Errorf("unable to update note '%s': %s", note.ID, err)
+		return fmt.Errorf("unable to complete the note '%s': %w", noteID, err)
+	}
 
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error in updating the note",
+	if next.ID == "" {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"msg": fmt.Sprintf("note '%s' completed successfully", noteID),
 		})
 	}
 
+	log.Infof("note %s created as next occurrence of note %s", next.ID, noteID)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"msg": fmt.Sprintf("note '%s' is updated successfully", note.ID),
+		"msg":  fmt.Sprintf("note '%s' completed successfully, next occurrence is note '%s'", noteID, next.ID),
+		"next": next,
 	})
 }
 
@@ -177,21 +351,23 @@ func (nh *NotesHandler) DeleteNote(c *fiber.Ctx) error {
 	if err != nil {
 		log.Errorf("error in reading user details in jwt token: %s", err)
 
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return fiber.ErrUnauthorized
 	}
 	noteID := c.Params("note_id")
 
-	err = nh.Store.Delete(c.UserContext(), noteID, userID)
+	version, err := nh.ifMatchVersion(c, noteID, userID)
+	if err == nil {
+		err = nh.Store.Delete(c.UserContext(), noteID, userID, version)
+	}
 	if err != nil {
-		log.Errorf("unable to delete the note '%s': %s", noteID, err)
+		return fmt.Errorf("unable to delete the note '%s': %w", noteID, err)
+	}
 
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "error in deleting the note",
+	if _, ok := nh.Store.(notestore.TrashStore); ok {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"msg": fmt.Sprintf("note '%s' moved to the trash successfully", noteID),
 		})
 	}
-
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"msg": fmt.Sprintf("note '%s' deleted successfully", noteID),
 	})
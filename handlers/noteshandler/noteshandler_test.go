@@ -0,0 +1,698 @@
+package noteshandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"local/sidharthjs/todo/handlers/problem"
+	"local/sidharthjs/todo/handlers/validate"
+	"local/sidharthjs/todo/notestore"
+	"local/sidharthjs/todo/notestore/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// testApp returns an app serving the notes of the store, the requests are
+// authenticated as the user in the X-User header
+func testApp(store notestore.NoteStore) *fiber.App {
+	nh := New(store, validate.DefaultLimits())
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler, JSONEncoder: json.Marshal})
+	app.Use(func(c *fiber.Ctx) error {
+		// The header is copied as Fiber reuses its buffer once the request is handled
+		userID := utils.CopyString(c.Get("X-User"))
+		c.Locals("user", jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": userID, "username": "john101"}))
+		return c.Next()
+	})
+	app.Post("/notes", nh.CreateNote)
+	app.Post("/notes\\:batch", nh.BatchNotes)
+	app.Get("/notes/:note_id", nh.ReadNote)
+	app.Put("/notes/:note_id", nh.UpdateNote)
+	app.Patch("/notes/:note_id", nh.PatchNote)
+	app.Delete("/notes/:note_id", nh.DeleteNote)
+	app.Post("/notes/:note_id/tags", nh.AddTags)
+	app.Delete("/notes/:note_id/tags/:tag", nh.RemoveTag)
+	app.Get("/tags", nh.ListTags)
+	app.Post("/tags/merge", nh.MergeTags)
+	app.Put("/tags/:tag", nh.RenameTag)
+	app.Get("/notes/:note_id/items", nh.ListItems)
+	app.Post("/notes/:note_id/items", nh.CreateItem)
+	app.Post("/notes/:note_id/items/reorder", nh.ReorderItems)
+	app.Put("/notes/:note_id/items/:item_id", nh.UpdateItem)
+	app.Delete("/notes/:note_id/items/:item_id", nh.DeleteItem)
+	app.Put("/notes/:note_id/project", nh.MoveNote)
+	app.Get("/projects", nh.ListProjects)
+	app.Post("/projects", nh.CreateProject)
+	app.Get("/projects/:project_id", nh.ReadProject)
+	app.Put("/projects/:project_id", nh.RenameProject)
+	app.Delete("/projects/:project_id", nh.DeleteProject)
+	app.Post("/projects/:project_id/archive", nh.ArchiveProject)
+	app.Post("/projects/:project_id/unarchive", nh.UnarchiveProject)
+	app.Get("/projects/:project_id/notes", nh.ReadNotes)
+	app.Get("/trash", nh.ListTrash)
+	app.Post("/trash/:note_id/restore", nh.RestoreNote)
+	app.Delete("/trash/:note_id", nh.PurgeNote)
+	app.Get("/notes/:note_id/revisions", nh.ListRevisions)
+	app.Get("/notes/:note_id/revisions/diff", nh.DiffRevisions)
+	app.Get("/notes/:note_id/revisions/:revision", nh.ReadRevision)
+	app.Post("/notes/:note_id/revisions/:revision/restore", nh.RestoreRevision)
+	return app
+}
+
+// send sends a request as the user and returns the status and body of the response
+func send(t *testing.T, app *fiber.App, userID, method, path, body string, headers ...string) (int, string) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set("X-User", userID)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := app.Test(req)
+	assert.Nil(t, err)
+	b, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(b)
+}
+
+func TestNotesHandler(t *testing.T) {
+	store := memory.New()
+	app := testApp(store)
+
+	assert := assert.New(t)
+	status, _ := send(t, app, "1001", "POST", "/notes", `{"title": "Groceries", "tags": ["home"]}`)
+	assert.Equal(fiber.StatusCreated, status)
+	page, err := store.ReadAll(context.Background(), "1001", notestore.ReadAllOptions{})
+	assert.Nil(err)
+	if !assert.Len(page.Notes, 1) {
+		return
+	}
+	path := "/notes/" + page.Notes[0].ID
+
+	status, body := send(t, app, "1001", "GET", path, "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Contains(body, `"Title":"Groceries"`)
+
+	// The notes of other users are not found
+	status, body = send(t, app, "1002", "GET", path, "")
+	assert.Equal(fiber.StatusNotFound, status)
+	assert.Contains(body, `"code":"not_found"`)
+
+	status, body = send(t, app, "1001", "POST", "/notes", `{"title": ""}`)
+	assert.Equal(fiber.StatusBadRequest, status)
+	assert.Contains(body, `"rule":"required"`)
+
+	status, _ = send(t, app, "1001", "PATCH", path, `{"status": "done"}`, fiber.HeaderIfMatch, `"7"`)
+	assert.Equal(fiber.StatusPreconditionFailed, status)
+	status, _ = send(t, app, "1001", "PATCH", path, `{"status": "done"}`, fiber.HeaderIfMatch, `"1"`)
+	assert.Equal(fiber.StatusOK, status)
+	note, err := store.Read(context.Background(), page.Notes[0].ID, "1001")
+	assert.Nil(err)
+	assert.Equal(notestore.StatusDone, note.Status)
+
+	status, _ = send(t, app, "1001", "DELETE", path, "")
+	assert.Equal(fiber.StatusCreated, status)
+	status, _ = send(t, app, "1001", "GET", path, "")
+	assert.Equal(fiber.StatusNotFound, status)
+}
+
+func TestBatchNotes(t *testing.T) {
+	store := memory.New()
+	app := testApp(store)
+	noteID := "6f1c6a4e-0a3b-4c1e-9d4a-1e2f3a4b5c6d"
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Groceries", UserID: "1001"})
+	assert.Nil(t, err)
+
+	type result struct {
+		Index  int              `json:"index"`
+		Op     string           `json:"op"`
+		Status int              `json:"status"`
+		Error  *problem.Details `json:"error"`
+	}
+	batch := func(body string) []result {
+		status, respBody := send(t, app, "1001", "POST", "/notes:batch", body)
+		assert.Equal(t, fiber.StatusOK, status, respBody)
+		var resp struct {
+			Results []result `json:"results"`
+		}
+		assert.Nil(t, json.Unmarshal([]byte(respBody), &resp))
+		return resp.Results
+	}
+
+	assert := assert.New(t)
+	results := batch(`{"operations": [
+		{"op": "create", "note": {"title": "Laundry"}},
+		{"op": "update", "id": "` + noteID + `", "note": {"priority": 9}}
+	]}`)
+	if assert.Len(results, 2) {
+		assert.Equal(fiber.StatusFailedDependency, results[0].Status)
+		assert.Equal(problem.CodeBatchAborted, results[0].Error.Code)
+		assert.Equal(fiber.StatusBadRequest, results[1].Status)
+		assert.Equal("priority", results[1].Error.Errors[0].Field)
+	}
+	page, err := store.ReadAll(context.Background(), "1001", notestore.ReadAllOptions{})
+	assert.Nil(err)
+	assert.Len(page.Notes, 1)
+
+	results = batch(`{"mode": "best_effort", "operations": [
+		{"op": "create", "note": {"title": "Laundry"}},
+		{"op": "update", "id": "` + noteID + `", "version": 1, "note": {"title": "Shopping"}},
+		{"op": "delete", "id": "unknown"}
+	]}`)
+	if assert.Len(results, 3) {
+		assert.Equal(fiber.StatusCreated, results[0].Status)
+		assert.Equal(fiber.StatusOK, results[1].Status)
+		assert.Equal(fiber.StatusNotFound, results[2].Status)
+	}
+	note, err := store.Read(context.Background(), noteID, "1001")
+	assert.Nil(err)
+	assert.Equal("Shopping", note.Title)
+
+	status, _ := send(t, app, "1001", "POST", "/notes:batch", `{"mode": "all", "operations": []}`)
+	assert.Equal(fiber.StatusBadRequest, status)
+}
+
+// failingStore fails the operations of the batches with an internal error
+type failingStore struct {
+	*memory.Store
+}
+
+func (s failingStore) Batch(ctx context.Context, userID string, ops []notestore.BatchOperation, atomic bool) ([]notestore.BatchResult, error) {
+	results := make([]notestore.BatchResult, len(ops))
+	for i := range results {
+		results[i].Err = errors.New("disk full")
+	}
+	return results, nil
+}
+
+func TestBatchNotesLogsInternalErrors(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	app := testApp(failingStore{Store: memory.New()})
+	status, body := send(t, app, "1001", "POST", "/notes:batch", `{"mode": "best_effort", "operations": [
+		{"op": "create", "note": {"title": "Laundry"}}
+	]}`)
+
+	// The cause of the error is logged but not sent to the client
+	assert := assert.New(t)
+	assert.Equal(fiber.StatusOK, status)
+	assert.Contains(body, `"status":500`)
+	assert.NotContains(body, "disk full")
+	assert.Contains(logs.String(), "POST /notes:batch (request ): batch operation 0: disk full")
+}
+
+// racingStore changes the title of a note right after it is read once, as a concurrent
+// request would
+type racingStore struct {
+	*memory.Store
+	raced bool
+}
+
+func (s *racingStore) Read(ctx context.Context, noteID, userID string) (notestore.Note, error) {
+	note, err := s.Store.Read(ctx, noteID, userID)
+	if err == nil && !s.raced {
+		s.raced = true
+		err = s.Store.UpdateFields(ctx, notestore.Note{ID: noteID, UserID: userID, Title: "Shopping"}, []notestore.NoteField{notestore.FieldTitle})
+	}
+	return note, err
+}
+
+func TestPatchNoteRace(t *testing.T) {
+	store := &racingStore{Store: memory.New()}
+	app := testApp(store)
+	noteID := "6f1c6a4e-0a3b-4c1e-9d4a-1e2f3a4b5c6d"
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Groceries", UserID: "1001"})
+	assert.Nil(t, err)
+
+	// The test passes on the note as read, but the note changes before it is updated
+	assert := assert.New(t)
+	patch := `[{"op": "test", "path": "/title", "value": "Groceries"}, {"op": "replace", "path": "/body", "value": "Milk"}]`
+	status, body := send(t, app, "1001", "PATCH", "/notes/"+noteID, patch, fiber.HeaderContentType, mimeJSONPatch)
+	assert.Equal(fiber.StatusConflict, status, body)
+	note, err := store.Read(context.Background(), noteID, "1001")
+	assert.Nil(err)
+	assert.Equal("Shopping", note.Title)
+	assert.Equal("", note.Body)
+
+	// The test fails on the note as read now
+	status, body = send(t, app, "1001", "PATCH", "/notes/"+noteID, patch, fiber.HeaderContentType, mimeJSONPatch)
+	assert.Equal(fiber.StatusConflict, status, body)
+	assert.Contains(body, "test of '/title' failed")
+
+	// Every update returns the entity tag of the new version
+	req := httptest.NewRequest("PATCH", "/notes/"+noteID, strings.NewReader(`{"body": "Milk"}`))
+	req.Header.Set("X-User", "1001")
+	resp, err := app.Test(req)
+	if assert.Nil(err) {
+		assert.Equal(fiber.StatusOK, resp.StatusCode)
+		assert.Equal(`"3"`, resp.Header.Get(fiber.HeaderETag))
+	}
+}
+
+func TestUpdateNoteETag(t *testing.T) {
+	store := &racingStore{Store: memory.New(), raced: true}
+	app := testApp(store)
+	noteID := "6f1c6a4e-0a3b-4c1e-9d4a-1e2f3a4b5c6d"
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Groceries", UserID: "1001"})
+	assert.Nil(t, err)
+	// put sends an update and returns its status and entity tag
+	put := func(title, ifMatch string) (int, string) {
+		req := httptest.NewRequest("PUT", "/notes/"+noteID, strings.NewReader(`{"title": "`+title+`"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set("X-User", "1001")
+		if ifMatch != "" {
+			req.Header.Set(fiber.HeaderIfMatch, ifMatch)
+		}
+		resp, err := app.Test(req)
+		if !assert.Nil(t, err) {
+			return 0, ""
+		}
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderETag)
+	}
+
+	// The unconditional updates return the entity tag of the new version too
+	assert := assert.New(t)
+	status, tag := put("Shopping", "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal(`"2"`, tag)
+	status, tag = put("Groceries", tag)
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal(`"3"`, tag)
+	status, _ = put("Shopping", `"2"`)
+	assert.Equal(fiber.StatusPreconditionFailed, status)
+
+	// The note changing between its read and its update is a conflict
+	store.raced = false
+	status, _ = put("Laundry", "")
+	assert.Equal(fiber.StatusConflict, status)
+	note, err := store.Read(context.Background(), noteID, "1001")
+	assert.Nil(err)
+	assert.Equal("Shopping", note.Title)
+}
+
+// basicStore is a note store without any of the optional features
+type basicStore struct {
+	notestore.NoteStore
+}
+
+func TestTags(t *testing.T) {
+	store := memory.New()
+	app := testApp(store)
+	noteID := "6f1c6a4e-0a3b-4c1e-9d4a-1e2f3a4b5c6d"
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Groceries", UserID: "1001", Tags: []string{"home"}})
+	assert.Nil(t, err)
+	path := "/notes/" + noteID + "/tags"
+	// listTags returns the tags of the user
+	listTags := func() []notestore.Tag {
+		status, body := send(t, app, "1001", "GET", "/tags", "")
+		assert.Equal(t, fiber.StatusOK, status, body)
+		var resp struct {
+			Tags []notestore.Tag `json:"tags"`
+		}
+		assert.Nil(t, json.Unmarshal([]byte(body), &resp), body)
+		return resp.Tags
+	}
+
+	// The tags are normalized
+	assert := assert.New(t)
+	status, body := send(t, app, "1001", "POST", path, `{"tags": [" Work ", "home"]}`)
+	assert.Equal(fiber.StatusOK, status, body)
+	assert.Equal([]notestore.Tag{{Name: "home", Count: 1}, {Name: "work", Count: 1}}, listTags())
+
+	status, body = send(t, app, "1001", "POST", path, `{"tags": ["to/do"]}`)
+	assert.Equal(fiber.StatusBadRequest, status)
+	assert.Contains(body, `"code":"validation_failed"`)
+	status, _ = send(t, app, "1001", "POST", path, `{}`)
+	assert.Equal(fiber.StatusBadRequest, status)
+	status, _ = send(t, app, "1001", "POST", path, `tags=work`, fiber.HeaderContentType, fiber.MIMETextPlain)
+	assert.Equal(fiber.StatusUnsupportedMediaType, status)
+
+	// The notes and tags of other users are not found
+	status, body = send(t, app, "1002", "POST", path, `{"tags": ["work"]}`)
+	assert.Equal(fiber.StatusNotFound, status)
+	assert.Contains(body, `"code":"not_found"`)
+	status, _ = send(t, app, "1002", "DELETE", path+"/work", "")
+	assert.Equal(fiber.StatusNotFound, status)
+	status, _ = send(t, app, "1002", "PUT", "/tags/work", `{"name": "office"}`)
+	assert.Equal(fiber.StatusNotFound, status)
+
+	// The tag in the path is unescaped
+	status, body = send(t, app, "1001", "PUT", "/tags/work", `{"name": "Office Hours"}`)
+	assert.Equal(fiber.StatusOK, status, body)
+	status, body = send(t, app, "1001", "PUT", "/tags/office%20hours", `{"name": "home"}`)
+	assert.Equal(fiber.StatusConflict, status)
+	assert.Contains(body, `"code":"conflict"`)
+	status, body = send(t, app, "1001", "DELETE", path+"/office%20hours", "")
+	assert.Equal(fiber.StatusOK, status, body)
+	assert.Equal([]notestore.Tag{{Name: "home", Count: 1}}, listTags())
+
+	status, body = send(t, app, "1001", "POST", path, `{"tags": ["errands"]}`)
+	assert.Equal(fiber.StatusOK, status, body)
+	status, body = send(t, app, "1001", "POST", "/tags/merge", `{"sources": ["errands"], "target": "home"}`)
+	assert.Equal(fiber.StatusOK, status, body)
+	assert.Equal([]notestore.Tag{{Name: "home", Count: 1}}, listTags())
+	status, _ = send(t, app, "1001", "POST", "/tags/merge", `{"sources": [], "target": "home"}`)
+	assert.Equal(fiber.StatusBadRequest, status)
+
+	// The stores without tags do not implement the routes
+	status, body = send(t, testApp(basicStore{store}), "1001", "GET", "/tags", "")
+	assert.Equal(fiber.StatusNotImplemented, status)
+	assert.Contains(body, "tags are not supported by the note store")
+}
+
+func TestItems(t *testing.T) {
+	store := memory.New()
+	app := testApp(store)
+	noteID := "6f1c6a4e-0a3b-4c1e-9d4a-1e2f3a4b5c6d"
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Groceries", UserID: "1001"})
+	assert.Nil(t, err)
+	path := "/notes/" + noteID + "/items"
+	// listItems returns the checklist of the note and its progress
+	listItems := func() ([]notestore.Item, string) {
+		status, body := send(t, app, "1001", "GET", path, "")
+		assert.Equal(t, fiber.StatusOK, status, body)
+		var resp struct {
+			Items    []notestore.Item `json:"items"`
+			Progress string           `json:"progress"`
+		}
+		assert.Nil(t, json.Unmarshal([]byte(body), &resp), body)
+		return resp.Items, resp.Progress
+	}
+
+	assert := assert.New(t)
+	status, body := send(t, app, "1001", "POST", path, `{"text": " Milk "}`)
+	assert.Equal(fiber.StatusCreated, status, body)
+	status, body = send(t, app, "1001", "POST", path, `{"text": "Eggs", "checked": true}`)
+	assert.Equal(fiber.StatusCreated, status, body)
+	items, progress := listItems()
+	if !assert.Len(items, 2) {
+		return
+	}
+	assert.Equal("Milk", items[0].Text)
+	assert.Equal(noteID, items[0].NoteID)
+	assert.Equal("1/2", progress)
+
+	status, body = send(t, app, "1001", "POST", path, `{"text": ""}`)
+	assert.Equal(fiber.StatusBadRequest, status)
+	assert.Contains(body, `"rule":"required"`)
+	status, _ = send(t, app, "1001", "POST", path, `text=Milk`, fiber.HeaderContentType, fiber.MIMETextPlain)
+	assert.Equal(fiber.StatusUnsupportedMediaType, status)
+
+	// The checklists of the notes of other users are not found
+	status, _ = send(t, app, "1002", "GET", path, "")
+	assert.Equal(fiber.StatusNotFound, status)
+	status, _ = send(t, app, "1002", "POST", path, `{"text": "Bread"}`)
+	assert.Equal(fiber.StatusNotFound, status)
+	status, _ = send(t, app, "1002", "PUT", path+"/"+items[0].ID, `{"text": "Bread"}`)
+	assert.Equal(fiber.StatusNotFound, status)
+	status, _ = send(t, app, "1002", "DELETE", path+"/"+items[0].ID, "")
+	assert.Equal(fiber.StatusNotFound, status)
+	status, _ = send(t, app, "1001", "PUT", path+"/unknown", `{"text": "Bread"}`)
+	assert.Equal(fiber.StatusNotFound, status)
+
+	status, body = send(t, app, "1001", "PUT", path+"/"+items[0].ID, `{"text": "Oat milk", "checked": true}`)
+	assert.Equal(fiber.StatusOK, status, body)
+	status, body = send(t, app, "1001", "POST", path+"/reorder", `{"item_ids": ["`+items[1].ID+`", "`+items[0].ID+`"]}`)
+	assert.Equal(fiber.StatusOK, status, body)
+	reordered, progress := listItems()
+	if assert.Len(reordered, 2) {
+		assert.Equal(items[1].ID, reordered[0].ID)
+		assert.Equal("Oat milk", reordered[1].Text)
+		assert.Equal("2/2", progress)
+	}
+	status, body = send(t, app, "1001", "POST", path+"/reorder", `{"item_ids": ["`+items[0].ID+`"]}`)
+	assert.Equal(fiber.StatusBadRequest, status)
+	assert.Contains(body, "the order must list all 2 items of the note")
+
+	status, body = send(t, app, "1001", "DELETE", path+"/"+items[0].ID, "")
+	assert.Equal(fiber.StatusOK, status, body)
+	items, progress = listItems()
+	assert.Len(items, 1)
+	assert.Equal("1/1", progress)
+
+	status, _ = send(t, testApp(basicStore{store}), "1001", "GET", path, "")
+	assert.Equal(fiber.StatusNotImplemented, status)
+}
+
+func TestProjects(t *testing.T) {
+	store := memory.New()
+	app := testApp(store)
+	noteID := "6f1c6a4e-0a3b-4c1e-9d4a-1e2f3a4b5c6d"
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Groceries", UserID: "1001"})
+	assert.Nil(t, err)
+	// listProjects returns the projects of the user
+	listProjects := func(query string) []notestore.Project {
+		status, body := send(t, app, "1001", "GET", "/projects"+query, "")
+		assert.Equal(t, fiber.StatusOK, status, body)
+		var resp struct {
+			Projects []notestore.Project `json:"projects"`
+		}
+		assert.Nil(t, json.Unmarshal([]byte(body), &resp), body)
+		return resp.Projects
+	}
+	// projectNotes returns the IDs of the notes of the project
+	projectNotes := func(projectID string) []string {
+		status, body := send(t, app, "1001", "GET", "/projects/"+projectID+"/notes", "")
+		assert.Equal(t, fiber.StatusOK, status, body)
+		var page notestore.Page
+		assert.Nil(t, json.Unmarshal([]byte(body), &page), body)
+		ids := []string{}
+		for _, note := range page.Notes {
+			ids = append(ids, note.ID)
+		}
+		return ids
+	}
+
+	assert := assert.New(t)
+	status, body := send(t, app, "1001", "POST", "/projects", `{"name": " Home "}`)
+	assert.Equal(fiber.StatusCreated, status, body)
+	status, body = send(t, app, "1001", "POST", "/projects", `{"name": "Home"}`)
+	assert.Equal(fiber.StatusConflict, status)
+	assert.Contains(body, `"code":"conflict"`)
+	status, _ = send(t, app, "1001", "POST", "/projects", `{"name": ""}`)
+	assert.Equal(fiber.StatusBadRequest, status)
+	status, _ = send(t, app, "1001", "POST", "/projects", `name=Home`, fiber.HeaderContentType, fiber.MIMETextPlain)
+	assert.Equal(fiber.StatusUnsupportedMediaType, status)
+	projects := listProjects("")
+	if !assert.Len(projects, 1) {
+		return
+	}
+	projectID := projects[0].ID
+	path := "/projects/" + projectID
+
+	status, body = send(t, app, "1001", "PUT", "/notes/"+noteID+"/project", `{"project_id": "`+projectID+`"}`)
+	assert.Equal(fiber.StatusOK, status, body)
+	assert.Equal([]string{noteID}, projectNotes(projectID))
+	var project notestore.Project
+	status, body = send(t, app, "1001", "GET", path, "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Nil(json.Unmarshal([]byte(body), &project), body)
+	assert.Equal("Home", project.Name)
+	assert.Equal(1, project.NoteCount)
+
+	// The projects of other users are not found
+	for _, req := range []struct{ method, path, body string }{
+		{"GET", path, ""},
+		{"PUT", path, `{"name": "Work"}`},
+		{"POST", path + "/archive", ""},
+		{"DELETE", path, ""},
+		{"PUT", "/notes/" + noteID + "/project", `{"project_id": ""}`},
+	} {
+		status, body = send(t, app, "1002", req.method, req.path, req.body)
+		assert.Equal(fiber.StatusNotFound, status, req.method+" "+req.path)
+		assert.Contains(body, `"code":"not_found"`)
+	}
+
+	status, body = send(t, app, "1001", "PUT", path, `{"name": "House"}`)
+	assert.Equal(fiber.StatusOK, status, body)
+
+	// The archived projects are read-only and only listed on demand
+	status, body = send(t, app, "1001", "POST", path+"/archive", "")
+	assert.Equal(fiber.StatusOK, status, body)
+	assert.Empty(listProjects(""))
+	if archived := listProjects("?archived=true"); assert.Len(archived, 1) {
+		assert.Equal("House", archived[0].Name)
+		assert.True(archived[0].Archived)
+	}
+	status, body = send(t, app, "1001", "PUT", "/notes/"+noteID+"/project", `{"project_id": ""}`)
+	assert.Equal(fiber.StatusConflict, status, body)
+	status, body = send(t, app, "1001", "POST", path+"/unarchive", "")
+	assert.Equal(fiber.StatusOK, status, body)
+
+	// The notes of a deleted project are moved to the inbox
+	status, body = send(t, app, "1001", "DELETE", path, "")
+	assert.Equal(fiber.StatusOK, status, body)
+	status, _ = send(t, app, "1001", "GET", path, "")
+	assert.Equal(fiber.StatusNotFound, status)
+	note, err := store.Read(context.Background(), noteID, "1001")
+	assert.Nil(err)
+	assert.Equal("", note.ProjectID)
+
+	status, _ = send(t, testApp(basicStore{store}), "1001", "GET", "/projects", "")
+	assert.Equal(fiber.StatusNotImplemented, status)
+}
+
+func TestTrash(t *testing.T) {
+	store := memory.New()
+	app := testApp(store)
+	noteID := "6f1c6a4e-0a3b-4c1e-9d4a-1e2f3a4b5c6d"
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Groceries", UserID: "1001"})
+	assert.Nil(t, err)
+	// listTrash returns the IDs of the deleted notes of the user
+	listTrash := func(userID string) []string {
+		status, body := send(t, app, userID, "GET", "/trash", "")
+		assert.Equal(t, fiber.StatusOK, status, body)
+		var resp struct {
+			Notes []notestore.Note `json:"notes"`
+		}
+		assert.Nil(t, json.Unmarshal([]byte(body), &resp), body)
+		ids := []string{}
+		for _, note := range resp.Notes {
+			ids = append(ids, note.ID)
+		}
+		return ids
+	}
+
+	assert := assert.New(t)
+	status, _ := send(t, app, "1001", "POST", "/trash/"+noteID+"/restore", "")
+	assert.Equal(fiber.StatusNotFound, status)
+	assert.Empty(listTrash("1001"))
+	status, body := send(t, app, "1001", "DELETE", "/notes/"+noteID, "")
+	assert.Equal(fiber.StatusCreated, status, body)
+	assert.Equal([]string{noteID}, listTrash("1001"))
+	status, _ = send(t, app, "1001", "GET", "/notes/"+noteID, "")
+	assert.Equal(fiber.StatusNotFound, status)
+
+	// The trash of other users is not found
+	assert.Empty(listTrash("1002"))
+	for _, req := range []struct{ method, path string }{
+		{"POST", "/trash/" + noteID + "/restore"},
+		{"DELETE", "/trash/" + noteID},
+	} {
+		status, body = send(t, app, "1002", req.method, req.path, "")
+		assert.Equal(fiber.StatusNotFound, status, req.method+" "+req.path)
+		assert.Contains(body, `"code":"not_found"`)
+	}
+
+	status, body = send(t, app, "1001", "POST", "/trash/"+noteID+"/restore", "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Contains(body, "note '"+noteID+"' restored successfully")
+	assert.Empty(listTrash("1001"))
+	status, _ = send(t, app, "1001", "GET", "/notes/"+noteID, "")
+	assert.Equal(fiber.StatusOK, status)
+	status, _ = send(t, app, "1001", "DELETE", "/trash/"+noteID, "")
+	assert.Equal(fiber.StatusNotFound, status)
+
+	// Purged notes are gone for good
+	status, _ = send(t, app, "1001", "DELETE", "/notes/"+noteID, "")
+	assert.Equal(fiber.StatusCreated, status)
+	status, body = send(t, app, "1001", "DELETE", "/trash/"+noteID, "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Contains(body, "note '"+noteID+"' deleted permanently")
+	assert.Empty(listTrash("1001"))
+	status, _ = send(t, app, "1001", "POST", "/trash/"+noteID+"/restore", "")
+	assert.Equal(fiber.StatusNotFound, status)
+
+	status, _ = send(t, testApp(basicStore{store}), "1001", "GET", "/trash", "")
+	assert.Equal(fiber.StatusNotImplemented, status)
+}
+
+func TestRevisions(t *testing.T) {
+	store := memory.New()
+	app := testApp(store)
+	noteID := "6f1c6a4e-0a3b-4c1e-9d4a-1e2f3a4b5c6d"
+	ctx := context.Background()
+	err := store.Create(ctx, notestore.Note{ID: noteID, Title: "Groceries", Body: "milk", UserID: "1001"})
+	assert.Nil(t, err)
+	note, err := store.Read(ctx, noteID, "1001")
+	assert.Nil(t, err)
+	note.Title = "Shopping"
+	assert.Nil(t, store.Update(ctx, note))
+	path := "/notes/" + noteID + "/revisions"
+	// listRevisions returns the titles of the revisions of the note, newest first
+	listRevisions := func() []string {
+		status, body := send(t, app, "1001", "GET", path, "")
+		assert.Equal(t, fiber.StatusOK, status, body)
+		var resp struct {
+			Revisions []notestore.Revision `json:"revisions"`
+		}
+		assert.Nil(t, json.Unmarshal([]byte(body), &resp), body)
+		titles := []string{}
+		for _, revision := range resp.Revisions {
+			titles = append(titles, revision.Title)
+		}
+		return titles
+	}
+
+	assert := assert.New(t)
+	assert.Equal([]string{"Groceries"}, listRevisions())
+	var revision notestore.Revision
+	status, body := send(t, app, "1001", "GET", path+"/1", "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Nil(json.Unmarshal([]byte(body), &revision), body)
+	assert.Equal(1, revision.Number)
+	assert.Equal("Groceries", revision.Title)
+	assert.Equal("milk", revision.Body)
+	status, _ = send(t, app, "1001", "GET", path+"/2", "")
+	assert.Equal(fiber.StatusNotFound, status)
+	status, body = send(t, app, "1001", "GET", path+"/first", "")
+	assert.Equal(fiber.StatusBadRequest, status)
+	assert.Contains(body, "invalid revision 'first'")
+
+	// The diff is against the current content unless the to revision is given
+	var diff struct {
+		Diff string `json:"diff"`
+	}
+	status, body = send(t, app, "1001", "GET", path+"/diff?from=1", "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Nil(json.Unmarshal([]byte(body), &diff), body)
+	assert.Contains(diff.Diff, "+++ current")
+	assert.Contains(diff.Diff, "-Groceries")
+	assert.Contains(diff.Diff, "+Shopping")
+	status, _ = send(t, app, "1001", "GET", path+"/diff?from=1&to=2", "")
+	assert.Equal(fiber.StatusNotFound, status)
+	status, _ = send(t, app, "1001", "GET", path+"/diff?from=1&to=last", "")
+	assert.Equal(fiber.StatusBadRequest, status)
+	status, _ = send(t, app, "1001", "GET", path+"/diff", "")
+	assert.Equal(fiber.StatusBadRequest, status)
+
+	// The revisions of other users are not found
+	for _, req := range []struct{ method, path string }{
+		{"GET", path},
+		{"GET", path + "/1"},
+		{"GET", path + "/diff?from=1"},
+		{"POST", path + "/1/restore"},
+	} {
+		status, body = send(t, app, "1002", req.method, req.path, "")
+		assert.Equal(fiber.StatusNotFound, status, req.method+" "+req.path)
+		assert.Contains(body, `"code":"not_found"`)
+	}
+
+	// Restoring keeps the replaced content as a new revision
+	status, body = send(t, app, "1001", "POST", path+"/1/restore", "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Contains(body, "note '"+noteID+"' is restored to revision 1 successfully")
+	note, err = store.Read(ctx, noteID, "1001")
+	assert.Nil(err)
+	assert.Equal("Groceries", note.Title)
+	assert.Equal([]string{"Shopping", "Groceries"}, listRevisions())
+	status, body = send(t, app, "1001", "GET", path+"/diff?from=1&to=2", "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Nil(json.Unmarshal([]byte(body), &diff), body)
+	assert.Contains(diff.Diff, "+++ revision 2")
+	status, _ = send(t, app, "1001", "POST", path+"/3/restore", "")
+	assert.Equal(fiber.StatusNotFound, status)
+
+	status, _ = send(t, testApp(basicStore{store}), "1001", "GET", path, "")
+	assert.Equal(fiber.StatusNotImplemented, status)
+}
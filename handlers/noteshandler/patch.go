@@ -0,0 +1,127 @@
+package noteshandler
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+
+	"local/sidharthjs/todo/handlers/validate"
+	jwtutil "local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// Media types of the patch documents accepted by PatchNote
+const (
+	mimeMergePatch = "application/merge-patch+json"
+	mimeJSONPatch  = "application/json-patch+json"
+)
+
+//PatchNote is the handler method for updating some fields of a note with a JSON
+//merge patch or, when the content type is application/json-patch+json, a JSON patch
+func (nh *NotesHandler) PatchNote(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	apply := notestore.ApplyMergePatch
+	mediaType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	switch mediaType {
+	case mimeMergePatch, fiber.MIMEApplicationJSON, "":
+	case mimeJSONPatch:
+		apply = notestore.ApplyJSONPatch
+	default:
+		c.Set(fiber.HeaderAcceptPatch, mimeMergePatch+", "+mimeJSONPatch)
+		return fiber.NewError(fiber.StatusUnsupportedMediaType,
+			fmt.Sprintf("content type must be %s or %s", mimeMergePatch, mimeJSONPatch))
+	}
+
+	noteID := c.Params("note_id")
+	version, err := nh.ifMatchVersion(c, noteID, userID)
+
+	var current notestore.Note
+	if err == nil {
+		current, err = nh.Store.Read(c.UserContext(), noteID, userID)
+	}
+	if err == nil && version != 0 && version != current.Version {
+		err = notestore.ErrVersionMismatch
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read note '%s': %w", noteID, err)
+	}
+
+	patch, fields, err := apply(current, c.Body())
+	if err != nil {
+		return err
+	}
+
+	// Validate the patched note before storing it
+	merged := notestore.MergeFields(current, patch, fields)
+	err = validatePatch(nh.Validator, merged, fields)
+	if err != nil {
+		return err
+	}
+	_, err = notestore.NormalizeTodo(merged)
+	if err != nil {
+		return err
+	}
+
+	// The patch was applied to the current version, the tests of a JSON patch
+	// included, so it is only stored if no other change came in between
+	patch.Version = current.Version
+	if len(fields) > 0 {
+		err = nh.Store.UpdateFields(c.UserContext(), patch, fields)
+	}
+	if errors.Is(err, notestore.ErrVersionMismatch) && version == 0 {
+		err = notestore.Conflictf("note '%s' was changed concurrently, try again", noteID)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update note '%s': %w", noteID, err)
+	}
+
+	if len(fields) > 0 {
+		c.Set(fiber.HeaderETag, etag(current.Version+1))
+	} else {
+		c.Set(fiber.HeaderETag, etag(current.Version))
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("note '%s' is updated successfully", noteID),
+	})
+}
+
+// validatePatch validates the patched fields of a note with the rules of the other
+// note requests, the fields that are not patched are left as they are
+func validatePatch(v *validate.Validator, note notestore.Note, fields []notestore.NoteField) error {
+	err := v.Struct(noteFields{
+		Title:      note.Title,
+		Body:       note.Body,
+		Status:     string(note.Status),
+		Priority:   note.Priority,
+		DueDate:    note.DueDate,
+		Recurrence: note.Recurrence,
+	})
+	errs, ok := err.(validate.Errors)
+	if !ok {
+		return err
+	}
+
+	var patched validate.Errors
+	for _, fe := range errs {
+		for _, field := range fields {
+			if fe.Field == string(field) {
+				patched = append(patched, fe)
+			}
+		}
+	}
+	if len(patched) > 0 {
+		return patched
+	}
+	return nil
+}
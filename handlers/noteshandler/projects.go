@@ -0,0 +1,266 @@
+package noteshandler
+
+import (
+	"fmt"
+
+	jwtutil "local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// projectStore returns the store as a ProjectStore if the backend supports projects
+func (nh *NotesHandler) projectStore() (notestore.ProjectStore, error) {
+	store, ok := nh.Store.(notestore.ProjectStore)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusNotImplemented, "projects are not supported by the note store")
+	}
+	return store, nil
+}
+
+//CreateProject is the handler method for creating a project
+func (nh *NotesHandler) CreateProject(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.projectStore()
+	if err != nil {
+		return err
+	}
+
+	type request struct {
+		Name string `json:"name" validate:"required,max=project"`
+	}
+
+	var req request
+	err = nh.Validator.DecodeBody(c, &req)
+	if err != nil {
+		return err
+	}
+
+	name, err := notestore.NormalizeProjectName(req.Name)
+	if err != nil {
+		return err
+	}
+
+	project := notestore.Project{
+		ID:     uuid.New().String(),
+		Name:   name,
+		UserID: userID,
+	}
+	err = store.CreateProject(c.UserContext(), project)
+	if err != nil {
+		return fmt.Errorf("unable to create a project: %w", err)
+	}
+
+	log.Infof("project %s created successfully", project.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"msg": fmt.Sprintf("project '%s' created successfully", project.ID),
+	})
+}
+
+//ReadProject is the handler method for reading a project
+func (nh *NotesHandler) ReadProject(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.projectStore()
+	if err != nil {
+		return err
+	}
+
+	projectID := c.Params("project_id")
+	project, err := store.ReadProject(c.UserContext(), projectID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to read project '%s': %w", projectID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(project)
+}
+
+//ListProjects is the handler method for listing the projects
+func (nh *NotesHandler) ListProjects(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.projectStore()
+	if err != nil {
+		return err
+	}
+
+	projects, err := store.ListProjects(c.UserContext(), userID, c.Query("archived") == "true")
+	if err != nil {
+		return fmt.Errorf("error in listing projects: %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"projects": projects,
+	})
+}
+
+//RenameProject is the handler method for renaming a project
+func (nh *NotesHandler) RenameProject(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.projectStore()
+	if err != nil {
+		return err
+	}
+
+	type request struct {
+		Name string `json:"name" validate:"required,max=project"`
+	}
+
+	var req request
+	err = nh.Validator.DecodeBody(c, &req)
+	if err != nil {
+		return err
+	}
+
+	name, err := notestore.NormalizeProjectName(req.Name)
+	if err != nil {
+		return err
+	}
+
+	projectID := c.Params("project_id")
+	err = store.RenameProject(c.UserContext(), projectID, userID, name)
+	if err != nil {
+		return fmt.Errorf("unable to rename project '%s': %w", projectID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("project '%s' is renamed successfully", projectID),
+	})
+}
+
+//ArchiveProject is the handler method for archiving a project
+func (nh *NotesHandler) ArchiveProject(c *fiber.Ctx) error {
+	return nh.setProjectArchived(c, true)
+}
+
+//UnarchiveProject is the handler method for restoring an archived project
+func (nh *NotesHandler) UnarchiveProject(c *fiber.Ctx) error {
+	return nh.setProjectArchived(c, false)
+}
+
+func (nh *NotesHandler) setProjectArchived(c *fiber.Ctx, archived bool) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.projectStore()
+	if err != nil {
+		return err
+	}
+
+	action := "archived"
+	if !archived {
+		action = "unarchived"
+	}
+
+	projectID := c.Params("project_id")
+	err = store.ArchiveProject(c.UserContext(), projectID, userID, archived)
+	if err != nil {
+		return fmt.Errorf("unable to set project '%s' %s: %w", projectID, action, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("project '%s' is %s successfully", projectID, action),
+	})
+}
+
+//DeleteProject is the handler method for deleting a project, its notes are moved to the inbox
+func (nh *NotesHandler) DeleteProject(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.projectStore()
+	if err != nil {
+		return err
+	}
+
+	projectID := c.Params("project_id")
+	err = store.DeleteProject(c.UserContext(), projectID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to delete the project '%s': %w", projectID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("project '%s' deleted successfully", projectID),
+	})
+}
+
+//MoveNote is the handler method for moving a note to a project or to the inbox
+func (nh *NotesHandler) MoveNote(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.projectStore()
+	if err != nil {
+		return err
+	}
+
+	type request struct {
+		ProjectID string `json:"project_id" validate:"max=id"`
+	}
+
+	var req request
+	err = nh.Validator.DecodeBody(c, &req)
+	if err != nil {
+		return err
+	}
+
+	noteID := c.Params("note_id")
+	err = store.MoveNote(c.UserContext(), noteID, userID, req.ProjectID)
+	if err != nil {
+		return fmt.Errorf("unable to move note '%s' to project '%s': %w", noteID, req.ProjectID, err)
+	}
+
+	if req.ProjectID == "" {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"msg": fmt.Sprintf("note '%s' is moved to the inbox successfully", noteID),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("note '%s' is moved to project '%s' successfully", noteID, req.ProjectID),
+	})
+}
@@ -0,0 +1,133 @@
+package noteshandler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	jwtutil "local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultPreviewOccurrences is the number of occurrences previewed when no count is given
+const defaultPreviewOccurrences = 5
+
+//PreviewOccurrences is the handler method for listing the next due dates of a recurring note
+func (nh *NotesHandler) PreviewOccurrences(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	count := defaultPreviewOccurrences
+	if n := c.Query("count"); n != "" {
+		count, err = strconv.Atoi(n)
+		if err != nil || count <= 0 {
+			return notestore.Invalidf("invalid count '%s'", n)
+		}
+	}
+
+	noteID := c.Params("note_id")
+	note, err := nh.Store.Read(c.UserContext(), noteID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to read note '%s': %w", noteID, err)
+	}
+
+	if note.Recurrence == "" {
+		return notestore.Invalidf("note '%s' is not recurring", noteID)
+	}
+
+	r, err := notestore.ParseRecurrence(note.Recurrence)
+	if err != nil {
+		return fmt.Errorf("invalid recurrence of note '%s': %s", noteID, err)
+	}
+	due, err := notestore.ParseDueDate(note.DueDate)
+	if err != nil {
+		return fmt.Errorf("invalid due date of note '%s': %s", noteID, err)
+	}
+
+	occurrences := []string{}
+	for _, occurrence := range r.Occurrences(due, note.Occurrence, count) {
+		occurrences = append(occurrences, occurrence.Format(time.RFC3339))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"recurrence":  note.Recurrence,
+		"occurrences": occurrences,
+	})
+}
+
+//SkipOccurrence is the handler method for moving a recurring note to the next date of its series
+func (nh *NotesHandler) SkipOccurrence(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	noteID := c.Params("note_id")
+	note, err := nh.Store.Read(c.UserContext(), noteID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to read note '%s': %w", noteID, err)
+	}
+
+	next, ok := notestore.NextOccurrence(note)
+	if !ok {
+		return notestore.Invalidf("note '%s' has no next occurrence", noteID)
+	}
+
+	note.DueDate = next.DueDate
+	note.Occurrence = next.Occurrence
+	// The note is only updated if it did not change since it was read
+	err = nh.Store.Update(c.UserContext(), note)
+	if errors.Is(err, notestore.ErrVersionMismatch) {
+		return notestore.Conflictf("note '%s' was changed concurrently, try again", noteID)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update note '%s': %w", noteID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("note '%s' is now due on %s", noteID, note.DueDate),
+	})
+}
+
+//StopRecurrence is the handler method for ending the series of a recurring note
+func (nh *NotesHandler) StopRecurrence(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	noteID := c.Params("note_id")
+	note, err := nh.Store.Read(c.UserContext(), noteID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to read note '%s': %w", noteID, err)
+	}
+
+	note.Recurrence = ""
+	err = nh.Store.Update(c.UserContext(), note)
+	if errors.Is(err, notestore.ErrVersionMismatch) {
+		return notestore.Conflictf("note '%s' was changed concurrently, try again", noteID)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update note '%s': %w", noteID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("recurrence of note '%s' is stopped", noteID),
+	})
+}
@@ -0,0 +1,173 @@
+package noteshandler
+
+import (
+	"fmt"
+	"strconv"
+
+	jwtutil "local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// revisionStore returns the store as a RevisionStore if the backend keeps revisions
+func (nh *NotesHandler) revisionStore() (notestore.RevisionStore, error) {
+	store, ok := nh.Store.(notestore.RevisionStore)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusNotImplemented, "revisions are not supported by the note store")
+	}
+	return store, nil
+}
+
+// parseRevision parses a revision number
+func parseRevision(s string) (int, error) {
+	number, err := strconv.Atoi(s)
+	if err != nil || number < 1 {
+		return 0, notestore.Invalidf("invalid revision '%s'", s)
+	}
+	return number, nil
+}
+
+//ListRevisions is the handler method for listing the revisions of a note
+func (nh *NotesHandler) ListRevisions(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.revisionStore()
+	if err != nil {
+		return err
+	}
+
+	noteID := c.Params("note_id")
+	revisions, err := store.ListRevisions(c.UserContext(), noteID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to list the revisions of note '%s': %w", noteID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"revisions": revisions,
+	})
+}
+
+//ReadRevision is the handler method for reading a revision of a note
+func (nh *NotesHandler) ReadRevision(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.revisionStore()
+	if err != nil {
+		return err
+	}
+
+	number, err := parseRevision(c.Params("revision"))
+	if err != nil {
+		return err
+	}
+
+	noteID := c.Params("note_id")
+	revision, err := store.ReadRevision(c.UserContext(), noteID, userID, number)
+	if err != nil {
+		return fmt.Errorf("unable to read revision %d of note '%s': %w", number, noteID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(revision)
+}
+
+//DiffRevisions is the handler method for the unified diff between two revisions
+//of a note. The diff is against the current content when the to revision is omitted.
+func (nh *NotesHandler) DiffRevisions(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.revisionStore()
+	if err != nil {
+		return err
+	}
+
+	from, err := parseRevision(c.Query("from"))
+	to := 0
+	if err == nil && c.Query("to") != "" {
+		to, err = parseRevision(c.Query("to"))
+	}
+	if err != nil {
+		return err
+	}
+
+	noteID := c.Params("note_id")
+	fromRevision, err := store.ReadRevision(c.UserContext(), noteID, userID, from)
+	if err != nil {
+		return fmt.Errorf("unable to read revision %d of note '%s': %w", from, noteID, err)
+	}
+
+	toName := "current"
+	var toText string
+	if to == 0 {
+		note, err := nh.Store.Read(c.UserContext(), noteID, userID)
+		if err != nil {
+			return fmt.Errorf("unable to read note '%s': %w", noteID, err)
+		}
+		toText = notestore.RevisionText(note.Title, note.Body)
+	} else {
+		toRevision, err := store.ReadRevision(c.UserContext(), noteID, userID, to)
+		if err != nil {
+			return fmt.Errorf("unable to read revision %d of note '%s': %w", to, noteID, err)
+		}
+		toName = fmt.Sprintf("revision %d", to)
+		toText = notestore.RevisionText(toRevision.Title, toRevision.Body)
+	}
+
+	diff := notestore.UnifiedDiff(fmt.Sprintf("revision %d", from), toName,
+		notestore.RevisionText(fromRevision.Title, fromRevision.Body), toText)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"diff": diff,
+	})
+}
+
+//RestoreRevision is the handler method for rolling a note back to a revision
+func (nh *NotesHandler) RestoreRevision(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.revisionStore()
+	if err != nil {
+		return err
+	}
+
+	number, err := parseRevision(c.Params("revision"))
+	if err != nil {
+		return err
+	}
+
+	noteID := c.Params("note_id")
+	err = store.RestoreRevision(c.UserContext(), noteID, userID, number)
+	if err != nil {
+		return fmt.Errorf("unable to restore revision %d of note '%s': %w", number, noteID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("note '%s' is restored to revision %d successfully", noteID, number),
+	})
+}
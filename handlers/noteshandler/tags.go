@@ -0,0 +1,215 @@
+package noteshandler
+
+import (
+	"fmt"
+	"net/url"
+
+	jwtutil "local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// tagStore returns the store as a TagStore if the backend supports tags
+func (nh *NotesHandler) tagStore() (notestore.TagStore, error) {
+	store, ok := nh.Store.(notestore.TagStore)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusNotImplemented, "tags are not supported by the note store")
+	}
+	return store, nil
+}
+
+// tagParam returns the unescaped tag path parameter
+func tagParam(c *fiber.Ctx) (string, error) {
+	tag, err := url.PathUnescape(c.Params("tag"))
+	if err != nil {
+		return "", notestore.Invalidf("invalid tag '%s'", c.Params("tag"))
+	}
+	return notestore.NormalizeTag(tag)
+}
+
+//AddTags is the handler method for tagging a note
+func (nh *NotesHandler) AddTags(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.tagStore()
+	if err != nil {
+		return err
+	}
+
+	type request struct {
+		Tags []string `json:"tags" validate:"required,max=tags,eachmax=tag"`
+	}
+
+	var req request
+	err = nh.Validator.DecodeBody(c, &req)
+	if err != nil {
+		return err
+	}
+
+	tags, err := notestore.NormalizeTags(req.Tags)
+	if err != nil {
+		return err
+	}
+
+	noteID := c.Params("note_id")
+	err = store.AddTags(c.UserContext(), noteID, userID, tags)
+	if err != nil {
+		return fmt.Errorf("unable to tag note '%s': %w", noteID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("note '%s' is tagged successfully", noteID),
+	})
+}
+
+//RemoveTag is the handler method for removing a tag from a note
+func (nh *NotesHandler) RemoveTag(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.tagStore()
+	if err != nil {
+		return err
+	}
+
+	tag, err := tagParam(c)
+	if err != nil {
+		return err
+	}
+
+	noteID := c.Params("note_id")
+	err = store.RemoveTag(c.UserContext(), noteID, userID, tag)
+	if err != nil {
+		return fmt.Errorf("unable to remove tag '%s' from note '%s': %w", tag, noteID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("tag '%s' is removed from note '%s' successfully", tag, noteID),
+	})
+}
+
+//ListTags is the handler method for listing the tags with their usage counts
+func (nh *NotesHandler) ListTags(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.tagStore()
+	if err != nil {
+		return err
+	}
+
+	tags, err := store.ListTags(c.UserContext(), userID)
+	if err != nil {
+		return fmt.Errorf("error in listing tags: %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"tags": tags,
+	})
+}
+
+//RenameTag is the handler method for renaming a tag
+func (nh *NotesHandler) RenameTag(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.tagStore()
+	if err != nil {
+		return err
+	}
+
+	type request struct {
+		Name string `json:"name" validate:"required,max=tag"`
+	}
+
+	var req request
+	err = nh.Validator.DecodeBody(c, &req)
+	if err != nil {
+		return err
+	}
+
+	tag, err := tagParam(c)
+	if err == nil {
+		_, err = notestore.NormalizeTag(req.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = store.RenameTag(c.UserContext(), userID, tag, req.Name)
+	if err != nil {
+		return fmt.Errorf("unable to rename tag '%s': %w", tag, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("tag '%s' is renamed successfully", tag),
+	})
+}
+
+//MergeTags is the handler method for merging tags into a target tag
+func (nh *NotesHandler) MergeTags(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.tagStore()
+	if err != nil {
+		return err
+	}
+
+	type request struct {
+		Sources []string `json:"sources" validate:"required,max=tags,eachmax=tag"`
+		Target  string   `json:"target" validate:"required,max=tag"`
+	}
+
+	var req request
+	err = nh.Validator.DecodeBody(c, &req)
+	if err != nil {
+		return err
+	}
+
+	_, err = notestore.NormalizeTags(append(req.Sources, req.Target))
+	if err == nil && len(req.Sources) == 0 {
+		err = notestore.Invalidf("no source tags to merge")
+	}
+	if err != nil {
+		return err
+	}
+
+	err = store.MergeTags(c.UserContext(), userID, req.Sources, req.Target)
+	if err != nil {
+		return fmt.Errorf("unable to merge tags into '%s': %w", req.Target, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("tags are merged into '%s' successfully", req.Target),
+	})
+}
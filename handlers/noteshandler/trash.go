@@ -0,0 +1,98 @@
+package noteshandler
+
+import (
+	"fmt"
+
+	jwtutil "local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// trashStore returns the store as a TrashStore if the backend supports the trash
+func (nh *NotesHandler) trashStore() (notestore.TrashStore, error) {
+	store, ok := nh.Store.(notestore.TrashStore)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusNotImplemented, "trash is not supported by the note store")
+	}
+	return store, nil
+}
+
+//ListTrash is the handler method for listing the deleted notes
+func (nh *NotesHandler) ListTrash(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.trashStore()
+	if err != nil {
+		return err
+	}
+
+	notes, err := store.ListTrash(c.UserContext(), userID)
+	if err != nil {
+		return fmt.Errorf("error in listing the trash: %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"notes": notes,
+	})
+}
+
+//RestoreNote is the handler method for restoring a deleted note
+func (nh *NotesHandler) RestoreNote(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.trashStore()
+	if err != nil {
+		return err
+	}
+
+	noteID := c.Params("note_id")
+	err = store.Restore(c.UserContext(), noteID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to restore the note '%s': %w", noteID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("note '%s' restored successfully", noteID),
+	})
+}
+
+//PurgeNote is the handler method for permanently deleting a note from the trash
+func (nh *NotesHandler) PurgeNote(c *fiber.Ctx) error {
+
+	userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+	if err != nil {
+		log.Errorf("error in reading user details in jwt token: %s", err)
+
+		return fiber.ErrUnauthorized
+	}
+
+	store, err := nh.trashStore()
+	if err != nil {
+		return err
+	}
+
+	noteID := c.Params("note_id")
+	err = store.Purge(c.UserContext(), noteID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to purge the note '%s': %w", noteID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"msg": fmt.Sprintf("note '%s' deleted permanently", noteID),
+	})
+}
@@ -0,0 +1,119 @@
+// Package problem maps the errors returned by the handlers to RFC 7807 problem
+// details responses
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"local/sidharthjs/todo/handlers/validate"
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// MIMEProblemJSON is the content type of the error responses
+const MIMEProblemJSON = "application/problem+json"
+
+// Stable codes of the errors of the notes, the errors created with fiber.NewError
+// are coded after their status, e.g. bad_request or not_implemented
+const (
+	CodeNotFound        = "not_found"
+	CodeConflict        = "conflict"
+	CodeValidation      = "validation_failed"
+	CodeVersionMismatch = "version_mismatch"
+	CodeBatchAborted    = "batch_aborted"
+	CodeInternal        = "internal_error"
+)
+
+// Details is the body of an error response
+type Details struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+	// Errors are the failed rules of the fields of an invalid request
+	Errors []validate.FieldError `json:"errors,omitempty"`
+}
+
+// New returns the problem details of an error
+func New(err error) Details {
+	var fieldErrs validate.Errors
+	var storeErr *notestore.Error
+	var fiberErr *fiber.Error
+	var d Details
+	switch {
+	case errors.As(err, &fieldErrs):
+		d = Details{
+			Status: fiber.StatusBadRequest,
+			Code:   CodeValidation,
+			Detail: "the request is invalid",
+			Errors: fieldErrs,
+		}
+	case errors.Is(err, notestore.ErrVersionMismatch):
+		d = Details{
+			Status: fiber.StatusPreconditionFailed,
+			Code:   CodeVersionMismatch,
+			Detail: "the note has been changed, read it again to get its current ETag",
+		}
+	case errors.Is(err, notestore.ErrBatchAborted):
+		d = Details{
+			Status: fiber.StatusFailedDependency,
+			Code:   CodeBatchAborted,
+			Detail: err.Error(),
+		}
+	case errors.As(err, &storeErr):
+		d = Details{Status: fiber.StatusInternalServerError, Code: CodeInternal, Detail: storeErr.Message}
+		switch storeErr.Kind {
+		case notestore.ErrNotFound:
+			d.Status, d.Code = fiber.StatusNotFound, CodeNotFound
+		case notestore.ErrConflict:
+			d.Status, d.Code = fiber.StatusConflict, CodeConflict
+		case notestore.ErrValidation:
+			d.Status, d.Code = fiber.StatusBadRequest, CodeValidation
+		}
+	case errors.As(err, &fiberErr):
+		d = Details{Status: fiberErr.Code, Code: statusCode(fiberErr.Code), Detail: fiberErr.Message}
+	default:
+		d = Details{Status: fiber.StatusInternalServerError, Code: CodeInternal, Detail: "internal server error"}
+	}
+
+	d.Type = "about:blank"
+	d.Title = utils.StatusMessage(d.Status)
+	return d
+}
+
+// statusCode returns the code of an HTTP status, e.g. not_implemented
+func statusCode(status int) string {
+	return strings.ToLower(strings.ReplaceAll(utils.StatusMessage(status), " ", "_"))
+}
+
+// ErrorHandler is the Fiber error handler writing the errors as problem details.
+// The internal errors are logged and their details are not sent to the client.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	d := New(err)
+	d.Instance = c.Path()
+	d.RequestID = c.GetRespHeader(fiber.HeaderXRequestID)
+	LogInternal(c, d, err)
+
+	body, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, MIMEProblemJSON)
+	return c.Status(d.Status).Send(body)
+}
+
+// LogInternal logs the error of the problem details when it is an internal error,
+// whose details are not sent to the client
+func LogInternal(c *fiber.Ctx, d Details, err error) {
+	if d.Status >= fiber.StatusInternalServerError {
+		log.Errorf("%s %s (request %s): %s", c.Method(), c.Path(), c.GetRespHeader(fiber.HeaderXRequestID), err)
+	}
+}
@@ -0,0 +1,106 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		description    string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedDetail string
+	}{
+		{
+			description:    "Not found",
+			err:            fmt.Errorf("unable to read note '1': %w", notestore.NotFoundf("Note '1' is not found")),
+			expectedStatus: fiber.StatusNotFound,
+			expectedCode:   CodeNotFound,
+			expectedDetail: "Note '1' is not found",
+		},
+		{
+			description:    "Conflict",
+			err:            notestore.Conflictf("Tag 'work' already exists"),
+			expectedStatus: fiber.StatusConflict,
+			expectedCode:   CodeConflict,
+			expectedDetail: "Tag 'work' already exists",
+		},
+		{
+			description:    "Validation",
+			err:            notestore.Invalidf("invalid status 'later'"),
+			expectedStatus: fiber.StatusBadRequest,
+			expectedCode:   CodeValidation,
+			expectedDetail: "invalid status 'later'",
+		},
+		{
+			description:    "Version mismatch",
+			err:            fmt.Errorf("unable to update note '1': %w", notestore.ErrVersionMismatch),
+			expectedStatus: fiber.StatusPreconditionFailed,
+			expectedCode:   CodeVersionMismatch,
+			expectedDetail: "the note has been changed, read it again to get its current ETag",
+		},
+		{
+			description:    "Batch aborted",
+			err:            notestore.ErrBatchAborted,
+			expectedStatus: fiber.StatusFailedDependency,
+			expectedCode:   CodeBatchAborted,
+			expectedDetail: "operation is rolled back because another operation of the batch failed",
+		},
+		{
+			description:    "Fiber error",
+			err:            fiber.NewError(fiber.StatusNotImplemented, "tags are not supported by the note store"),
+			expectedStatus: fiber.StatusNotImplemented,
+			expectedCode:   "not_implemented",
+			expectedDetail: "tags are not supported by the note store",
+		},
+		{
+			description:    "Internal errors are not detailed",
+			err:            errors.New("error occurred while querying the notes: connection refused"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedCode:   CodeInternal,
+			expectedDetail: "internal server error",
+		},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		d := New(testCase.err)
+		assert.Equal(testCase.expectedStatus, d.Status, testCase.description)
+		assert.Equal(testCase.expectedCode, d.Code, testCase.description)
+		assert.Equal(testCase.expectedDetail, d.Detail, testCase.description)
+	}
+	assert.Equal("Bad Request", New(fiber.ErrBadRequest).Title)
+}
+
+func TestErrorHandler(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(requestid.New())
+	app.Get("/notes/:note_id", func(c *fiber.Ctx) error {
+		return notestore.NotFoundf("Note '%s' is not found", c.Params("note_id"))
+	})
+
+	assert := assert.New(t)
+	resp, err := app.Test(httptest.NewRequest("GET", "/notes/1", nil))
+	assert.Nil(err)
+	assert.Equal(fiber.StatusNotFound, resp.StatusCode)
+	assert.Equal(MIMEProblemJSON, resp.Header.Get(fiber.HeaderContentType))
+
+	var d Details
+	err = json.NewDecoder(resp.Body).Decode(&d)
+	assert.Nil(err)
+	assert.Equal(CodeNotFound, d.Code)
+	assert.Equal("/notes/1", d.Instance)
+	assert.NotEmpty(d.RequestID)
+	assert.Equal(resp.Header.Get(fiber.HeaderXRequestID), d.RequestID)
+}
@@ -0,0 +1,318 @@
+// Package validate checks the request bodies of the handlers against the rules
+// declared in the validate tags of the request structs, e.g.
+//   Title string `json:"title" validate:"required,max=title"`
+//
+// The rules are separated by commas:
+//   required      the string is not blank, the slice is not empty
+//   max=N         at most N characters for strings, N elements for slices, N for integers
+//   min=N         at least N characters for strings, N for integers
+//   eachmax=N     at most N characters for every string of a slice
+//   oneof=a b c   the string is one of the values
+// N is a number or the name of one of the Limits. The rules other than required
+// are only checked on non-empty values. Every string must be valid UTF-8 without
+// NUL characters.
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Limits are the named maximums used by the max and eachmax rules
+type Limits map[string]int
+
+// DefaultLimits returns the limits used unless configured otherwise
+func DefaultLimits() Limits {
+	return Limits{
+		"title":      200,
+		"body":       100000,
+		"tag":        notestore.MaxTagLength,
+		"tags":       50,
+		"item":       notestore.MaxItemLength,
+		"items":      1000,
+		"project":    notestore.MaxProjectNameLength,
+		"recurrence": 200,
+		"date":       64,
+		"id":         64,
+		"batch":      100,
+	}
+}
+
+// FieldError is the failed rule of a field
+type FieldError struct {
+	// Field is the JSON name of the field, empty for errors of the whole body
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors are the failed rules of a request, they are ErrValidation errors
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is makes the Errors match notestore.ErrValidation
+func (e Errors) Is(target error) bool {
+	return target == notestore.ErrValidation
+}
+
+// Validator validates the requests with its limits
+type Validator struct {
+	limits Limits
+}
+
+// New returns a Validator, the limits missing from limits are the default ones
+func New(limits Limits) *Validator {
+	v := &Validator{limits: DefaultLimits()}
+	for name, limit := range limits {
+		v.limits[name] = limit
+	}
+	return v
+}
+
+// Limit returns the value of a limit
+func (v *Validator) Limit(name string) int {
+	return v.limits[name]
+}
+
+// DecodeBody decodes the JSON body of a request into dst like Decode. The bodies of
+// other content types, such as forms, are refused with 415 Unsupported Media Type.
+func (v *Validator) DecodeBody(c *fiber.Ctx, dst interface{}) error {
+	mediaType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if mediaType != fiber.MIMEApplicationJSON {
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "content type must be "+fiber.MIMEApplicationJSON)
+	}
+	return v.Decode(c.Body(), dst)
+}
+
+// Decode decodes a JSON request body into dst, a pointer to a struct, and validates it.
+// Unknown fields are rejected.
+func (v *Validator) Decode(body []byte, dst interface{}) error {
+	if !utf8.Valid(body) {
+		return Errors{{Rule: "utf8", Message: "request body is not valid UTF-8"}}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(dst)
+	if err == nil && decoder.More() {
+		err = errors.New("unexpected data after the JSON object")
+	}
+	if err != nil {
+		return decodeError(err)
+	}
+	return v.Struct(dst)
+}
+
+// decodeError converts the errors of the JSON decoder about fields to Errors
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		return Errors{{Field: typeErr.Field, Rule: "type", Message: fmt.Sprintf("%s must be of type %s", typeErr.Field, typeErr.Type)}}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		return Errors{{Field: field, Rule: "unknown", Message: fmt.Sprintf("unknown field '%s'", field)}}
+	case err == io.EOF:
+		return Errors{{Rule: "required", Message: "request body is empty"}}
+	}
+	return Errors{{Rule: "json", Message: fmt.Sprintf("request body is not valid JSON: %s", err)}}
+}
+
+// Struct validates a struct, or a pointer to a struct, against its validate tags
+func (v *Validator) Struct(s interface{}) error {
+	var errs Errors
+	err := v.walk(reflect.Indirect(reflect.ValueOf(s)), &errs)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// walk validates the fields of a struct, the fields of embedded structs included
+func (v *Validator) walk(s reflect.Value, errs *Errors) error {
+	for i := 0; i < s.NumField(); i++ {
+		field, value := s.Type().Field(i), s.Field(i)
+		if field.Anonymous && value.Kind() == reflect.Struct {
+			err := v.walk(value, errs)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		if name == "-" {
+			continue
+		}
+
+		fe, err := v.field(name, value, field.Tag.Get("validate"))
+		if err != nil {
+			return err
+		}
+		if fe != nil {
+			*errs = append(*errs, *fe)
+		}
+	}
+	return nil
+}
+
+// field validates a field and returns its first failed rule
+func (v *Validator) field(name string, value reflect.Value, tag string) (*FieldError, error) {
+	fail := func(rule, format string, args ...interface{}) (*FieldError, error) {
+		return &FieldError{Field: name, Rule: rule, Message: name + " " + fmt.Sprintf(format, args...)}, nil
+	}
+
+	for _, s := range stringsOf(value) {
+		if !utf8.ValidString(s) || strings.ContainsRune(s, 0) {
+			return fail("utf8", "must be valid UTF-8 without NUL characters")
+		}
+	}
+
+	if tag == "" {
+		return nil, nil
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		key, arg := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			key, arg = rule[:i], rule[i+1:]
+		}
+
+		if key == "required" {
+			if isBlank(value) {
+				return fail(key, "is required")
+			}
+			continue
+		}
+		if isBlank(value) {
+			continue
+		}
+
+		switch key {
+		case "max", "min", "eachmax":
+			n, err := v.number(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule '%s' of field '%s': %s", rule, name, err)
+			}
+			if fe := checkBound(key, n, value); fe != "" {
+				return fail(key, "%s", fe)
+			}
+		case "oneof":
+			values := strings.Fields(arg)
+			if value.Kind() == reflect.String && !contains(values, value.String()) {
+				return fail(key, "must be one of %s", strings.Join(values, ", "))
+			}
+		default:
+			return nil, fmt.Errorf("unknown rule '%s' of field '%s'", key, name)
+		}
+	}
+	return nil, nil
+}
+
+// number returns the value of a rule argument, a number or the name of a limit
+func (v *Validator) number(arg string) (int, error) {
+	if n, err := strconv.Atoi(arg); err == nil {
+		return n, nil
+	}
+	n, ok := v.limits[arg]
+	if !ok {
+		return 0, fmt.Errorf("unknown limit '%s'", arg)
+	}
+	return n, nil
+}
+
+// checkBound checks a max, min or eachmax rule and returns the failure message
+func checkBound(key string, n int, value reflect.Value) string {
+	switch {
+	case key == "eachmax":
+		for _, s := range stringsOf(value) {
+			if utf8.RuneCountInString(s) > n {
+				return fmt.Sprintf("must only contain values of at most %d characters", n)
+			}
+		}
+	case value.Kind() == reflect.String:
+		length := utf8.RuneCountInString(value.String())
+		if key == "max" && length > n {
+			return fmt.Sprintf("must be at most %d characters long", n)
+		}
+		if key == "min" && length < n {
+			return fmt.Sprintf("must be at least %d characters long", n)
+		}
+	case value.Kind() == reflect.Slice:
+		if key == "max" && value.Len() > n {
+			return fmt.Sprintf("must have at most %d values", n)
+		}
+		if key == "min" && value.Len() < n {
+			return fmt.Sprintf("must have at least %d values", n)
+		}
+	case value.Kind() == reflect.Int:
+		if key == "max" && value.Int() > int64(n) {
+			return fmt.Sprintf("must be at most %d", n)
+		}
+		if key == "min" && value.Int() < int64(n) {
+			return fmt.Sprintf("must be at least %d", n)
+		}
+	}
+	return ""
+}
+
+// isBlank reports whether a string is blank or a slice is empty, integers are never blank
+func isBlank(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	}
+	return false
+}
+
+// stringsOf returns the string of a string value or the strings of a string slice
+func stringsOf(value reflect.Value) []string {
+	switch {
+	case value.Kind() == reflect.String:
+		return []string{value.String()}
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String:
+		s := make([]string, value.Len())
+		for i := range s {
+			s[i] = value.Index(i).String()
+		}
+		return s
+	}
+	return nil
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package validate
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type embedded struct {
+	Title string `json:"title" validate:"required,max=title"`
+}
+
+type request struct {
+	embedded
+	Status   string   `json:"status" validate:"oneof=open done"`
+	Priority int      `json:"priority" validate:"min=0,max=3"`
+	Tags     []string `json:"tags" validate:"max=2,eachmax=tag"`
+}
+
+func TestDecode(t *testing.T) {
+	v := New(Limits{"title": 10, "tag": 5})
+
+	testCases := []struct {
+		description    string
+		body           string
+		expectedErrors Errors
+	}{
+		{description: "Valid", body: `{"title": "Groceries", "status": "done", "tags": ["home"]}`},
+		{description: "Limits count characters", body: `{"title": "Ĝroceriĝs"}`},
+		{
+			description:    "Required",
+			body:           `{"title": "  "}`,
+			expectedErrors: Errors{{Field: "title", Rule: "required", Message: "title is required"}},
+		},
+		{
+			description: "Several fields",
+			body:        `{"title": "Groceries for the week", "status": "later", "priority": 4}`,
+			expectedErrors: Errors{
+				{Field: "title", Rule: "max", Message: "title must be at most 10 characters long"},
+				{Field: "status", Rule: "oneof", Message: "status must be one of open, done"},
+				{Field: "priority", Rule: "max", Message: "priority must be at most 3"},
+			},
+		},
+		{
+			description: "Slices",
+			body:        `{"title": "Groceries", "tags": ["home", "errands"]}`,
+			expectedErrors: Errors{
+				{Field: "tags", Rule: "eachmax", Message: "tags must only contain values of at most 5 characters"},
+			},
+		},
+		{
+			description:    "Unknown field",
+			body:           `{"title": "Groceries", "colour": "red"}`,
+			expectedErrors: Errors{{Field: "colour", Rule: "unknown", Message: "unknown field 'colour'"}},
+		},
+		{
+			description:    "Wrong type",
+			body:           `{"title": "Groceries", "priority": "high"}`,
+			expectedErrors: Errors{{Field: "priority", Rule: "type", Message: "priority must be of type int"}},
+		},
+		{
+			description:    "NUL character",
+			body:           `{"title": "Groc\u0000eries"}`,
+			expectedErrors: Errors{{Field: "title", Rule: "utf8", Message: "title must be valid UTF-8 without NUL characters"}},
+		},
+		{
+			description:    "Invalid UTF-8",
+			body:           "{\"title\": \"Groc\xffries\"}",
+			expectedErrors: Errors{{Rule: "utf8", Message: "request body is not valid UTF-8"}},
+		},
+		{
+			description:    "Empty body",
+			body:           "",
+			expectedErrors: Errors{{Rule: "required", Message: "request body is empty"}},
+		},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		var req request
+		err := v.Decode([]byte(testCase.body), &req)
+		if testCase.expectedErrors == nil {
+			assert.Nil(err, testCase.description)
+			continue
+		}
+		assert.Equal(testCase.expectedErrors, err, testCase.description)
+		assert.True(errors.Is(err, notestore.ErrValidation), testCase.description)
+	}
+
+	var req request
+	err := v.Decode([]byte(`{"title": "Groceries"} {}`), &req)
+	if assert.NotNil(err) {
+		assert.True(strings.HasPrefix(err.Error(), "request body is not valid JSON"))
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	v := New(nil)
+	app := fiber.New()
+	app.Post("/", func(c *fiber.Ctx) error {
+		var req request
+		err := v.DecodeBody(c, &req)
+		if err != nil {
+			return err
+		}
+		return c.SendString(req.Title)
+	})
+
+	testCases := []struct {
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{contentType: "application/json", body: `{"title": "Groceries"}`, expectedStatus: fiber.StatusOK},
+		{contentType: "application/json; charset=utf-8", body: `{"title": "Groceries"}`, expectedStatus: fiber.StatusOK},
+		{contentType: "application/x-www-form-urlencoded", body: "title=Groceries", expectedStatus: fiber.StatusUnsupportedMediaType},
+		{contentType: "text/plain", body: `{"title": "Groceries"}`, expectedStatus: fiber.StatusUnsupportedMediaType},
+		{contentType: "", body: `{"title": "Groceries"}`, expectedStatus: fiber.StatusUnsupportedMediaType},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(testCase.body))
+		req.Header.Set(fiber.HeaderContentType, testCase.contentType)
+		resp, err := app.Test(req)
+		if assert.Nil(err, testCase.contentType) {
+			assert.Equal(testCase.expectedStatus, resp.StatusCode, testCase.contentType)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	v := New(Limits{"title": 10})
+	assert.Equal(t, 10, v.Limit("title"))
+	assert.Equal(t, DefaultLimits()["body"], v.Limit("body"))
+
+	type badRule struct {
+		Title string `json:"title" validate:"max=unknown"`
+	}
+	err := v.Struct(badRule{Title: "Groceries"})
+	assert.EqualError(t, err, "invalid rule 'max=unknown' of field 'title': unknown limit 'unknown'")
+}
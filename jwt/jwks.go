@@ -0,0 +1,83 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+//JWK is a public key as a JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	// N and E are the modulus and exponent of the RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Crv, X and Y are the curve and point of the ECDSA and Ed25519 keys
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+//JWKS is a JSON Web Key Set, served for the other services to verify the tokens
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+//JWKS returns the public keys of the key set, the secret keys are left out
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		if key.Private == nil {
+			continue
+		}
+		jwk := JWK{Use: "sig", Alg: ks.methods[key.ID].Alg(), Kid: key.ID}
+		switch public := key.Private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encode(public.N.Bytes())
+			jwk.E = encode(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			jwk.Kty, jwk.Crv = "EC", public.Curve.Params().Name
+			jwk.X = encode(padded(public.X.Bytes(), size))
+			jwk.Y = encode(padded(public.Y.Bytes(), size))
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+			jwk.X = encode(public)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+//Algorithms returns the signing methods of the public keys of the key set
+func (ks *KeySet) Algorithms() []string {
+	algs := []string{}
+	seen := map[string]bool{}
+	for _, jwk := range ks.JWKS().Keys {
+		if !seen[jwk.Alg] {
+			seen[jwk.Alg] = true
+			algs = append(algs, jwk.Alg)
+		}
+	}
+	return algs
+}
+
+// encode encodes the bytes in base64url without padding
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// padded returns the bytes left-padded with zeros to size, as the coordinates of the
+// curve points have a fixed size
+func padded(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
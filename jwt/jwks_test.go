@@ -0,0 +1,151 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// privateKeys returns an RSA, an ECDSA and an Ed25519 key
+func privateKeys(t *testing.T) []Key {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating the RSA key: %s", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating the ECDSA key: %s", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating the Ed25519 key: %s", err)
+	}
+	return []Key{{ID: "rsa", Private: rsaKey}, {ID: "ec", Private: ecKey}, {ID: "ed", Private: edKey}}
+}
+
+// publicKey returns the public key of a JWK
+func publicKey(t *testing.T, jwk JWK) crypto.PublicKey {
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("error decoding %q: %s", s, err)
+		}
+		return b
+	}
+	switch jwk.Kty {
+	case "RSA":
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(decode(jwk.N)), E: int(new(big.Int).SetBytes(decode(jwk.E)).Int64())}
+	case "EC":
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(decode(jwk.X)), Y: new(big.Int).SetBytes(decode(jwk.Y))}
+	default:
+		return ed25519.PublicKey(decode(jwk.X))
+	}
+}
+
+func TestAsymmetricKeys(t *testing.T) {
+	assert := assert.New(t)
+	keys := append(privateKeys(t), Key{ID: "hs", Secret: []byte("a test secret")})
+
+	// Only the public keys are published
+	jwks := testKeys(t, keys...).JWKS()
+	if !assert.Len(jwks.Keys, 3) {
+		return
+	}
+	assert.Equal([]string{"RS256", "ES256", "EdDSA"}, testKeys(t, keys...).Algorithms())
+
+	for i, jwk := range jwks.Keys {
+		assert.Equal(keys[i].ID, jwk.Kid)
+		assert.Equal("sig", jwk.Use)
+
+		ks, err := NewKeySet(keys[i].ID, keys...)
+		if !assert.Nil(err) {
+			continue
+		}
+		token, err := ks.CreateJWTToken("1001", "john101", "session-1")
+		assert.Nil(err)
+		_, err = ks.Parse(token)
+		assert.Nil(err, jwk.Alg)
+
+		// The other services verify the tokens with the published key alone
+		parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+			return publicKey(t, jwk), nil
+		})
+		if assert.Nil(err, jwk.Alg) {
+			assert.Equal(jwk.Alg, parsed.Method.Alg())
+			assert.Equal(jwk.Kid, parsed.Header["kid"])
+		}
+	}
+}
+
+func TestAlgorithmConfusion(t *testing.T) {
+	assert := assert.New(t)
+	keys := privateKeys(t)
+	ks := testKeys(t, keys...)
+
+	// A token signed with HS256 and the public key of an RSA key is rejected
+	public, err := x509.MarshalPKIXPublicKey(keys[0].Private.Public())
+	assert.Nil(err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1001", "username": "john101"})
+	forged.Header["kid"] = "rsa"
+	token, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}))
+	assert.Nil(err)
+	_, err = ks.Parse(token)
+	assert.NotNil(err)
+}
+
+func TestPrivateKeyFiles(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	write := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600)
+		if err != nil {
+			t.Fatalf("error writing the key file: %s", err)
+		}
+		return path
+	}
+
+	for _, key := range privateKeys(t) {
+		der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+		assert.Nil(err)
+		read, err := ReadKeyFile(write(key.ID+".pem", "PRIVATE KEY", der))
+		if assert.Nil(err) {
+			assert.Equal(key, read)
+		}
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(err)
+	der, err := x509.MarshalECPrivateKey(ecKey)
+	assert.Nil(err)
+	read, err := ReadKeyFile(write("sec1.pem", "EC PRIVATE KEY", der))
+	if assert.Nil(err) {
+		assert.Equal(Key{ID: "sec1", Private: ecKey}, read)
+	}
+
+	_, err = ReadKeyFile(write("cert.pem", "CERTIFICATE", []byte("not a key")))
+	assert.NotNil(err)
+
+	// The weak keys are refused
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(err)
+	_, err = NewKeySet("", Key{ID: "small", Private: smallKey})
+	assert.EqualError(err, `the RSA key "small" has less than 2048 bits`)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(err)
+	_, err = NewKeySet("", Key{ID: "p384", Private: p384Key})
+	assert.EqualError(err, `the ECDSA key "p384" is not on the P-256 curve`)
+}
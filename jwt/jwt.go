@@ -7,19 +7,29 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 )
 
-const jwtSecret = "aJWTSecret"
+// DefaultAccessTokenTTL is the lifetime of the access tokens, which cannot be revoked
+// and are refreshed with the refresh tokens of their session
+const DefaultAccessTokenTTL = 15 * time.Minute
 
-// CreateJWTToken expects user ID and username and creates a JWT token
-func CreateJWTToken(userID, userName string) (string, error) {
+// CreateJWTToken expects user ID, username and the ID of the session of the user and
+// creates an access token signed with the signing key
+func (ks *KeySet) CreateJWTToken(userID, userName, sessionID string) (string, error) {
+	ttl := ks.AccessTokenTTL
+	if ttl == 0 {
+		ttl = DefaultAccessTokenTTL
+	}
 	claims := jwt.MapClaims{
 		"sub":      userID,
 		"username": userName,
-		"exp":      time.Now().Add(time.Hour * 24 * 7).Unix(), // expiry: one week
+		"sid":      sessionID,
+		"exp":      time.Now().Add(ttl).Unix(),
+	}
+	if ks.Issuer != "" {
+		claims["iss"] = ks.Issuer
 	}
 
 	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	t, err := token.SignedString([]byte(jwtSecret))
+	t, err := ks.Sign(claims)
 	if err != nil {
 		return "", err
 	}
@@ -27,14 +37,32 @@ func CreateJWTToken(userID, userName string) (string, error) {
 	return t, nil
 }
 
-// GetUserFromJWTToken expects JWT token, decodes the user ID and username
+// GetUserFromJWTToken expects JWT token, decodes the user ID and username. A token
+// without them, or with claims of another type, is an error.
 func GetUserFromJWTToken(token *jwt.Token) (string, string, error) {
-	claims := token.Claims.(jwt.MapClaims)
-	userID := claims["sub"].(string)
-	userName := claims["username"].(string)
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", "", fmt.Errorf("unexpected claims of type %T", token.Claims)
+	}
+	userID, ok := claims["sub"].(string)
+	if !ok {
+		return "", "", fmt.Errorf("the sub claim is missing or not a string")
+	}
+	userName, ok := claims["username"].(string)
+	if !ok {
+		return "", "", fmt.Errorf("the username claim is missing or not a string")
+	}
 	if userID == "" || userName == "" {
 		return "", "", fmt.Errorf("user ID or user name is empty")
 	}
 
 	return userID, userName, nil
 }
+
+// GetSessionFromJWTToken returns the ID of the session of an access token, empty for the
+// tokens issued before the sessions
+func GetSessionFromJWTToken(token *jwt.Token) string {
+	claims, _ := token.Claims.(jwt.MapClaims)
+	sessionID, _ := claims["sid"].(string)
+	return sessionID
+}
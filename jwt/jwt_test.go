@@ -1,12 +1,27 @@
 package jwt
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 )
 
+// testKeys returns a key set signing with its first key
+func testKeys(t *testing.T, keys ...Key) *KeySet {
+	if len(keys) == 0 {
+		keys = []Key{{ID: "test", Secret: []byte("a test secret")}}
+	}
+	ks, err := NewKeySet("", keys...)
+	if err != nil {
+		t.Fatalf("error creating the key set: %s", err)
+	}
+	return ks
+}
+
 func TestJWTMethods(t *testing.T) {
 
 	testCases := []struct {
@@ -23,18 +38,179 @@ func TestJWTMethods(t *testing.T) {
 	}
 
 	assert := assert.New(t)
+	ks := testKeys(t)
+	ks.Issuer = "http://localhost:4010"
 	for _, testCase := range testCases {
-		token, err := CreateJWTToken(testCase.userID, testCase.userName)
+		token, err := ks.CreateJWTToken(testCase.userID, testCase.userName, "session-1")
 		assert.Nil(err)
 
-		parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtSecret), nil
-		})
+		parsedToken, err := ks.Parse(token)
 		assert.Nil(err)
+		assert.Equal("test", parsedToken.Header["kid"])
+		assert.Equal("http://localhost:4010", parsedToken.Claims.(jwt.MapClaims)["iss"])
 
 		userID, userName, err := GetUserFromJWTToken(parsedToken)
 		assert.Nil(err)
 		assert.Equal(testCase.expectedUserID, userID)
 		assert.Equal(testCase.expectedUserName, userName)
+		assert.Equal("session-1", GetSessionFromJWTToken(parsedToken))
+	}
+}
+
+func TestGetUserFromJWTTokenClaims(t *testing.T) {
+	testCases := []struct {
+		name   string
+		claims jwt.Claims
+		err    string
+	}{
+		{"no sub", jwt.MapClaims{"username": "john101"}, "the sub claim is missing or not a string"},
+		{"numeric sub", jwt.MapClaims{"sub": 1001.0, "username": "john101"}, "the sub claim is missing or not a string"},
+		{"no username", jwt.MapClaims{"sub": "1001"}, "the username claim is missing or not a string"},
+		{"empty username", jwt.MapClaims{"sub": "1001", "username": ""}, "user ID or user name is empty"},
+		{"registered claims", &jwt.RegisteredClaims{Subject: "1001"}, "unexpected claims of type *jwt.RegisteredClaims"},
+	}
+
+	// The tokens missing the claims are refused rather than panicking
+	for _, testCase := range testCases {
+		_, _, err := GetUserFromJWTToken(&jwt.Token{Claims: testCase.claims})
+		assert.EqualError(t, err, testCase.err, testCase.name)
+	}
+}
+
+func TestAccessTokenTTL(t *testing.T) {
+	assert := assert.New(t)
+	ks := testKeys(t)
+
+	// The access tokens are short-lived
+	token, err := ks.CreateJWTToken("1001", "john101", "session-1")
+	assert.Nil(err)
+	parsed, err := ks.Parse(token)
+	if assert.Nil(err) {
+		exp := int64(parsed.Claims.(jwt.MapClaims)["exp"].(float64))
+		assert.InDelta(time.Now().Add(DefaultAccessTokenTTL).Unix(), exp, 5)
+	}
+
+	ks.AccessTokenTTL = -time.Minute
+	token, err = ks.CreateJWTToken("1001", "john101", "session-1")
+	assert.Nil(err)
+	_, err = ks.Parse(token)
+	assert.NotNil(err)
+
+	// The tokens issued before the sessions have none
+	token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1001"}).SignedString([]byte("a test secret"))
+	assert.Nil(err)
+	parsed, err = ks.Parse(token)
+	if assert.Nil(err) {
+		assert.Equal("", GetSessionFromJWTToken(parsed))
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	assert := assert.New(t)
+	token, hash, err := NewRefreshToken()
+	assert.Nil(err)
+	assert.Len(token, 43)
+	assert.Equal(HashRefreshToken(token), hash)
+	assert.Len(hash, 64)
+
+	other, _, err := NewRefreshToken()
+	assert.Nil(err)
+	assert.NotEqual(token, other)
+}
+
+func TestRotation(t *testing.T) {
+	assert := assert.New(t)
+	old := Key{ID: "2021-10", Secret: []byte("the old secret")}
+	current := Key{ID: "2021-11", Secret: []byte("the new secret")}
+
+	before := testKeys(t, old)
+	oldToken, err := before.CreateJWTToken("1001", "john101", "session-1")
+	assert.Nil(err)
+
+	// The new key signs, the old one still verifies the tokens it signed
+	rotated := testKeys(t, current, old)
+	newToken, err := rotated.CreateJWTToken("1001", "john101", "session-1")
+	assert.Nil(err)
+	_, err = rotated.Parse(oldToken)
+	assert.Nil(err)
+	_, err = rotated.Parse(newToken)
+	assert.Nil(err)
+	_, err = before.Parse(newToken)
+	assert.NotNil(err)
+
+	// Once the old key is removed its tokens are rejected
+	after := testKeys(t, current)
+	_, err = after.Parse(oldToken)
+	assert.NotNil(err)
+	_, err = after.Parse(newToken)
+	assert.Nil(err)
+}
+
+func TestKeyfunc(t *testing.T) {
+	assert := assert.New(t)
+	ks := testKeys(t)
+	claims := jwt.MapClaims{"sub": "1001", "username": "john101"}
+
+	// The tokens without kid are verified with the signing key
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("a test secret"))
+	assert.Nil(err)
+	_, err = ks.Parse(token)
+	assert.Nil(err)
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = "unknown"
+	token, err = forged.SignedString([]byte("a test secret"))
+	assert.Nil(err)
+	_, err = ks.Parse(token)
+	assert.NotNil(err)
+
+	token, err = jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	assert.Nil(err)
+	_, err = ks.Parse(token)
+	assert.NotNil(err)
+}
+
+func TestNewKeySet(t *testing.T) {
+	key := Key{ID: "a", Secret: []byte("secret")}
+	testCases := []struct {
+		signingID string
+		keys      []Key
+		expected  string
+	}{
+		{keys: nil, expected: "no key is given"},
+		{keys: []Key{{Secret: []byte("secret")}}, expected: "a key has no ID"},
+		{keys: []Key{{ID: "a"}}, expected: `the key "a" has no secret`},
+		{keys: []Key{key, key}, expected: `the key "a" is given twice`},
+		{signingID: "b", keys: []Key{key}, expected: `the signing key "b" is not given`},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		_, err := NewKeySet(testCase.signingID, testCase.keys...)
+		if assert.NotNil(err) {
+			assert.Equal(testCase.expected, err.Error())
+		}
+	}
+
+	ks, err := NewKeySet("b", key, Key{ID: "b", Secret: []byte("other")})
+	if assert.Nil(err) {
+		assert.Equal("b", ks.SigningKeyID())
 	}
 }
+
+func TestKeySources(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := ParseKey("2021-11:a:secret")
+	assert.Nil(err)
+	assert.Equal(Key{ID: "2021-11", Secret: []byte("a:secret")}, key)
+	_, err = ParseKey("secret")
+	assert.NotNil(err)
+
+	path := filepath.Join(t.TempDir(), "2021-11.key")
+	err = ioutil.WriteFile(path, []byte("file secret\n"), 0o600)
+	assert.Nil(err)
+	key, err = ReadKeyFile(path)
+	assert.Nil(err)
+	assert.Equal(Key{ID: "2021-11", Secret: []byte("file secret")}, key)
+}
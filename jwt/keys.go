@@ -0,0 +1,229 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// minRSABits is the smallest size of the RSA keys
+const minRSABits = 2048
+
+//Key is a key signing and verifying the tokens. Its ID is the kid header of the tokens
+//it signs. A key has either a secret, signing with HS256, or a private key.
+type Key struct {
+	ID string
+	// Secret is the secret of the HS256 keys, which only the app can verify
+	Secret []byte
+	// Private is the private key of the keys whose public key is published: an
+	// *rsa.PrivateKey signing with RS256, an *ecdsa.PrivateKey on P-256 signing with
+	// ES256 or an ed25519.PrivateKey signing with EdDSA
+	Private crypto.Signer
+}
+
+// method returns the signing method of the key
+func (k Key) method() (jwt.SigningMethod, error) {
+	if k.Private == nil {
+		if len(k.Secret) == 0 {
+			return nil, fmt.Errorf("the key %q has no secret", k.ID)
+		}
+		return jwt.SigningMethodHS256, nil
+	}
+	if len(k.Secret) > 0 {
+		return nil, fmt.Errorf("the key %q has both a secret and a private key", k.ID)
+	}
+
+	switch private := k.Private.(type) {
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("the RSA key %q has less than %d bits", k.ID, minRSABits)
+		}
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		if private.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("the ECDSA key %q is not on the P-256 curve", k.ID)
+		}
+		return jwt.SigningMethodES256, nil
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("the private key %q is neither RSA, ECDSA nor Ed25519", k.ID)
+	}
+}
+
+// verifying returns the key verifying the signatures of the key
+func (k Key) verifying() interface{} {
+	if k.Private == nil {
+		return k.Secret
+	}
+	return k.Private.Public()
+}
+
+//KeySet is the keys of the app. One key signs the new tokens, every key verifies the
+//tokens carrying its ID, so that a key is rotated by adding a new signing key and
+//removing the old one once the tokens it signed have expired.
+type KeySet struct {
+	// Issuer is the iss claim of the new tokens, the base URL of the app, which
+	// is left out when empty
+	Issuer string
+	// AccessTokenTTL is the lifetime of the new tokens, DefaultAccessTokenTTL when zero
+	AccessTokenTTL time.Duration
+
+	signing Key
+	keys    []Key
+	methods map[string]jwt.SigningMethod
+}
+
+//NewKeySet returns the key set signing with the key of signingID, or with the first
+//key when signingID is empty
+func NewKeySet(signingID string, keys ...Key) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no key is given")
+	}
+	if signingID == "" {
+		signingID = keys[0].ID
+	}
+
+	ks := &KeySet{keys: keys, methods: map[string]jwt.SigningMethod{}}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, fmt.Errorf("a key has no ID")
+		}
+		if _, ok := ks.methods[key.ID]; ok {
+			return nil, fmt.Errorf("the key %q is given twice", key.ID)
+		}
+		method, err := key.method()
+		if err != nil {
+			return nil, err
+		}
+		ks.methods[key.ID] = method
+		if key.ID == signingID {
+			ks.signing = key
+		}
+	}
+	if ks.signing.ID == "" {
+		return nil, fmt.Errorf("the signing key %q is not given", signingID)
+	}
+	return ks, nil
+}
+
+//ParseKey parses a secret key given as ID:secret
+func ParseKey(s string) (Key, error) {
+	i := strings.Index(s, ":")
+	if i < 0 {
+		return Key{}, fmt.Errorf("the key is not given as ID:secret")
+	}
+	return Key{ID: s[:i], Secret: []byte(s[i+1:])}, nil
+}
+
+//ReadKeyFile reads a key from a file holding either its secret or its private key in
+//PEM, as PKCS #8 or as the PKCS #1 and SEC 1 of the RSA and ECDSA keys. The ID of
+//the key is the name of the file without its extension, the spaces around the
+//secret are trimmed.
+func ReadKeyFile(path string) (Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("unable to read the key file: %s", err)
+	}
+	name := filepath.Base(path)
+	key := Key{ID: strings.TrimSuffix(name, filepath.Ext(name))}
+
+	data = []byte(strings.TrimSpace(string(data)))
+	if !strings.HasPrefix(string(data), "-----BEGIN") {
+		key.Secret = data
+		return key, nil
+	}
+	key.Private, err = parsePrivateKey(data)
+	if err != nil {
+		return Key{}, fmt.Errorf("unable to read the private key of %s: %s", path, err)
+	}
+	return key, nil
+}
+
+// parsePrivateKey parses a private key in PEM
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("not a PEM block")
+	}
+
+	var private interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%q is not a private key", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key")
+	}
+	return signer, nil
+}
+
+//SigningKeyID returns the ID of the key signing the new tokens
+func (ks *KeySet) SigningKeyID() string {
+	return ks.signing.ID
+}
+
+//Sign signs the claims with the signing key
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.methods[ks.signing.ID], claims)
+	token.Header["kid"] = ks.signing.ID
+	if ks.signing.Private != nil {
+		return token.SignedString(ks.signing.Private)
+	}
+	return token.SignedString(ks.signing.Secret)
+}
+
+//Keyfunc returns the key verifying a token, chosen by its kid header, and checks that
+//the token is signed with the method of the key. The tokens without kid, signed
+//before the keys had IDs, are verified with the signing key.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	key := ks.signing
+	if kid, ok := token.Header["kid"]; ok {
+		id, _ := kid.(string)
+		key = ks.key(id)
+		if key.ID == "" {
+			return nil, fmt.Errorf("unknown key %v", kid)
+		}
+	}
+	if token.Method != ks.methods[key.ID] {
+		return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+	}
+	return key.verifying(), nil
+}
+
+// key returns the key of the ID, the zero key when there is none
+func (ks *KeySet) key(id string) Key {
+	for _, key := range ks.keys {
+		if key.ID == id {
+			return key
+		}
+	}
+	return Key{}
+}
+
+//Parse parses and verifies a token
+func (ks *KeySet) Parse(token string) (*jwt.Token, error) {
+	return jwt.Parse(token, ks.Keyfunc)
+}
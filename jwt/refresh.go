@@ -0,0 +1,31 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// refreshTokenSize is the number of random bytes of a refresh token
+const refreshTokenSize = 32
+
+//NewRefreshToken returns a random refresh token along with its hash, which is the only
+//form of the token that is stored
+func NewRefreshToken() (string, string, error) {
+	b := make([]byte, refreshTokenSize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to generate a refresh token: %s", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashRefreshToken(token), nil
+}
+
+//HashRefreshToken returns the SHA-256 hash of a refresh token in hex. The tokens are
+//random, so that they need no salt.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,96 +1,296 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"flag"
 	"os"
 
+	"local/sidharthjs/todo/config"
 	migrate "local/sidharthjs/todo/db"
+	"local/sidharthjs/todo/handlers/adminhandler"
 	"local/sidharthjs/todo/handlers/authhandler"
 	"local/sidharthjs/todo/handlers/noteshandler"
+	"local/sidharthjs/todo/handlers/problem"
 	"local/sidharthjs/todo/middleware"
+	"local/sidharthjs/todo/notestore"
+	"local/sidharthjs/todo/notestore/cache"
+	"local/sidharthjs/todo/notestore/gitstore"
+	"local/sidharthjs/todo/notestore/memory"
 	"local/sidharthjs/todo/notestore/postgres"
+	"local/sidharthjs/todo/notestore/sqlite"
+	"local/sidharthjs/todo/sessionstore"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	log "github.com/sirupsen/logrus"
 )
 
+// version is the version of the build, set with -ldflags "-X main.version=..."
+var version = "dev"
+
+// Migrations of the databases of the stores
+const (
+	postgresMigrations = "file://db/migrations"
+	sqliteMigrations   = "file://db/migrations/sqlite"
+)
+
 func main() {
 
 	// Set log level to debug
 	log.SetLevel(log.DebugLevel)
 
-	// Perform DB Migration
-	postgresUser := readEnv("POSTGRES_USER")
-	postgresPassword := readEnv("POSTGRES_PASSWORD")
-	postgresDB := readEnv("POSTGRES_DB")
-	postgresHost := readEnv("POSTGRES_HOST")
-	postgresPort := readEnv("POSTGRES_PORT")
-	dbURL := "postgres://" + postgresUser + ":" + postgresPassword + "@" + postgresHost + ":" + postgresPort + "/" + postgresDB + "?sslmode=disable"
-	err := migrate.Migrate(dbURL, "file://db/migrations")
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if err == flag.ErrHelp {
+		return
+	}
+	var cfgErr *config.Error
+	if errors.As(err, &cfgErr) {
+		for _, problem := range cfgErr.Problems {
+			log.Error(problem)
+		}
+		log.Fatal("invalid configuration, see the errors above")
+	}
 	if err != nil {
-		log.Fatalf("error performing db migration: %s", err)
+		log.Fatal(err)
 	}
+	fields := log.Fields{}
+	for name, value := range cfg.Redacted() {
+		fields[name] = value
+	}
+	log.WithFields(fields).Info("effective configuration")
 
-	// Init DB
-	db, err := postgres.NewClient(dbURL)
+	// The keys were checked along with the configuration
+	keys, err := cfg.JWT.KeySet()
 	if err != nil {
-		log.Fatalf("error creating db client: %s", err)
+		log.Fatal(err)
 	}
-	defer db.Close()
+	log.Infof("the JWT tokens are signed with the key %s", keys.SigningKeyID())
 
-	err = db.Ping()
-	if err != nil {
-		log.Fatal("unable to ping the db")
+	// Init the note store along with its diagnostics, and the session store kept in the
+	// database of the note store or in a SQLite file of its own
+	var db noteStore
+	var sessions sessionstore.Store
+	var sqlDB *sql.DB
+	var migrations adminhandler.MigrationVersion
+	checks := map[string]adminhandler.Check{}
+	switch cfg.Store {
+	case "postgres":
+		pg := openPostgres(cfg.Postgres)
+		defer pg.Close()
+		db, sqlDB = pg, pg.DB
+		sessions = sessionstore.NewSQL(pg.DB, pg.Dialect())
+		migrations = func() (uint, bool, error) {
+			return migrate.Version(cfg.Postgres.ConnString(), postgresMigrations)
+		}
+		checks["store"] = pg.PingContext
+	case "sqlite":
+		lite := openSQLite(cfg.SQLitePath)
+		defer lite.Close()
+		db, sqlDB = lite, lite.DB
+		sessions = sessionstore.NewSQL(lite.DB, lite.Dialect())
+		migrations = func() (uint, bool, error) {
+			return migrate.Version("sqlite://"+cfg.SQLitePath, sqliteMigrations)
+		}
+		checks["store"] = lite.Check
+	case "git":
+		repo, err := gitstore.New(cfg.GitRepoPath)
+		if err != nil {
+			log.Fatalf("error opening the note repository: %s", err)
+		}
+		db = repo
+		sessions = openSessions(cfg.SessionsPath, checks)
+	case "memory":
+		log.Warn("the notes are kept in memory and are lost when the app stops")
+		db = memory.New()
+		sessions = sessionstore.NewMemory()
+		if cfg.SessionsPath != "" {
+			sessions = openSessions(cfg.SessionsPath, checks)
+		}
 	}
+	db = openCache(db, cfg.Cache, checks)
+
+	// Purge the trash in the background
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go notestore.RunPurger(ctx, db, cfg.TrashRetention, cfg.TrashPurgeInterval)
 
 	// Init handlers
-	authHandler := authhandler.New(readEnv("GITHUB_CLIENT_ID"), readEnv("GITHUB_CLIENT_SECRET"),
-		readEnv("LOGIN_URI"), readEnv("ACCESS_TOKEN_URI"),
-		readEnv("REDIRECT_URI"), readEnv("PROFILE_URI"), readEnv("USERS_SVC_ENDPOINT"))
-	notesHandler := noteshandler.New(db)
+	authHandler := authhandler.New(cfg.GitHub.ClientID, cfg.GitHub.ClientSecret,
+		cfg.GitHub.LoginURI, cfg.GitHub.AccessTokenURI,
+		cfg.GitHub.RedirectURI, cfg.GitHub.ProfileURI, cfg.UsersServiceEndpoint,
+		keys, sessions, cfg.JWT.SessionTTL)
+	idempotencyWindow := cfg.IdempotencyWindow
+	notesHandler := noteshandler.New(db, cfg.Limits)
+	adminHandler := adminhandler.New(version, cfg.Redacted(), cfg.Store, sqlDB, migrations, checks)
 
 	// Define routes
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		// Errors are sent as problem details with the ID of the request
+		ErrorHandler: problem.ErrorHandler,
+	})
+	app.Use(requestid.New())
 	app.Static("/", "./public/login.html")
 	app.Get("/login/github", authHandler.InitiateOAuth)
 	app.Get("/github/callback", authHandler.ProcessCallback)
-
-	app.Get("/env", func(c *fiber.Ctx) error {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"POSTGRES_USER":        readEnv("POSTGRES_USER"),
-			"POSTGRES_PASSWORD":    readEnv("POSTGRES_PASSWORD"),
-			"POSTGRES_DB":          readEnv("POSTGRES_DB"),
-			"POSTGRES_HOST":        readEnv("POSTGRES_HOST"),
-			"POSTGRES_PORT":        readEnv("POSTGRES_PORT"),
-			"GITHUB_CLIENT_ID":     readEnv("GITHUB_CLIENT_ID"),
-			"GITHUB_CLIENT_SECRET": readEnv("GITHUB_CLIENT_SECRET"),
-			"LOGIN_URI":            readEnv("LOGIN_URI"),
-			"ACCESS_TOKEN_URI":     readEnv("ACCESS_TOKEN_URI"),
-			"REDIRECT_URI":         readEnv("REDIRECT_URI"),
-			"PROFILE_URI":          readEnv("PROFILE_URI"),
-			"USERS_SVC_ENDPOINT":   readEnv("USERS_SVC_ENDPOINT"),
-		})
-	})
+	app.Get(authhandler.JWKSPath, authHandler.JWKS)
+	app.Get(authhandler.DiscoveryPath, authHandler.Discovery)
+	app.Post("/auth/refresh", authHandler.Refresh)
+	app.Post("/auth/logout", authHandler.Logout)
 
 	app.Get("/users", func(c *fiber.Ctx) error {
-		return c.Redirect(readEnv("USERS_SVC_ENDPOINT") + "/users")
+		return c.Redirect(cfg.UsersServiceEndpoint + "/users")
 	})
 
-	middleware.SetupAuthentication(app)
+	middleware.SetupAuthentication(app, keys)
+
+	// The diagnostics of the app, for the users with the admin role
+	admin := app.Group("/admin", middleware.RequireAdmin(cfg.AdminUsers))
+	admin.Get("/build", adminHandler.Build)
+	admin.Get("/config", adminHandler.Config)
+	admin.Get("/db", adminHandler.Database)
+	admin.Get("/health", adminHandler.Health)
+	admin.Get("/metrics", adminHandler.Metrics)
 
+	app.Get("/notes/search", notesHandler.SearchNotes)
 	app.Get("/notes/:note_id", notesHandler.ReadNote)
 	app.Put("/notes/:note_id", notesHandler.UpdateNote)
+	app.Patch("/notes/:note_id", notesHandler.PatchNote)
 	app.Get("/notes", notesHandler.ReadNotes)
-	app.Post("/notes", notesHandler.CreateNote)
+	app.Post("/notes", middleware.Idempotency(db, idempotencyWindow), notesHandler.CreateNote)
+	app.Post("/notes\\:batch", middleware.Idempotency(db, idempotencyWindow), notesHandler.BatchNotes)
 	app.Delete("/notes/:note_id", notesHandler.DeleteNote)
+	app.Post("/notes/:note_id/complete", notesHandler.CompleteNote)
+	app.Get("/notes/:note_id/occurrences", notesHandler.PreviewOccurrences)
+	app.Post("/notes/:note_id/skip", notesHandler.SkipOccurrence)
+	app.Post("/notes/:note_id/stop", notesHandler.StopRecurrence)
+	app.Post("/notes/:note_id/tags", notesHandler.AddTags)
+	app.Delete("/notes/:note_id/tags/:tag", notesHandler.RemoveTag)
+	app.Get("/notes/:note_id/items", notesHandler.ListItems)
+	app.Post("/notes/:note_id/items", notesHandler.CreateItem)
+	app.Post("/notes/:note_id/items/reorder", notesHandler.ReorderItems)
+	app.Put("/notes/:note_id/items/:item_id", notesHandler.UpdateItem)
+	app.Delete("/notes/:note_id/items/:item_id", notesHandler.DeleteItem)
+	app.Put("/notes/:note_id/project", notesHandler.MoveNote)
+	app.Get("/notes/:note_id/revisions", notesHandler.ListRevisions)
+	app.Get("/notes/:note_id/revisions/diff", notesHandler.DiffRevisions)
+	app.Get("/notes/:note_id/revisions/:revision", notesHandler.ReadRevision)
+	app.Post("/notes/:note_id/revisions/:revision/restore", notesHandler.RestoreRevision)
+
+	app.Get("/tags", notesHandler.ListTags)
+	app.Post("/tags/merge", notesHandler.MergeTags)
+	app.Put("/tags/:tag", notesHandler.RenameTag)
+
+	app.Get("/projects", notesHandler.ListProjects)
+	app.Post("/projects", notesHandler.CreateProject)
+	app.Get("/projects/:project_id", notesHandler.ReadProject)
+	app.Put("/projects/:project_id", notesHandler.RenameProject)
+	app.Delete("/projects/:project_id", notesHandler.DeleteProject)
+	app.Post("/projects/:project_id/archive", notesHandler.ArchiveProject)
+	app.Post("/projects/:project_id/unarchive", notesHandler.UnarchiveProject)
+	app.Get("/projects/:project_id/notes", notesHandler.ReadNotes)
+
+	app.Get("/trash", notesHandler.ListTrash)
+	app.Post("/trash/:note_id/restore", notesHandler.RestoreNote)
+	app.Delete("/trash/:note_id", notesHandler.PurgeNote)
+
+	app.Get("/sessions", authHandler.ListSessions)
+	app.Delete("/sessions/:session_id", authHandler.RevokeSession)
+
+	// Unknown routes are answered with a problem too
+	app.Use(func(c *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
 
 	log.Info("app running...")
-	log.Fatal(app.Listen(":4010"))
+	log.Fatal(app.Listen(cfg.Addr))
+}
+
+// noteStore is a note store with the features used outside of the handlers
+type noteStore interface {
+	notestore.NoteStore
+	notestore.TrashStore
+	notestore.IdempotencyStore
+}
+
+// openCache puts the note store behind the configured cache, an LRU in the process or
+// Redis whose health is added to checks. The store is returned as is when the cache is
+// disabled.
+func openCache(db noteStore, cfg config.Cache, checks map[string]adminhandler.Check) noteStore {
+	if cfg.Backend == "" {
+		return db
+	}
+	backing, ok := db.(cache.Backing)
+	if !ok {
+		log.Fatal("the cache needs a note store with every feature, unset CACHE for this store")
+	}
+
+	var backend cache.Backend
+	switch cfg.Backend {
+	case "memory":
+		backend = cache.NewLRU(cfg.Size)
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			log.Fatalf("REDIS_URL is not a valid redis URL: %s", err)
+		}
+		redisBackend := cache.NewRedis(redis.NewClient(opts))
+		checks["cache"] = redisBackend.Ping
+		backend = redisBackend
+	}
+
+	cached := cache.New(backing, backend, cfg.TTL)
+	expvar.Publish("note_cache", cached.Metrics())
+	return cached
+}
+
+// openPostgres migrates the Postgres database and connects to it
+func openPostgres(cfg config.Postgres) *postgres.DB {
+	dbURL := cfg.ConnString()
+	err := migrate.Migrate(dbURL, postgresMigrations)
+	if err != nil {
+		log.Fatalf("error performing db migration: %s", err)
+	}
+
+	db, err := postgres.NewClient(dbURL)
+	if err != nil {
+		log.Fatalf("error creating db client: %s", err)
+	}
+
+	err = db.Ping()
+	if err != nil {
+		log.Fatal("unable to ping the db")
+	}
+	return db
 }
 
-func readEnv(key string) string {
-	val, ok := os.LookupEnv(key)
-	if !ok || val == "" {
-		log.Fatalf("env variable %s is not set or empty", key)
+// openSQLite migrates the SQLite database in the file at path and connects to it
+func openSQLite(path string) *sqlite.DB {
+	err := migrate.Migrate("sqlite://"+path, sqliteMigrations)
+	if err != nil {
+		log.Fatalf("error performing db migration: %s", err)
+	}
+
+	db, err := sqlite.NewClient(path)
+	if err != nil {
+		log.Fatalf("error creating db client: %s", err)
 	}
-	return val
+
+	err = db.Ping()
+	if err != nil {
+		log.Fatal("unable to ping the db")
+	}
+	return db
+}
+
+// openSessions migrates the SQLite database of the sessions in the file at path and
+// returns its session store, whose health is added to checks. The file has the schema
+// of the SQLite store, whose notes tables are left empty.
+func openSessions(path string, checks map[string]adminhandler.Check) sessionstore.Store {
+	lite := openSQLite(path)
+	checks["sessions"] = lite.Check
+	return sessionstore.NewSQL(lite.DB, lite.Dialect())
 }
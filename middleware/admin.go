@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	jwtutil "local/sidharthjs/todo/jwt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequireAdmin lets the authenticated users having the admin role through, the users
+// whose IDs are in adminUsers. The other users are answered with 403.
+func RequireAdmin(adminUsers []string) fiber.Handler {
+	admins := map[string]bool{}
+	for _, userID := range adminUsers {
+		admins[userID] = true
+	}
+
+	return func(c *fiber.Ctx) error {
+		userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+		if err != nil {
+			log.Errorf("error in reading user details in jwt token: %s", err)
+
+			return fiber.ErrUnauthorized
+		}
+		if !admins[userID] {
+			log.Warnf("user '%s' is not an admin and was denied %s", userID, c.Path())
+			return fiber.NewError(fiber.StatusForbidden, "the admin role is required")
+		}
+		return c.Next()
+	}
+}
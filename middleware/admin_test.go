@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"local/sidharthjs/todo/handlers/problem"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		userID := utils.CopyString(c.Get("X-User"))
+		c.Locals("user", jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": userID, "username": "john101"}))
+		return c.Next()
+	})
+	app.Get("/admin/build", RequireAdmin([]string{"1001"}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	get := func(userID string) int {
+		req := httptest.NewRequest("GET", "/admin/build", nil)
+		req.Header.Set("X-User", userID)
+		resp, err := app.Test(req)
+		assert.Nil(t, err)
+		return resp.StatusCode
+	}
+
+	assert := assert.New(t)
+	assert.Equal(fiber.StatusOK, get("1001"))
+	assert.Equal(fiber.StatusForbidden, get("1002"))
+}
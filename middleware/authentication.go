@@ -1,23 +1,27 @@
 package middleware
 
 import (
+	jwtutil "local/sidharthjs/todo/jwt"
+
 	"github.com/gofiber/fiber/v2"
 	jwtware "github.com/gofiber/jwt/v3"
 	log "github.com/sirupsen/logrus"
 )
 
-const jwtSecret = "aJWTSecret"
+// protectedRoutes are the route prefixes that require authentication
+var protectedRoutes = []string{"/notes", "/tags", "/projects", "/trash", "/admin", "/sessions"}
 
-// SetupAuthentication set authentication middleware for /notes routes
-func SetupAuthentication(app *fiber.App) {
-	app.Use("/notes", jwtware.New(jwtware.Config{
+// SetupAuthentication set authentication middleware for the protected routes, the
+// tokens are verified with the keys
+func SetupAuthentication(app *fiber.App, keys *jwtutil.KeySet) {
+	auth := jwtware.New(jwtware.Config{
 		ErrorHandler: func(ctx *fiber.Ctx, err error) error {
 			log.Errorf("error in middleware authentication: %s", err)
-			ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
-			return nil
+			return fiber.ErrUnauthorized
 		},
-		SigningKey: []byte(jwtSecret),
-	}))
+		KeyFunc: keys.Keyfunc,
+	})
+	for _, route := range protectedRoutes {
+		app.Use(route, auth)
+	}
 }
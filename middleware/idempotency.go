@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	jwtutil "local/sidharthjs/todo/jwt"
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// Headers of the idempotent requests
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// idempotencyLease is how long a request holds its key: the request is canceled
+// after it, and a retry may then take the key over
+const idempotencyLease = time.Minute
+
+// Idempotency replays the first response to the requests of a user sent with the same
+// Idempotency-Key header within the window. Reusing a key for a different request
+// fails with 422, and retrying while the first request is in progress with 409 until
+// its lease runs out. Requests without the header are passed through.
+func Idempotency(store notestore.IdempotencyStore, window time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// The key is copied as the memory store keeps it and Fiber reuses its buffer
+		key := utils.CopyString(c.Get(HeaderIdempotencyKey))
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > notestore.MaxIdempotencyKeyLength {
+			return notestore.Invalidf("%s is longer than %d characters", HeaderIdempotencyKey, notestore.MaxIdempotencyKeyLength)
+		}
+
+		userID, _, err := jwtutil.GetUserFromJWTToken(c.Locals("user").(*jwt.Token))
+		if err != nil {
+			log.Errorf("error in reading user details in jwt token: %s", err)
+
+			return fiber.ErrUnauthorized
+		}
+
+		hash := requestHash(c)
+		now := time.Now()
+		resp, reserved, err := store.ReserveIdempotencyKey(c.UserContext(), userID, key, hash, now.Add(-window), now.Add(-idempotencyLease))
+		if err != nil {
+			return fmt.Errorf("unable to reserve idempotency key '%s': %w", key, err)
+		}
+		if !reserved {
+			switch {
+			case resp.RequestHash != hash:
+				return fiber.NewError(fiber.StatusUnprocessableEntity,
+					fmt.Sprintf("%s '%s' was already used for a different request", HeaderIdempotencyKey, key))
+			case resp.Status == 0:
+				return notestore.Conflictf("the request with %s '%s' is still in progress", HeaderIdempotencyKey, key)
+			}
+			c.Set(HeaderIdempotentReplayed, "true")
+			c.Set(fiber.HeaderContentType, resp.ContentType)
+			return c.Status(resp.Status).Send(resp.Body)
+		}
+
+		ctx := c.UserContext()
+		leaseCtx, cancel := context.WithTimeout(ctx, idempotencyLease)
+		defer cancel()
+		c.SetUserContext(leaseCtx)
+		err = c.Next()
+		c.SetUserContext(ctx)
+		if leaseCtx.Err() == context.DeadlineExceeded {
+			// A retry may hold the key by now, the reservation is left to expire
+			log.Errorf("the request with idempotency key '%s' outlasted its lease of %s", key, idempotencyLease)
+			return err
+		}
+
+		// Failed requests are not replayed so that they can be retried
+		if err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError {
+			releaseErr := store.ReleaseIdempotencyKey(c.UserContext(), userID, key)
+			if releaseErr != nil {
+				log.Errorf("unable to release idempotency key '%s': %s", key, releaseErr)
+			}
+			return err
+		}
+
+		err = store.SaveIdempotentResponse(c.UserContext(), notestore.IdempotentResponse{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: hash,
+			Status:      c.Response().StatusCode(),
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        append([]byte(nil), c.Response().Body()...),
+		})
+		if err != nil {
+			// The response was successful, only its replay is lost
+			log.Errorf("unable to store the response of idempotency key '%s': %s", key, err)
+		}
+		return nil
+	}
+}
+
+// requestHash identifies a request by its method, path and body
+func requestHash(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method() + " " + c.Path() + "\n"))
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
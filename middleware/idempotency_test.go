@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"local/sidharthjs/todo/handlers/problem"
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// keyRecorder is an in-memory IdempotencyStore
+type keyRecorder struct {
+	mu    sync.Mutex
+	saved map[string]notestore.IdempotentResponse
+}
+
+func (r *keyRecorder) ReserveIdempotencyKey(ctx context.Context, userID, key, requestHash string, expiredBefore, abandonedBefore time.Time) (notestore.IdempotentResponse, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	resp, ok := r.saved[userID+"/"+key]
+	if ok && !resp.CreatedAt.Before(expiredBefore) && (resp.Status != 0 || !resp.CreatedAt.Before(abandonedBefore)) {
+		return resp, false, nil
+	}
+	r.saved[userID+"/"+key] = notestore.IdempotentResponse{UserID: userID, Key: key, RequestHash: requestHash, CreatedAt: time.Now()}
+	return notestore.IdempotentResponse{}, true, nil
+}
+
+func (r *keyRecorder) SaveIdempotentResponse(ctx context.Context, resp notestore.IdempotentResponse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	resp.CreatedAt = r.saved[resp.UserID+"/"+resp.Key].CreatedAt
+	r.saved[resp.UserID+"/"+resp.Key] = resp
+	return nil
+}
+
+func (r *keyRecorder) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.saved, userID+"/"+key)
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	store := &keyRecorder{saved: map[string]notestore.IdempotentResponse{}}
+	calls := 0
+	var deadline time.Time
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1001", "username": "john101"}))
+		return c.Next()
+	})
+	app.Post("/notes", Idempotency(store, time.Hour), func(c *fiber.Ctx) error {
+		calls++
+		deadline, _ = c.UserContext().Deadline()
+		if strings.Contains(string(c.Body()), "fail") {
+			return fiber.ErrServiceUnavailable
+		}
+		return c.Status(fiber.StatusCreated).SendString(fmt.Sprintf(`{"msg":%d}`, calls))
+	})
+
+	post := func(key, body string) (int, string, string) {
+		req := httptest.NewRequest("POST", "/notes", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if key != "" {
+			req.Header.Set(HeaderIdempotencyKey, key)
+		}
+		resp, err := app.Test(req)
+		assert.Nil(t, err)
+		b, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(b), resp.Header.Get(HeaderIdempotentReplayed)
+	}
+
+	assert := assert.New(t)
+	status, body, replayed := post("key-1", `{"title": "Deploy"}`)
+	assert.Equal(fiber.StatusCreated, status)
+	assert.Equal(`{"msg":1}`, body)
+	assert.Empty(replayed)
+	assert.WithinDuration(time.Now().Add(idempotencyLease), deadline, time.Second)
+
+	// Retries replay the first response
+	status, body, replayed = post("key-1", `{"title": "Deploy"}`)
+	assert.Equal(fiber.StatusCreated, status)
+	assert.Equal(`{"msg":1}`, body)
+	assert.Equal("true", replayed)
+	assert.Equal(1, calls)
+
+	status, _, _ = post("key-1", `{"title": "Release"}`)
+	assert.Equal(fiber.StatusUnprocessableEntity, status)
+	status, _, _ = post("", `{"title": "Deploy"}`)
+	assert.Equal(fiber.StatusCreated, status)
+	assert.Equal(2, calls)
+
+	// Failed requests can be retried
+	status, _, _ = post("key-2", `{"title": "fail"}`)
+	assert.Equal(fiber.StatusServiceUnavailable, status)
+	status, _, _ = post("key-2", `{"title": "fail"}`)
+	assert.Equal(fiber.StatusServiceUnavailable, status)
+	assert.Equal(4, calls)
+
+	// A retry while the first request is in progress conflicts
+	_, reserved, _ := store.ReserveIdempotencyKey(context.Background(), "1001", "key-3", requestHashOf("POST", "/notes", `{}`), time.Now(), time.Now())
+	assert.True(reserved)
+	status, _, _ = post("key-3", `{}`)
+	assert.Equal(fiber.StatusConflict, status)
+
+	// A retry takes the key over once the lease of the first request ran out
+	store.mu.Lock()
+	abandoned := store.saved["1001/key-3"]
+	abandoned.CreatedAt = time.Now().Add(-idempotencyLease - time.Second)
+	store.saved["1001/key-3"] = abandoned
+	store.mu.Unlock()
+	status, body, replayed = post("key-3", `{}`)
+	assert.Equal(fiber.StatusCreated, status)
+	assert.Equal(`{"msg":5}`, body)
+	assert.Empty(replayed)
+
+	status, _, _ = post(strings.Repeat("k", notestore.MaxIdempotencyKeyLength+1), `{}`)
+	assert.Equal(fiber.StatusBadRequest, status)
+}
+
+// requestHashOf returns the requestHash of a request
+func requestHashOf(method, path, body string) string {
+	var hash string
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		hash = requestHash(c)
+		return nil
+	})
+	app.Test(httptest.NewRequest(method, path, strings.NewReader(body)))
+	return hash
+}
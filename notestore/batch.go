@@ -0,0 +1,43 @@
+package notestore
+
+import "errors"
+
+// BatchAction is the action of a batch operation
+type BatchAction string
+
+// Actions of the batch operations
+const (
+	BatchCreate BatchAction = "create"
+	BatchUpdate BatchAction = "update"
+	BatchDelete BatchAction = "delete"
+)
+
+// ErrBatchAborted is the error of the operations of an atomic batch rolled back
+// because another operation of the batch failed
+var ErrBatchAborted = errors.New("operation is rolled back because another operation of the batch failed")
+
+// BatchOperation is an operation of a batch on a note of the user of the batch
+type BatchOperation struct {
+	Action BatchAction
+	// Note is the note to create, the note to delete or the note with the updated
+	// fields. The version of the note is checked as by Update and Delete when set.
+	Note Note
+	// Fields are the fields of the note changed by an update
+	Fields []NoteField
+}
+
+// BatchResult is the result of a batch operation, Err is nil when it succeeded
+type BatchResult struct {
+	NoteID string
+	Err    error
+}
+
+// AbortBatch returns the results of an atomic batch that failed at the operation
+// failed with err, the other operations are aborted
+func AbortBatch(results []BatchResult, failed int, err error) []BatchResult {
+	for i := range results {
+		results[i].Err = ErrBatchAborted
+	}
+	results[failed].Err = err
+	return results
+}
@@ -0,0 +1,17 @@
+package notestore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAbortBatch(t *testing.T) {
+	results := []BatchResult{{NoteID: "1"}, {NoteID: "2"}, {NoteID: "3"}}
+	results = AbortBatch(results, 1, ErrVersionMismatch)
+	assert.Equal(t, []BatchResult{
+		{NoteID: "1", Err: ErrBatchAborted},
+		{NoteID: "2", Err: ErrVersionMismatch},
+		{NoteID: "3", Err: ErrBatchAborted},
+	}, results)
+}
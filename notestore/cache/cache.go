@@ -0,0 +1,228 @@
+// Package cache is a read-through cache of the notes in front of a note store.
+// Read and ReadAll are cached per user in a Backend, every change of the notes of a
+// user through the cache invalidates the cached notes and pages of that user.
+//
+// The invalidation moves the user to a new generation of keys rather than deleting
+// the entries, which are left to expire. A read racing with a change may cache the
+// note it read under the old generation, which is never read again.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/google/uuid"
+)
+
+//Defaults of the cache
+const (
+	DefaultTTL  = time.Minute
+	DefaultSize = 10000
+)
+
+//Backend holds the entries of the cache
+type Backend interface {
+	// Get returns the value of the key and false when it is not found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set sets the value of the key for ttl, a zero ttl never expires
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+//Backing is the store behind the cache, a store with every feature of the notes so
+//that the cache can invalidate the notes on every change
+type Backing interface {
+	notestore.NoteStore
+	notestore.TagStore
+	notestore.ItemStore
+	notestore.ProjectStore
+	notestore.TrashStore
+	notestore.RevisionStore
+	notestore.IdempotencyStore
+}
+
+//Store is a note store caching the reads of the backing store, see New
+type Store struct {
+	store   Backing
+	backend Backend
+	ttl     time.Duration
+
+	metrics *expvar.Map
+	hits    *expvar.Int
+	misses  *expvar.Int
+	errors  *expvar.Int
+}
+
+//New returns a store caching the reads of store in backend for ttl
+func New(store Backing, backend Backend, ttl time.Duration) *Store {
+	s := &Store{
+		store:   store,
+		backend: backend,
+		ttl:     ttl,
+		metrics: new(expvar.Map).Init(),
+		hits:    new(expvar.Int),
+		misses:  new(expvar.Int),
+		errors:  new(expvar.Int),
+	}
+	s.metrics.Set("hits", s.hits)
+	s.metrics.Set("misses", s.misses)
+	s.metrics.Set("errors", s.errors)
+	return s
+}
+
+//Metrics returns the counters of the cache: the hits and misses of the reads, and the
+//errors of the backend. They are published with expvar.Publish.
+func (s *Store) Metrics() *expvar.Map {
+	return s.metrics
+}
+
+// generationKey is the key of the current generation of the keys of the user
+func generationKey(userID string) string {
+	return fmt.Sprintf("notes:%q:generation", userID)
+}
+
+// generation returns the current generation of the keys of the user, a user without
+// one is moved to a new generation
+func (s *Store) generation(ctx context.Context, userID string) (string, error) {
+	generation, ok, err := s.backend.Get(ctx, generationKey(userID))
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return string(generation), nil
+	}
+	return s.invalidate(ctx, userID)
+}
+
+// invalidate moves the user to a new generation, the cached notes and pages of the
+// user are not read anymore. The generation expires too, the entries of an expired
+// generation are never read as a user without a generation gets a new one.
+func (s *Store) invalidate(ctx context.Context, userID string) (string, error) {
+	generation := uuid.New().String()
+	err := s.backend.Set(ctx, generationKey(userID), []byte(generation), 2*s.ttl)
+	if err != nil {
+		return "", err
+	}
+	return generation, nil
+}
+
+// invalidated invalidates the notes of the user after a change and returns the error
+// of the change. Failed changes are invalidated too, the operations of a batch may
+// have succeeded. A change is not failed by the backend, whose stale entries expire.
+func (s *Store) invalidated(ctx context.Context, userID string, err error) error {
+	if _, invalidateErr := s.invalidate(ctx, userID); invalidateErr != nil {
+		s.errors.Add(1)
+	}
+	return err
+}
+
+// cached reads the value of the key of the user from the backend into value, or reads
+// it with read and caches it. The backend failing falls back to read.
+func (s *Store) cached(ctx context.Context, userID, key string, value interface{}, read func() error) error {
+	generation, err := s.generation(ctx, userID)
+	if err != nil {
+		s.errors.Add(1)
+		return read()
+	}
+	key = fmt.Sprintf("notes:%q:%s:%s", userID, generation, key)
+
+	data, ok, err := s.backend.Get(ctx, key)
+	if err != nil {
+		s.errors.Add(1)
+	}
+	if ok && json.Unmarshal(data, value) == nil {
+		s.hits.Add(1)
+		return nil
+	}
+
+	s.misses.Add(1)
+	err = read()
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(value)
+	if err == nil {
+		err = s.backend.Set(ctx, key, data, s.ttl)
+	}
+	if err != nil {
+		s.errors.Add(1)
+	}
+	return nil
+}
+
+//Create creates a note
+func (s *Store) Create(ctx context.Context, note notestore.Note) error {
+	return s.invalidated(ctx, note.UserID, s.store.Create(ctx, note))
+}
+
+//Read reads a note through the cache, notes which are not found are not cached
+func (s *Store) Read(ctx context.Context, noteID, userID string) (notestore.Note, error) {
+	var note notestore.Note
+	err := s.cached(ctx, userID, "note:"+noteID, &note, func() error {
+		var err error
+		note, err = s.store.Read(ctx, noteID, userID)
+		return err
+	})
+	return note, err
+}
+
+//ReadAll reads a page of notes through the cache, the pages are cached by their options
+func (s *Store) ReadAll(ctx context.Context, userID string, opts notestore.ReadAllOptions) (notestore.Page, error) {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return notestore.Page{}, err
+	}
+	hash := sha256.Sum256(data)
+
+	var page notestore.Page
+	err = s.cached(ctx, userID, "page:"+hex.EncodeToString(hash[:]), &page, func() error {
+		var err error
+		page, err = s.store.ReadAll(ctx, userID, opts)
+		return err
+	})
+	return page, err
+}
+
+//Search searches the notes, the results are not cached
+func (s *Store) Search(ctx context.Context, userID, query string, limit int) ([]notestore.SearchResult, error) {
+	return s.store.Search(ctx, userID, query, limit)
+}
+
+//Update updates a note
+func (s *Store) Update(ctx context.Context, note notestore.Note) error {
+	return s.invalidated(ctx, note.UserID, s.store.Update(ctx, note))
+}
+
+//UpdateFields updates the listed fields of a note
+func (s *Store) UpdateFields(ctx context.Context, note notestore.Note, fields []notestore.NoteField) error {
+	return s.invalidated(ctx, note.UserID, s.store.UpdateFields(ctx, note, fields))
+}
+
+//Complete sets the status of a note to done
+func (s *Store) Complete(ctx context.Context, noteID, userID string) (notestore.Note, error) {
+	next, err := s.store.Complete(ctx, noteID, userID)
+	return next, s.invalidated(ctx, userID, err)
+}
+
+//Delete deletes a note
+func (s *Store) Delete(ctx context.Context, noteID, userID string, version int) error {
+	return s.invalidated(ctx, userID, s.store.Delete(ctx, noteID, userID, version))
+}
+
+//Batch runs the operations of a batch
+func (s *Store) Batch(ctx context.Context, userID string, ops []notestore.BatchOperation, atomic bool) ([]notestore.BatchResult, error) {
+	results, err := s.store.Batch(ctx, userID, ops, atomic)
+	return results, s.invalidated(ctx, userID, err)
+}
+
+// The cache implements the interfaces of every feature of the notes
+var (
+	_ notestore.NoteStore = (*Store)(nil)
+	_ Backing             = (*Store)(nil)
+)
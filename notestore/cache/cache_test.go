@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+	"local/sidharthjs/todo/notestore/memory"
+	"local/sidharthjs/todo/notestore/notestoretest"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// testRedis returns a client of a Redis stand-in running in the test
+func testRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("error starting redis: %s", err)
+	}
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return server, client
+}
+
+func TestConformance(t *testing.T) {
+	notestoretest.Run(t, New(memory.New(), NewLRU(DefaultSize), DefaultTTL))
+}
+
+func TestConformanceRedis(t *testing.T) {
+	_, client := testRedis(t)
+	notestoretest.Run(t, New(memory.New(), NewRedis(client), DefaultTTL))
+}
+
+func TestMetrics(t *testing.T) {
+	store := New(memory.New(), NewLRU(DefaultSize), DefaultTTL)
+	userID := "Metrics_user_1"
+	noteID := uuid.New().String()
+
+	assert := assert.New(t)
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Plan", UserID: userID})
+	assert.Nil(err)
+
+	for i := 0; i < 3; i++ {
+		_, err = store.Read(context.Background(), noteID, userID)
+		assert.Nil(err)
+	}
+	assert.Equal(int64(1), store.misses.Value())
+	assert.Equal(int64(2), store.hits.Value())
+
+	// A change is read from the store again
+	err = store.Update(context.Background(), notestore.Note{ID: noteID, Title: "Plan B", UserID: userID})
+	assert.Nil(err)
+	note, err := store.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.Equal("Plan B", note.Title)
+	assert.Equal(int64(2), store.misses.Value())
+	assert.Equal(int64(0), store.errors.Value())
+	assert.Equal(`{"errors": 0, "hits": 2, "misses": 2}`, store.Metrics().String())
+}
+
+func TestBackendDown(t *testing.T) {
+	server, client := testRedis(t)
+	store := New(memory.New(), NewRedis(client), DefaultTTL)
+	userID := "Down_user_1"
+	noteID := uuid.New().String()
+
+	// The notes are read from the store while the backend is down
+	server.Close()
+	assert := assert.New(t)
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Plan", UserID: userID})
+	assert.Nil(err)
+	note, err := store.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.Equal("Plan", note.Title)
+	assert.NotZero(store.errors.Value())
+}
+
+func TestTTL(t *testing.T) {
+	backing := memory.New()
+	store := New(backing, NewLRU(DefaultSize), 50*time.Millisecond)
+	userID := "TTL_user_1"
+	noteID := uuid.New().String()
+
+	assert := assert.New(t)
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Plan", UserID: userID})
+	assert.Nil(err)
+	_, err = store.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+
+	// Changes made behind the cache are seen once the entries expire
+	err = backing.Update(context.Background(), notestore.Note{ID: noteID, Title: "Plan B", UserID: userID})
+	assert.Nil(err)
+	note, err := store.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.Equal("Plan", note.Title)
+	time.Sleep(60 * time.Millisecond)
+	note, err = store.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.Equal("Plan B", note.Title)
+}
@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+// The features of the notes are not cached, their changes invalidate the notes of
+// the user as they change the versions, tags and projects of the notes.
+
+//AddTags attaches the tags to a note
+func (s *Store) AddTags(ctx context.Context, noteID, userID string, tags []string) error {
+	return s.invalidated(ctx, userID, s.store.AddTags(ctx, noteID, userID, tags))
+}
+
+//RemoveTag detaches a tag from a note
+func (s *Store) RemoveTag(ctx context.Context, noteID, userID, tag string) error {
+	return s.invalidated(ctx, userID, s.store.RemoveTag(ctx, noteID, userID, tag))
+}
+
+//ListTags lists the tags of the user
+func (s *Store) ListTags(ctx context.Context, userID string) ([]notestore.Tag, error) {
+	return s.store.ListTags(ctx, userID)
+}
+
+//RenameTag renames a tag of the user
+func (s *Store) RenameTag(ctx context.Context, userID, name, newName string) error {
+	return s.invalidated(ctx, userID, s.store.RenameTag(ctx, userID, name, newName))
+}
+
+//MergeTags moves the notes of the source tags to the target tag
+func (s *Store) MergeTags(ctx context.Context, userID string, sources []string, target string) error {
+	return s.invalidated(ctx, userID, s.store.MergeTags(ctx, userID, sources, target))
+}
+
+//ListItems lists the checklist of a note
+func (s *Store) ListItems(ctx context.Context, noteID, userID string) ([]notestore.Item, error) {
+	return s.store.ListItems(ctx, noteID, userID)
+}
+
+//CreateItem appends an item to the checklist of a note
+func (s *Store) CreateItem(ctx context.Context, userID string, item notestore.Item) error {
+	return s.invalidated(ctx, userID, s.store.CreateItem(ctx, userID, item))
+}
+
+//UpdateItem updates an item of a checklist
+func (s *Store) UpdateItem(ctx context.Context, userID string, item notestore.Item) error {
+	return s.invalidated(ctx, userID, s.store.UpdateItem(ctx, userID, item))
+}
+
+//DeleteItem deletes an item of a checklist
+func (s *Store) DeleteItem(ctx context.Context, noteID, itemID, userID string) error {
+	return s.invalidated(ctx, userID, s.store.DeleteItem(ctx, noteID, itemID, userID))
+}
+
+//ReorderItems sets the order of the items of a checklist
+func (s *Store) ReorderItems(ctx context.Context, noteID, userID string, itemIDs []string) error {
+	return s.invalidated(ctx, userID, s.store.ReorderItems(ctx, noteID, userID, itemIDs))
+}
+
+//CreateProject creates a project
+func (s *Store) CreateProject(ctx context.Context, project notestore.Project) error {
+	return s.store.CreateProject(ctx, project)
+}
+
+//ReadProject reads a project
+func (s *Store) ReadProject(ctx context.Context, projectID, userID string) (notestore.Project, error) {
+	return s.store.ReadProject(ctx, projectID, userID)
+}
+
+//ListProjects lists the projects of the user
+func (s *Store) ListProjects(ctx context.Context, userID string, includeArchived bool) ([]notestore.Project, error) {
+	return s.store.ListProjects(ctx, userID, includeArchived)
+}
+
+//RenameProject renames a project
+func (s *Store) RenameProject(ctx context.Context, projectID, userID, name string) error {
+	return s.store.RenameProject(ctx, projectID, userID, name)
+}
+
+//ArchiveProject archives or unarchives a project, which hides or shows its notes
+func (s *Store) ArchiveProject(ctx context.Context, projectID, userID string, archived bool) error {
+	return s.invalidated(ctx, userID, s.store.ArchiveProject(ctx, projectID, userID, archived))
+}
+
+//DeleteProject deletes a project and moves its notes to the inbox
+func (s *Store) DeleteProject(ctx context.Context, projectID, userID string) error {
+	return s.invalidated(ctx, userID, s.store.DeleteProject(ctx, projectID, userID))
+}
+
+//MoveNote moves a note to a project
+func (s *Store) MoveNote(ctx context.Context, noteID, userID, projectID string) error {
+	return s.invalidated(ctx, userID, s.store.MoveNote(ctx, noteID, userID, projectID))
+}
+
+//ListTrash lists the deleted notes of the user
+func (s *Store) ListTrash(ctx context.Context, userID string) ([]notestore.Note, error) {
+	return s.store.ListTrash(ctx, userID)
+}
+
+//Restore moves a note out of the trash
+func (s *Store) Restore(ctx context.Context, noteID, userID string) error {
+	return s.invalidated(ctx, userID, s.store.Restore(ctx, noteID, userID))
+}
+
+//Purge permanently deletes a note from the trash
+func (s *Store) Purge(ctx context.Context, noteID, userID string) error {
+	return s.store.Purge(ctx, noteID, userID)
+}
+
+//PurgeExpired permanently deletes the expired notes of the trash, which are not cached
+func (s *Store) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	return s.store.PurgeExpired(ctx, before)
+}
+
+//ListRevisions lists the revisions of a note
+func (s *Store) ListRevisions(ctx context.Context, noteID, userID string) ([]notestore.Revision, error) {
+	return s.store.ListRevisions(ctx, noteID, userID)
+}
+
+//ReadRevision reads a revision of a note
+func (s *Store) ReadRevision(ctx context.Context, noteID, userID string, number int) (notestore.Revision, error) {
+	return s.store.ReadRevision(ctx, noteID, userID, number)
+}
+
+//RestoreRevision sets the title and body of a note back to a revision
+func (s *Store) RestoreRevision(ctx context.Context, noteID, userID string, number int) error {
+	return s.invalidated(ctx, userID, s.store.RestoreRevision(ctx, noteID, userID, number))
+}
+
+//ReserveIdempotencyKey reserves the key of the user for the request
+func (s *Store) ReserveIdempotencyKey(ctx context.Context, userID, key, requestHash string, expiredBefore, abandonedBefore time.Time) (notestore.IdempotentResponse, bool, error) {
+	return s.store.ReserveIdempotencyKey(ctx, userID, key, requestHash, expiredBefore, abandonedBefore)
+}
+
+//SaveIdempotentResponse stores the response to the request that reserved the key
+func (s *Store) SaveIdempotentResponse(ctx context.Context, resp notestore.IdempotentResponse) error {
+	return s.store.SaveIdempotentResponse(ctx, resp)
+}
+
+//ReleaseIdempotencyKey frees the key of a failed request
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	return s.store.ReleaseIdempotencyKey(ctx, userID, key)
+}
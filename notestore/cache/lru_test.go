@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUEviction(t *testing.T) {
+	lru := NewLRU(2)
+	ctx := context.Background()
+
+	assert := assert.New(t)
+	assert.Nil(lru.Set(ctx, "a", []byte("1"), 0))
+	assert.Nil(lru.Set(ctx, "b", []byte("2"), 0))
+	// Reading a makes b the least recently used entry
+	_, ok, _ := lru.Get(ctx, "a")
+	assert.True(ok)
+	assert.Nil(lru.Set(ctx, "c", []byte("3"), 0))
+
+	assert.Equal(2, lru.Len())
+	_, ok, _ = lru.Get(ctx, "b")
+	assert.False(ok)
+	value, ok, _ := lru.Get(ctx, "a")
+	assert.True(ok)
+	assert.Equal("1", string(value))
+
+	// Setting a key again replaces its value
+	assert.Nil(lru.Set(ctx, "c", []byte("4"), 0))
+	value, _, _ = lru.Get(ctx, "c")
+	assert.Equal("4", string(value))
+	assert.Equal(2, lru.Len())
+}
+
+func TestLRUExpiry(t *testing.T) {
+	lru := NewLRU(2)
+	ctx := context.Background()
+
+	assert := assert.New(t)
+	assert.Nil(lru.Set(ctx, "a", []byte("1"), 10*time.Millisecond))
+	_, ok, _ := lru.Get(ctx, "a")
+	assert.True(ok)
+	time.Sleep(20 * time.Millisecond)
+	_, ok, _ = lru.Get(ctx, "a")
+	assert.False(ok)
+	assert.Equal(0, lru.Len())
+}
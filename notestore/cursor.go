@@ -0,0 +1,154 @@
+package notestore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Cursor is a position in a sorted list of notes. It is handed to clients as an
+// opaque base64 string, see Encode and DecodeCursor.
+type Cursor struct {
+	SortBy SortField `json:"s"`
+	Order  SortOrder `json:"o"`
+	// Key is the value of the sort field of the note at the position
+	Key string `json:"k"`
+	// NoteID breaks ties between notes with the same Key
+	NoteID string `json:"i"`
+	// Backward is set for cursors that page towards the start of the list
+	Backward bool `json:"b,omitempty"`
+}
+
+// Encode returns the opaque string form of the cursor
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a cursor returned by Encode
+func DecodeCursor(s string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, Invalidf("invalid cursor: %s", err)
+	}
+
+	var c Cursor
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return Cursor{}, Invalidf("invalid cursor: %s", err)
+	}
+	if !c.SortBy.valid() || !c.Order.valid() || c.NoteID == "" {
+		return Cursor{}, Invalidf("invalid cursor")
+	}
+	return c, nil
+}
+
+// SortKey returns the value of the sort field for the note
+func SortKey(note Note, field SortField) string {
+	switch field {
+	case SortByUpdated:
+		return note.UpdatedAt
+	case SortByTitle:
+		return note.Title
+	default:
+		return note.CreatedAt
+	}
+}
+
+func (f SortField) valid() bool {
+	return f == SortByCreated || f == SortByUpdated || f == SortByTitle
+}
+
+func (o SortOrder) valid() bool {
+	return o == Ascending || o == Descending
+}
+
+// Normalize validates the options, applies the defaults and decodes the cursor.
+// The returned cursor is nil when the first page is requested.
+func (opts ReadAllOptions) Normalize() (ReadAllOptions, *Cursor, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultPageSize
+	}
+	if opts.Limit > MaxPageSize {
+		opts.Limit = MaxPageSize
+	}
+	if opts.SortBy == "" {
+		opts.SortBy = SortByCreated
+	}
+	if opts.Order == "" {
+		opts.Order = Ascending
+	}
+	if !opts.SortBy.valid() {
+		return opts, nil, Invalidf("invalid sort field '%s'", opts.SortBy)
+	}
+	if !opts.Order.valid() {
+		return opts, nil, Invalidf("invalid sort order '%s'", opts.Order)
+	}
+	for _, status := range opts.Statuses {
+		if !status.Valid() {
+			return opts, nil, Invalidf("invalid status '%s'", status)
+		}
+	}
+	err := opts.applyDueFilter(time.Now())
+	if err != nil {
+		return opts, nil, err
+	}
+	if len(opts.Tags) > 0 {
+		tags, err := NormalizeTags(opts.Tags)
+		if err != nil {
+			return opts, nil, err
+		}
+		opts.Tags = tags
+	}
+
+	if opts.Cursor == "" {
+		return opts, nil, nil
+	}
+	cursor, err := DecodeCursor(opts.Cursor)
+	if err != nil {
+		return opts, nil, err
+	}
+	opts.SortBy = cursor.SortBy
+	opts.Order = cursor.Order
+	return opts, &cursor, nil
+}
+
+// NewPage builds a Page out of the notes a backend fetched for normalized options.
+// Backends fetch up to opts.Limit+1 notes in the direction of the cursor, so that
+// the existence of a further page can be detected.
+func NewPage(notes []Note, opts ReadAllOptions, cursor *Cursor) Page {
+	backward := cursor != nil && cursor.Backward
+	more := len(notes) > opts.Limit
+	if more {
+		notes = notes[:opts.Limit]
+	}
+	if backward {
+		for i, j := 0, len(notes)-1; i < j; i, j = i+1, j-1 {
+			notes[i], notes[j] = notes[j], notes[i]
+		}
+	}
+
+	page := Page{Notes: notes}
+	if len(notes) == 0 {
+		page.Notes = []Note{}
+		return page
+	}
+
+	at := func(note Note, backward bool) string {
+		return Cursor{
+			SortBy:   opts.SortBy,
+			Order:    opts.Order,
+			Key:      SortKey(note, opts.SortBy),
+			NoteID:   note.ID,
+			Backward: backward,
+		}.Encode()
+	}
+	// Paging backward always leaves the page we came from after this one
+	if more || backward {
+		page.NextCursor = at(notes[len(notes)-1], false)
+	}
+	if (cursor != nil && !backward) || (backward && more) {
+		page.PrevCursor = at(notes[0], true)
+	}
+	return page
+}
@@ -0,0 +1,120 @@
+package notestore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorEncoding(t *testing.T) {
+	testCases := []struct {
+		description string
+		cursor      Cursor
+	}{
+		{
+			description: "Forward cursor",
+			cursor:      Cursor{SortBy: SortByCreated, Order: Ascending, Key: "2021-10-20T10:00:00.123456Z", NoteID: "note_1"},
+		},
+		{
+			description: "Backward cursor",
+			cursor:      Cursor{SortBy: SortByTitle, Order: Descending, Key: "Groceries", NoteID: "note_2", Backward: true},
+		},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		cursor, err := DecodeCursor(testCase.cursor.Encode())
+		assert.Nil(err, testCase.description)
+		assert.Equal(testCase.cursor, cursor, testCase.description)
+	}
+
+	for _, invalid := range []string{"not a cursor", Cursor{SortBy: "size", Order: Ascending, NoteID: "note_1"}.Encode()} {
+		_, err := DecodeCursor(invalid)
+		assert.NotNil(err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		description   string
+		opts          ReadAllOptions
+		expectedOpts  ReadAllOptions
+		expectedError bool
+	}{
+		{
+			description:  "Defaults",
+			opts:         ReadAllOptions{},
+			expectedOpts: ReadAllOptions{Limit: DefaultPageSize, SortBy: SortByCreated, Order: Ascending},
+		},
+		{
+			description:  "Limit is capped",
+			opts:         ReadAllOptions{Limit: MaxPageSize + 1, SortBy: SortByTitle, Order: Descending},
+			expectedOpts: ReadAllOptions{Limit: MaxPageSize, SortBy: SortByTitle, Order: Descending},
+		},
+		{
+			description:   "Invalid sort field",
+			opts:          ReadAllOptions{SortBy: "size"},
+			expectedError: true,
+		},
+		{
+			description:   "Invalid sort order",
+			opts:          ReadAllOptions{Order: "random"},
+			expectedError: true,
+		},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		opts, cursor, err := testCase.opts.Normalize()
+		if testCase.expectedError {
+			assert.NotNil(err, testCase.description)
+			continue
+		}
+		assert.Nil(err, testCase.description)
+		assert.Nil(cursor, testCase.description)
+		assert.Equal(testCase.expectedOpts, opts, testCase.description)
+	}
+
+	// The cursor's sort takes precedence
+	cursor := Cursor{SortBy: SortByUpdated, Order: Descending, Key: "k", NoteID: "note_1"}
+	opts, decoded, err := ReadAllOptions{Cursor: cursor.Encode(), SortBy: SortByTitle}.Normalize()
+	assert.Nil(err)
+	assert.Equal(&cursor, decoded)
+	assert.Equal(SortByUpdated, opts.SortBy)
+	assert.Equal(Descending, opts.Order)
+}
+
+func TestNewPage(t *testing.T) {
+	notes := []Note{{ID: "1", Title: "a"}, {ID: "2", Title: "b"}, {ID: "3", Title: "c"}}
+	opts := ReadAllOptions{Limit: 2, SortBy: SortByTitle, Order: Ascending}
+
+	assert := assert.New(t)
+
+	// First page with more notes after it
+	page := NewPage(append([]Note{}, notes...), opts, nil)
+	assert.Equal(notes[:2], page.Notes)
+	assert.Empty(page.PrevCursor)
+	next, err := DecodeCursor(page.NextCursor)
+	assert.Nil(err)
+	assert.Equal(Cursor{SortBy: SortByTitle, Order: Ascending, Key: "b", NoteID: "2"}, next)
+
+	// Last page reached with a forward cursor
+	page = NewPage([]Note{notes[2]}, opts, &next)
+	assert.Equal([]Note{notes[2]}, page.Notes)
+	assert.Empty(page.NextCursor)
+	prev, err := DecodeCursor(page.PrevCursor)
+	assert.Nil(err)
+	assert.Equal(Cursor{SortBy: SortByTitle, Order: Ascending, Key: "c", NoteID: "3", Backward: true}, prev)
+
+	// Backward pages are fetched in reverse order
+	page = NewPage([]Note{notes[1], notes[0]}, opts, &prev)
+	assert.Equal(notes[:2], page.Notes)
+	assert.Empty(page.PrevCursor)
+	assert.NotEmpty(page.NextCursor)
+
+	// Empty pages have no cursors
+	page = NewPage(nil, opts, nil)
+	assert.Equal([]Note{}, page.Notes)
+	assert.Empty(page.NextCursor)
+	assert.Empty(page.PrevCursor)
+}
@@ -0,0 +1,51 @@
+package notestore
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of the errors returned by the stores. Errors of a kind wrap it and can be
+// checked with errors.Is, any other error is an internal error of the store.
+var (
+	// ErrNotFound is returned when a note, or anything else the request refers to, does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a request conflicts with the current state, e.g. an existing name
+	ErrConflict = errors.New("conflict")
+	// ErrValidation is returned when a request is invalid
+	ErrValidation = errors.New("validation failed")
+)
+
+//ErrVersionMismatch is returned when the expected version of a note is not its current version
+var ErrVersionMismatch = errors.New("note version does not match")
+
+// Error is an error of one of the kinds ErrNotFound, ErrConflict or ErrValidation.
+// Its message is meant for the users, unlike the messages of internal errors.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the kind of the error
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// NotFoundf returns an ErrNotFound error with a formatted message
+func NotFoundf(format string, args ...interface{}) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// Conflictf returns an ErrConflict error with a formatted message
+func Conflictf(format string, args ...interface{}) error {
+	return &Error{Kind: ErrConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+// Invalidf returns an ErrValidation error with a formatted message
+func Invalidf(format string, args ...interface{}) error {
+	return &Error{Kind: ErrValidation, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,25 @@
+package notestore
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKinds(t *testing.T) {
+	assert := assert.New(t)
+
+	err := fmt.Errorf("unable to read note: %w", NotFoundf("Note '%s' is not found", "1"))
+	assert.ErrorIs(err, ErrNotFound)
+	assert.False(errors.Is(err, ErrConflict))
+	assert.EqualError(errors.Unwrap(err), "Note '1' is not found")
+
+	assert.ErrorIs(Conflictf("Tag '%s' already exists", "work"), ErrConflict)
+	assert.ErrorIs(Invalidf("tag name is empty"), ErrValidation)
+
+	// Validation errors of the notes are typed
+	_, err = NormalizeTodo(Note{Status: "later"})
+	assert.ErrorIs(err, ErrValidation)
+}
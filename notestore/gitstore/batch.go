@@ -0,0 +1,30 @@
+package gitstore
+
+import (
+	"context"
+	"fmt"
+
+	"local/sidharthjs/todo/notestore"
+	"local/sidharthjs/todo/notestore/memory"
+)
+
+//Batch runs the operations of a batch on the index and commits the changed files
+//at once, the operations which failed leave no trace in the commit
+func (s *Store) Batch(ctx context.Context, userID string, ops []notestore.BatchOperation, atomic bool) ([]notestore.BatchResult, error) {
+	for _, op := range ops {
+		if op.Action == notestore.BatchCreate && !validName(op.Note.ID) {
+			return nil, notestore.Invalidf("Note ID '%s' cannot be used as a file name", op.Note.ID)
+		}
+	}
+
+	var results []notestore.BatchResult
+	err := s.change(ctx, userID, fmt.Sprintf("Run a batch of %d operations", len(ops)), func(index *memory.Store) error {
+		var err error
+		results, err = index.Batch(ctx, userID, ops, atomic)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
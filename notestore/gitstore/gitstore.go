@@ -0,0 +1,335 @@
+// Package gitstore is a note store keeping the notes as Markdown files in a git
+// repository, so that they can be read, grepped and reviewed outside of the app.
+// Every note is a file <user ID>/<note ID>.md holding the fields of the note as YAML
+// front matter followed by its body. Every change is committed with the user as
+// author, and the revisions of a note are read from the git log of its file.
+//
+// The notes are indexed in memory when the store is opened, the reads, filters and
+// searches are served by the index. The store expects to be the only writer of the
+// repository while it is open. Checklists and projects are not supported.
+package gitstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"local/sidharthjs/todo/notestore"
+	"local/sidharthjs/todo/notestore/memory"
+)
+
+// Identity of the committer of the changes, the author is the user of the notes
+const (
+	committerName  = "todo"
+	committerEmail = "todo@localhost"
+)
+
+//Store is a thread-safe note store in a git repository, see New
+type Store struct {
+	dir string
+
+	// mu serializes the changes and guards the swap of the index on a rollback
+	mu    sync.RWMutex
+	index *memory.Store
+	// keys are the idempotency keys, they are not notes and are only kept in memory
+	keys *memory.Store
+}
+
+// New opens the git repository in dir, creating it when it does not exist, and
+// indexes the notes of its working tree
+func New(dir string) (*Store, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while resolving the repository path: %s", err)
+	}
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while creating the repository directory: %s", err)
+	}
+
+	s := &Store{dir: dir, keys: memory.New()}
+	ctx := context.Background()
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		_, err := s.git(ctx, "init", "--quiet")
+		if err != nil {
+			return nil, err
+		}
+	}
+	// The repository needs a first commit for the rollback of failed changes
+	if _, err := s.git(ctx, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		_, err := s.git(ctx, "commit", "--quiet", "--allow-empty", "--message", "Create the note repository")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.index, err = s.load()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// git runs a git command in the repository and returns its output
+func (s *Store) git(ctx context.Context, args ...string) ([]byte, error) {
+	config := []string{"-c", "user.name=" + committerName, "-c", "user.email=" + committerEmail, "-c", "commit.gpgsign=false"}
+	cmd := exec.CommandContext(ctx, "git", append(config, args...)...)
+	cmd.Dir = s.dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return out, fmt.Errorf("error occurred while running git %s: %s: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// validName reports whether a user or note ID can be used as a file name
+func validName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, `/\`+"\x00")
+}
+
+// notePath returns the path of the file of a note relative to the repository
+func notePath(userID, noteID string) string {
+	return userID + "/" + noteID + ".md"
+}
+
+// load indexes the notes of the working tree, the directories of the repository are
+// the users and their Markdown files are the notes
+func (s *Store) load() (*memory.Store, error) {
+	index := memory.New()
+	users, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while reading the repository: %s", err)
+	}
+	for _, user := range users {
+		if !user.IsDir() || !validName(user.Name()) {
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join(s.dir, user.Name(), "*.md"))
+		if err != nil {
+			return nil, fmt.Errorf("error occurred while listing the notes of user '%s': %s", user.Name(), err)
+		}
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("error occurred while reading %s: %s", file, err)
+			}
+			note, err := parseNote(data, user.Name())
+			if err != nil {
+				return nil, fmt.Errorf("error occurred while parsing %s: %s", file, err)
+			}
+			index.Load(note)
+		}
+	}
+	return index, nil
+}
+
+// reader returns the index for a read
+func (s *Store) reader() *memory.Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.index
+}
+
+// change runs the change of the notes of the user on the index, then writes the notes
+// of the user to their files and commits them with the message. A failed commit
+// resets the working tree and the index to the last commit.
+func (s *Store) change(ctx context.Context, userID, message string, change func(index *memory.Store) error) error {
+	if !validName(userID) {
+		return notestore.Invalidf("User ID '%s' cannot be used as a directory name", userID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := change(s.index)
+	if err != nil {
+		return err
+	}
+
+	err = s.commit(ctx, userID, message)
+	if err != nil {
+		return s.rollback(userID, err)
+	}
+	return nil
+}
+
+// commit writes the notes of the user in the index to their files, removes the files
+// of the purged notes and commits the changed files with the user as author
+func (s *Store) commit(ctx context.Context, userID, message string) error {
+	notes, err := s.userNotes(ctx, userID)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(s.dir, userID)
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return fmt.Errorf("error occurred while creating the directory of user '%s': %s", userID, err)
+	}
+
+	files := map[string]bool{}
+	for _, note := range notes {
+		if !validName(note.ID) {
+			return notestore.Invalidf("Note ID '%s' cannot be used as a file name", note.ID)
+		}
+		data, err := marshalNote(note)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(s.dir, notePath(userID, note.ID))
+		files[file] = true
+		// Unchanged files are not rewritten
+		if current, err := ioutil.ReadFile(file); err == nil && bytes.Equal(current, data) {
+			continue
+		}
+		err = ioutil.WriteFile(file, data, 0o644)
+		if err != nil {
+			return fmt.Errorf("error occurred while writing note '%s': %s", note.ID, err)
+		}
+	}
+
+	existing, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return fmt.Errorf("error occurred while listing the notes of user '%s': %s", userID, err)
+	}
+	for _, file := range existing {
+		if !files[file] {
+			err := os.Remove(file)
+			if err != nil {
+				return fmt.Errorf("error occurred while removing %s: %s", file, err)
+			}
+		}
+	}
+
+	_, err = s.git(ctx, "add", "--all", "--", userID)
+	if err != nil {
+		return err
+	}
+	// A change leaving the files as they were, such as a failed batch, is not committed.
+	// diff fails when the files differ from the last commit.
+	_, err = s.git(ctx, "diff", "--cached", "--quiet", "--", userID)
+	if err == nil {
+		return nil
+	}
+	_, err = s.git(ctx, "commit", "--quiet", "--author", author(userID), "--message", message, "--", userID)
+	return err
+}
+
+// author returns the git author of the changes of the user
+func author(userID string) string {
+	return fmt.Sprintf("%s <%s@%s>", userID, userID, committerName)
+}
+
+// rollback resets the files of the user and the index to the last commit after the
+// commit of a change failed with err, and returns err
+func (s *Store) rollback(userID string, err error) error {
+	ctx := context.Background()
+	if _, resetErr := s.git(ctx, "reset", "--quiet", "--hard", "HEAD"); resetErr != nil {
+		return fmt.Errorf("%s, rolling back failed: %s", err, resetErr)
+	}
+	if _, cleanErr := s.git(ctx, "clean", "--quiet", "--force", "-d", "--", userID); cleanErr != nil {
+		return fmt.Errorf("%s, rolling back failed: %s", err, cleanErr)
+	}
+	index, loadErr := s.load()
+	if loadErr != nil {
+		return fmt.Errorf("%s, rolling back failed: %s", err, loadErr)
+	}
+	s.index = index
+	return err
+}
+
+// userNotes returns every note of the user in the index, including the trash
+func (s *Store) userNotes(ctx context.Context, userID string) ([]notestore.Note, error) {
+	notes, err := s.index.ListTrash(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	opts := notestore.ReadAllOptions{Limit: notestore.MaxPageSize}
+	for {
+		page, err := s.index.ReadAll(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, page.Notes...)
+		if page.NextCursor == "" {
+			return notes, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+//Create creates a note and commits its file
+func (s *Store) Create(ctx context.Context, note notestore.Note) error {
+	if !validName(note.ID) {
+		return notestore.Invalidf("Note ID '%s' cannot be used as a file name", note.ID)
+	}
+	return s.change(ctx, note.UserID, "Create note "+note.ID, func(index *memory.Store) error {
+		return index.Create(ctx, note)
+	})
+}
+
+//Read reads a note
+func (s *Store) Read(ctx context.Context, noteID, userID string) (notestore.Note, error) {
+	return s.reader().Read(ctx, noteID, userID)
+}
+
+//ReadAll reads a page of notes for the given user ID from the index, filtered and
+//sorted as by the postgres store
+func (s *Store) ReadAll(ctx context.Context, userID string, opts notestore.ReadAllOptions) (notestore.Page, error) {
+	return s.reader().ReadAll(ctx, userID, opts)
+}
+
+//Search searches the title and body of the notes of the given user ID in the index,
+//most relevant first
+func (s *Store) Search(ctx context.Context, userID, query string, limit int) ([]notestore.SearchResult, error) {
+	return s.reader().Search(ctx, userID, query, limit)
+}
+
+//Update updates a note and commits its file
+func (s *Store) Update(ctx context.Context, note notestore.Note) error {
+	return s.change(ctx, note.UserID, "Update note "+note.ID, func(index *memory.Store) error {
+		return index.Update(ctx, note)
+	})
+}
+
+//UpdateFields updates the listed fields of the note and commits its file
+func (s *Store) UpdateFields(ctx context.Context, note notestore.Note, fields []notestore.NoteField) error {
+	return s.change(ctx, note.UserID, "Update note "+note.ID, func(index *memory.Store) error {
+		return index.UpdateFields(ctx, note, fields)
+	})
+}
+
+//Complete sets the status of a note to done and commits its file, along with the
+//file of the next occurrence of a recurring note
+func (s *Store) Complete(ctx context.Context, noteID, userID string) (notestore.Note, error) {
+	var next notestore.Note
+	err := s.change(ctx, userID, "Complete note "+noteID, func(index *memory.Store) error {
+		var err error
+		next, err = index.Complete(ctx, noteID, userID)
+		return err
+	})
+	return next, err
+}
+
+//Delete moves a note to the trash, its file is kept with the time of the deletion
+//until the note is purged
+func (s *Store) Delete(ctx context.Context, noteID, userID string, version int) error {
+	return s.change(ctx, userID, "Delete note "+noteID, func(index *memory.Store) error {
+		return index.Delete(ctx, noteID, userID, version)
+	})
+}
+
+// The store implements the interfaces of the features kept in the note files
+var (
+	_ notestore.NoteStore        = (*Store)(nil)
+	_ notestore.TagStore         = (*Store)(nil)
+	_ notestore.TrashStore       = (*Store)(nil)
+	_ notestore.RevisionStore    = (*Store)(nil)
+	_ notestore.IdempotencyStore = (*Store)(nil)
+)
@@ -0,0 +1,98 @@
+package gitstore
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"local/sidharthjs/todo/notestore"
+	"local/sidharthjs/todo/notestore/notestoretest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// testStore returns a store in a new repository in a temporary directory
+func testStore(t *testing.T) *Store {
+	store, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("error creating the store: %s", err)
+	}
+	return store
+}
+
+func TestConformance(t *testing.T) {
+	notestoretest.Run(t, testStore(t))
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	store, err := New(dir)
+	if err != nil {
+		t.Fatalf("error creating the store: %s", err)
+	}
+	userID := "Reopen_user_1"
+	noteID := uuid.New().String()
+	deletedID := uuid.New().String()
+
+	assert := assert.New(t)
+	note := notestore.Note{ID: noteID, Title: "Groceries: milk", Body: "---\n- [ ] Milk\n", UserID: userID,
+		Tags: []string{"home"}, Priority: 2, Recurrence: "FREQ=WEEKLY", DueDate: "2030-01-07T09:00:00Z"}
+	err = store.Create(context.Background(), note)
+	assert.Nil(err)
+	err = store.Create(context.Background(), notestore.Note{ID: deletedID, Title: "Old", UserID: userID})
+	assert.Nil(err)
+	err = store.Delete(context.Background(), deletedID, userID, 0)
+	assert.Nil(err)
+
+	// The notes are read back from their files
+	reopened, err := New(dir)
+	assert.Nil(err)
+	want, err := store.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+	got, err := reopened.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.Equal(want, got)
+	assert.Equal("---\n- [ ] Milk\n", got.Body)
+	trash, err := reopened.ListTrash(context.Background(), userID)
+	assert.Nil(err)
+	if assert.Len(trash, 1) {
+		assert.Equal(deletedID, trash[0].ID)
+	}
+}
+
+func TestNoteFiles(t *testing.T) {
+	store := testStore(t)
+	userID := "Files_user_1"
+	noteID := uuid.New().String()
+
+	assert := assert.New(t)
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Plan", Body: "Ship it", UserID: userID})
+	assert.Nil(err)
+	data, err := ioutil.ReadFile(filepath.Join(store.dir, userID, noteID+".md"))
+	assert.Nil(err)
+	assert.Contains(string(data), "title: Plan\n")
+	assert.Contains(string(data), "---\nShip it")
+
+	// Every change is a commit authored by the user
+	err = store.Update(context.Background(), notestore.Note{ID: noteID, Title: "Plan", Body: "Ship it today", UserID: userID})
+	assert.Nil(err)
+	out, err := store.git(context.Background(), "log", "--format=%an %s", "--", notePath(userID, noteID))
+	assert.Nil(err)
+	assert.Equal(userID+" Update note "+noteID+"\n"+userID+" Create note "+noteID+"\n", string(out))
+
+	// Purging removes the file
+	err = store.Delete(context.Background(), noteID, userID, 0)
+	assert.Nil(err)
+	err = store.Purge(context.Background(), noteID, userID)
+	assert.Nil(err)
+	_, err = ioutil.ReadFile(filepath.Join(store.dir, userID, noteID+".md"))
+	assert.Error(err)
+
+	// IDs which are not file names are rejected
+	err = store.Create(context.Background(), notestore.Note{ID: "../escape", Title: "Plan", UserID: userID})
+	assert.ErrorIs(err, notestore.ErrValidation)
+	err = store.Create(context.Background(), notestore.Note{ID: uuid.New().String(), Title: "Plan", UserID: "../escape"})
+	assert.ErrorIs(err, notestore.ErrValidation)
+}
@@ -0,0 +1,26 @@
+package gitstore
+
+import (
+	"context"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+// The idempotency keys only guard the retries of requests for a short window, they
+// are kept in memory rather than in the repository.
+
+//ReserveIdempotencyKey reserves the key of the user for the request
+func (s *Store) ReserveIdempotencyKey(ctx context.Context, userID, key, requestHash string, expiredBefore, abandonedBefore time.Time) (notestore.IdempotentResponse, bool, error) {
+	return s.keys.ReserveIdempotencyKey(ctx, userID, key, requestHash, expiredBefore, abandonedBefore)
+}
+
+//SaveIdempotentResponse stores the response to the request that reserved the key
+func (s *Store) SaveIdempotentResponse(ctx context.Context, resp notestore.IdempotentResponse) error {
+	return s.keys.SaveIdempotentResponse(ctx, resp)
+}
+
+//ReleaseIdempotencyKey frees the key of a failed request
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	return s.keys.ReleaseIdempotencyKey(ctx, userID, key)
+}
@@ -0,0 +1,102 @@
+package gitstore
+
+import (
+	"bytes"
+	"fmt"
+
+	"local/sidharthjs/todo/notestore"
+
+	"gopkg.in/yaml.v3"
+)
+
+// delimiter opens and closes the front matter of a note file
+const delimiter = "---\n"
+
+// frontMatter is the YAML front matter of a note file, the fields of the note
+// other than its body. The user of the note is the directory of the file.
+type frontMatter struct {
+	ID          string           `yaml:"id"`
+	Title       string           `yaml:"title"`
+	Status      notestore.Status `yaml:"status"`
+	Priority    int              `yaml:"priority,omitempty"`
+	DueDate     string           `yaml:"due_date,omitempty"`
+	CompletedAt string           `yaml:"completed_at,omitempty"`
+	Recurrence  string           `yaml:"recurrence,omitempty"`
+	Occurrence  int              `yaml:"occurrence,omitempty"`
+	Tags        []string         `yaml:"tags,omitempty"`
+	CreatedAt   string           `yaml:"created_at"`
+	UpdatedAt   string           `yaml:"updated_at"`
+	DeletedAt   string           `yaml:"deleted_at,omitempty"`
+	Version     int              `yaml:"version"`
+}
+
+// marshalNote returns the content of the file of a note, its front matter
+// followed by its body as is
+func marshalNote(note notestore.Note) ([]byte, error) {
+	meta, err := yaml.Marshal(frontMatter{
+		ID:          note.ID,
+		Title:       note.Title,
+		Status:      note.Status,
+		Priority:    note.Priority,
+		DueDate:     note.DueDate,
+		CompletedAt: note.CompletedAt,
+		Recurrence:  note.Recurrence,
+		Occurrence:  note.Occurrence,
+		Tags:        note.Tags,
+		CreatedAt:   note.CreatedAt,
+		UpdatedAt:   note.UpdatedAt,
+		DeletedAt:   note.DeletedAt,
+		Version:     note.Version,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while encoding the front matter of note '%s': %s", note.ID, err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(delimiter)
+	buf.Write(meta)
+	buf.WriteString(delimiter)
+	buf.WriteString(note.Body)
+	return buf.Bytes(), nil
+}
+
+// parseNote parses the content of the file of a note of the user
+func parseNote(data []byte, userID string) (notestore.Note, error) {
+	if !bytes.HasPrefix(data, []byte(delimiter)) {
+		return notestore.Note{}, fmt.Errorf("the file does not start with a front matter")
+	}
+	data = data[len(delimiter):]
+
+	// The front matter is closed by the first delimiter line, an empty front matter
+	// is never written
+	end := bytes.Index(data, []byte("\n"+delimiter))
+	if end < 0 {
+		return notestore.Note{}, fmt.Errorf("the front matter of the file is not closed")
+	}
+	var meta frontMatter
+	err := yaml.Unmarshal(data[:end+1], &meta)
+	if err != nil {
+		return notestore.Note{}, fmt.Errorf("error occurred while decoding the front matter: %s", err)
+	}
+	if meta.ID == "" {
+		return notestore.Note{}, fmt.Errorf("the front matter has no id")
+	}
+
+	return notestore.Note{
+		ID:          meta.ID,
+		Title:       meta.Title,
+		Body:        string(data[end+1+len(delimiter):]),
+		UserID:      userID,
+		CreatedAt:   meta.CreatedAt,
+		UpdatedAt:   meta.UpdatedAt,
+		Tags:        append([]string{}, meta.Tags...),
+		Status:      meta.Status,
+		Priority:    meta.Priority,
+		DueDate:     meta.DueDate,
+		CompletedAt: meta.CompletedAt,
+		Recurrence:  meta.Recurrence,
+		Occurrence:  meta.Occurrence,
+		DeletedAt:   meta.DeletedAt,
+		Version:     meta.Version,
+	}, nil
+}
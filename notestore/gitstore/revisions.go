@@ -0,0 +1,111 @@
+package gitstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+	"local/sidharthjs/todo/notestore/memory"
+)
+
+// revisions returns the revisions of a note, oldest first, from the git log of its
+// file. Every commit changing the title or body of the note replaced a revision,
+// whose author is the user who updated the note.
+func (s *Store) revisions(ctx context.Context, index *memory.Store, noteID, userID string) ([]notestore.Revision, error) {
+	// The note is read first so that the history of the notes of other users,
+	// deleted or purged notes is never read
+	note, err := index.Read(ctx, noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	path := notePath(userID, note.ID)
+	out, err := s.git(ctx, "log", "--reverse", "--diff-filter=AM", "--format=%H%x00%an%x00%aI", "--", path)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []notestore.Revision{}
+	var current notestore.Note
+	for i, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("error occurred while reading the history of note '%s': unexpected log line %q", noteID, line)
+		}
+		data, err := s.git(ctx, "show", fields[0]+":"+path)
+		if err != nil {
+			return nil, err
+		}
+		content, err := parseNote(data, userID)
+		if err != nil {
+			return nil, fmt.Errorf("error occurred while parsing note '%s' at %s: %s", noteID, fields[0], err)
+		}
+
+		if i > 0 && (content.Title != current.Title || content.Body != current.Body) {
+			updatedAt, _ := time.Parse(time.RFC3339, fields[2])
+			revisions = append(revisions, notestore.Revision{
+				NoteID:    noteID,
+				Number:    len(revisions) + 1,
+				Title:     current.Title,
+				Body:      current.Body,
+				UserID:    fields[1],
+				CreatedAt: updatedAt.UTC().Format(time.RFC3339Nano),
+			})
+		}
+		current = content
+	}
+	return revisions, nil
+}
+
+// revision returns a revision of a note from the git log of its file
+func (s *Store) revision(ctx context.Context, index *memory.Store, noteID, userID string, number int) (notestore.Revision, error) {
+	revisions, err := s.revisions(ctx, index, noteID, userID)
+	if err != nil {
+		return notestore.Revision{}, err
+	}
+	if number < 1 || number > len(revisions) {
+		return notestore.Revision{}, notestore.NotFoundf("Revision %d of note '%s' is not found", number, noteID)
+	}
+	return revisions[number-1], nil
+}
+
+//ListRevisions lists the revisions of a note, newest first
+func (s *Store) ListRevisions(ctx context.Context, noteID, userID string) ([]notestore.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions, err := s.revisions(ctx, s.index, noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(revisions)-1; i < j; i, j = i+1, j-1 {
+		revisions[i], revisions[j] = revisions[j], revisions[i]
+	}
+	return revisions, nil
+}
+
+//ReadRevision reads a revision of a note
+func (s *Store) ReadRevision(ctx context.Context, noteID, userID string, number int) (notestore.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.revision(ctx, s.index, noteID, userID, number)
+}
+
+//RestoreRevision sets the title and body of a note back to a revision with a new
+//commit, so the replaced content becomes a new revision
+func (s *Store) RestoreRevision(ctx context.Context, noteID, userID string, number int) error {
+	message := fmt.Sprintf("Restore revision %d of note %s", number, noteID)
+	return s.change(ctx, userID, message, func(index *memory.Store) error {
+		revision, err := s.revision(ctx, index, noteID, userID, number)
+		if err != nil {
+			return err
+		}
+		note := notestore.Note{ID: noteID, UserID: userID, Title: revision.Title, Body: revision.Body}
+		return index.UpdateFields(ctx, note, []notestore.NoteField{notestore.FieldTitle, notestore.FieldBody})
+	})
+}
@@ -0,0 +1,47 @@
+package gitstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"local/sidharthjs/todo/notestore"
+	"local/sidharthjs/todo/notestore/memory"
+)
+
+// The tags are kept in the front matter of the notes, a change of the tags commits
+// the files of every note it touched.
+
+//AddTags attaches the tags to a note
+func (s *Store) AddTags(ctx context.Context, noteID, userID string, tags []string) error {
+	return s.change(ctx, userID, "Tag note "+noteID, func(index *memory.Store) error {
+		return index.AddTags(ctx, noteID, userID, tags)
+	})
+}
+
+//RemoveTag detaches a tag from a note
+func (s *Store) RemoveTag(ctx context.Context, noteID, userID, tag string) error {
+	return s.change(ctx, userID, "Untag note "+noteID, func(index *memory.Store) error {
+		return index.RemoveTag(ctx, noteID, userID, tag)
+	})
+}
+
+//ListTags lists the tags of the user with their note counts
+func (s *Store) ListTags(ctx context.Context, userID string) ([]notestore.Tag, error) {
+	return s.reader().ListTags(ctx, userID)
+}
+
+//RenameTag renames a tag of the user on all of its notes
+func (s *Store) RenameTag(ctx context.Context, userID, name, newName string) error {
+	return s.change(ctx, userID, fmt.Sprintf("Rename tag %s to %s", name, newName), func(index *memory.Store) error {
+		return index.RenameTag(ctx, userID, name, newName)
+	})
+}
+
+//MergeTags moves the notes of the source tags to the target tag
+func (s *Store) MergeTags(ctx context.Context, userID string, sources []string, target string) error {
+	message := fmt.Sprintf("Merge tags %s into %s", strings.Join(sources, ", "), target)
+	return s.change(ctx, userID, message, func(index *memory.Store) error {
+		return index.MergeTags(ctx, userID, sources, target)
+	})
+}
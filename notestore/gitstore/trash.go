@@ -0,0 +1,73 @@
+package gitstore
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+	"local/sidharthjs/todo/notestore/memory"
+)
+
+//ListTrash lists the deleted notes of the user, most recently deleted first
+func (s *Store) ListTrash(ctx context.Context, userID string) ([]notestore.Note, error) {
+	return s.reader().ListTrash(ctx, userID)
+}
+
+//Restore moves a note out of the trash
+func (s *Store) Restore(ctx context.Context, noteID, userID string) error {
+	return s.change(ctx, userID, "Restore note "+noteID, func(index *memory.Store) error {
+		return index.Restore(ctx, noteID, userID)
+	})
+}
+
+//Purge removes the file of a note in the trash, the note is still in the history
+//of the repository
+func (s *Store) Purge(ctx context.Context, noteID, userID string) error {
+	return s.change(ctx, userID, "Purge note "+noteID, func(index *memory.Store) error {
+		return index.Purge(ctx, noteID, userID)
+	})
+}
+
+//PurgeExpired purges the notes of every user deleted before the given time, with a
+//commit per user
+func (s *Store) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	users, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return 0, fmt.Errorf("error occurred while reading the repository: %s", err)
+	}
+
+	var purged int64
+	for _, user := range users {
+		userID := user.Name()
+		if !user.IsDir() || !validName(userID) {
+			continue
+		}
+		// The notes are only counted once their removal is committed
+		var n int64
+		err := s.change(ctx, userID, "Purge the expired notes", func(index *memory.Store) error {
+			notes, err := index.ListTrash(ctx, userID)
+			if err != nil {
+				return err
+			}
+			for _, note := range notes {
+				deletedAt, _ := time.Parse(time.RFC3339Nano, note.DeletedAt)
+				if !deletedAt.Before(before) {
+					continue
+				}
+				err := index.Purge(ctx, note.ID, userID)
+				if err != nil {
+					return err
+				}
+				n++
+			}
+			return nil
+		})
+		if err != nil {
+			return purged, err
+		}
+		purged += n
+	}
+	return purged, nil
+}
@@ -0,0 +1,40 @@
+package notestore
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultIdempotencyWindow is how long the responses to the requests with an
+// idempotency key are kept for their retries
+const DefaultIdempotencyWindow = 24 * time.Hour
+
+// MaxIdempotencyKeyLength is the maximum length of an idempotency key
+const MaxIdempotencyKeyLength = 255
+
+// IdempotentResponse is the response to the first request made with an idempotency key
+type IdempotentResponse struct {
+	UserID string
+	Key    string
+	// RequestHash identifies the request, retries must send the same request
+	RequestHash string
+	// Status is 0 while the first request is in progress
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// IdempotencyStore is the interface for the responses replayed to the retries of
+// the requests made with an idempotency key
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey reserves the key of the user for the request and returns true,
+	// or returns the response stored for the key and false when it is already reserved.
+	// Keys reserved before expiredBefore are free again, and so are the keys still in
+	// progress reserved before abandonedBefore as their request was given up.
+	ReserveIdempotencyKey(ctx context.Context, userID, key, requestHash string, expiredBefore, abandonedBefore time.Time) (IdempotentResponse, bool, error)
+	// SaveIdempotentResponse stores the response to the request that reserved the key
+	SaveIdempotentResponse(ctx context.Context, resp IdempotentResponse) error
+	// ReleaseIdempotencyKey frees a key whose request failed so that it can be retried
+	ReleaseIdempotencyKey(ctx context.Context, userID, key string) error
+}
@@ -0,0 +1,88 @@
+package notestore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxItemLength is the maximum length of the text of a checklist item in characters
+const MaxItemLength = 500
+
+// Item is an entry of the checklist of a note
+type Item struct {
+	ID      string
+	NoteID  string
+	Text    string
+	Checked bool
+	// Position orders the items of a note, starting at 0
+	Position  int
+	CreatedAt string
+}
+
+// Progress counts the checked items of a checklist
+type Progress struct {
+	Done  int
+	Total int
+}
+
+// String formats the progress as done/total, e.g. 3/7
+func (p Progress) String() string {
+	return fmt.Sprintf("%d/%d", p.Done, p.Total)
+}
+
+// ItemProgress returns the progress of the checklist
+func ItemProgress(items []Item) Progress {
+	p := Progress{Total: len(items)}
+	for _, item := range items {
+		if item.Checked {
+			p.Done++
+		}
+	}
+	return p
+}
+
+// ItemStore is the interface for storing the checklists of the notes.
+// The items are only accessible to the owner of their note.
+type ItemStore interface {
+	// ListItems returns the items of the note ordered by position
+	ListItems(ctx context.Context, noteID, userID string) ([]Item, error)
+	// CreateItem appends the item to the checklist of item.NoteID
+	CreateItem(ctx context.Context, userID string, item Item) error
+	// UpdateItem updates the text and checked state of the item
+	UpdateItem(ctx context.Context, userID string, item Item) error
+	DeleteItem(ctx context.Context, noteID, itemID, userID string) error
+	// ReorderItems sets the order of the items, itemIDs must contain every item of the note
+	ReorderItems(ctx context.Context, noteID, userID string, itemIDs []string) error
+}
+
+// NormalizeItemText trims the text of an item and checks its length
+func NormalizeItemText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", Invalidf("item text is empty")
+	}
+	if utf8.RuneCountInString(text) > MaxItemLength {
+		return "", Invalidf("item text is longer than %d characters", MaxItemLength)
+	}
+	return text, nil
+}
+
+// ValidateItemOrder checks that itemIDs lists every existing item exactly once
+func ValidateItemOrder(existing map[string]bool, itemIDs []string) error {
+	if len(itemIDs) != len(existing) {
+		return Invalidf("the order must list all %d items of the note", len(existing))
+	}
+	seen := map[string]bool{}
+	for _, id := range itemIDs {
+		if !existing[id] {
+			return Invalidf("Item '%s' is not found", id)
+		}
+		if seen[id] {
+			return Invalidf("item '%s' is listed more than once", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package notestore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItemProgress(t *testing.T) {
+	items := []Item{{Checked: true}, {Checked: false}, {Checked: true}}
+
+	assert := assert.New(t)
+	assert.Equal(Progress{Done: 2, Total: 3}, ItemProgress(items))
+	assert.Equal("2/3", ItemProgress(items).String())
+	assert.Equal("0/0", ItemProgress(nil).String())
+}
+
+func TestNormalizeItemText(t *testing.T) {
+	assert := assert.New(t)
+
+	text, err := NormalizeItemText("  Buy milk ")
+	assert.Nil(err)
+	assert.Equal("Buy milk", text)
+
+	_, err = NormalizeItemText(" ")
+	assert.NotNil(err)
+	_, err = NormalizeItemText(strings.Repeat("a", MaxItemLength+1))
+	assert.NotNil(err)
+}
+
+func TestValidateItemOrder(t *testing.T) {
+	existing := map[string]bool{"a": true, "b": true, "c": true}
+
+	testCases := []struct {
+		description   string
+		itemIDs       []string
+		expectedError bool
+	}{
+		{description: "All items", itemIDs: []string{"c", "a", "b"}},
+		{description: "Missing item", itemIDs: []string{"c", "a"}, expectedError: true},
+		{description: "Unknown item", itemIDs: []string{"c", "a", "d"}, expectedError: true},
+		{description: "Duplicate item", itemIDs: []string{"c", "a", "a"}, expectedError: true},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		err := ValidateItemOrder(existing, testCase.itemIDs)
+		if testCase.expectedError {
+			assert.NotNil(err, testCase.description)
+		} else {
+			assert.Nil(err, testCase.description)
+		}
+	}
+}
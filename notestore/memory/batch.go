@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+// undo restores the record of a note as it was before an operation
+type undo struct {
+	key noteKey
+	// r is nil when the note did not exist
+	r *record
+}
+
+// save returns the undo of the changes of an operation on the note
+func (s *Store) save(userID, noteID string) undo {
+	u := undo{key: noteKey{userID, noteID}}
+	if r, ok := s.notes[u.key]; ok {
+		u.r = r.copy()
+	}
+	return u
+}
+
+// rollback restores the notes saved by the undos, the latest first
+func (s *Store) rollback(undos []undo) {
+	for i := len(undos) - 1; i >= 0; i-- {
+		if undos[i].r == nil {
+			delete(s.notes, undos[i].key)
+			continue
+		}
+		s.notes[undos[i].key] = undos[i].r
+	}
+}
+
+//Batch runs the operations of a batch under a single lock. A failed operation of an
+//atomic batch rolls back the operations run before it.
+func (s *Store) Batch(ctx context.Context, userID string, ops []notestore.BatchOperation, atomic bool) ([]notestore.BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]notestore.BatchResult, len(ops))
+	var undos []undo
+	for i, op := range ops {
+		op.Note.UserID = userID
+		results[i].NoteID = op.Note.ID
+
+		u := s.save(userID, op.Note.ID)
+		err := s.runOperation(op)
+		switch {
+		case err != nil && atomic:
+			s.rollback(append(undos, u))
+			return notestore.AbortBatch(results, i, err), nil
+		case err != nil:
+			s.rollback([]undo{u})
+			results[i].Err = err
+		default:
+			undos = append(undos, u)
+		}
+	}
+	return results, nil
+}
+
+// runOperation runs an operation of a batch
+func (s *Store) runOperation(op notestore.BatchOperation) error {
+	switch op.Action {
+	case notestore.BatchCreate:
+		return s.createNote(op.Note)
+	case notestore.BatchUpdate:
+		return s.updateFields(op.Note, op.Fields)
+	case notestore.BatchDelete:
+		return s.deleteNote(op.Note.ID, op.Note.UserID, op.Note.Version)
+	}
+	return notestore.Invalidf("invalid batch operation '%s'", op.Action)
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+// idempotencyKey identifies an idempotency key of a user
+type idempotencyKey struct {
+	userID, key string
+}
+
+//ReserveIdempotencyKey reserves an idempotency key or returns the response stored for it
+func (s *Store) ReserveIdempotencyKey(ctx context.Context, userID, key, requestHash string, expiredBefore, abandonedBefore time.Time) (notestore.IdempotentResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The expired keys of the user are dropped as they are not replayed anymore, and
+	// so are the abandoned reservations
+	for k, resp := range s.keys {
+		if k.userID == userID && (resp.CreatedAt.Before(expiredBefore) || resp.Status == 0 && resp.CreatedAt.Before(abandonedBefore)) {
+			delete(s.keys, k)
+		}
+	}
+
+	resp, ok := s.keys[idempotencyKey{userID, key}]
+	if ok {
+		resp.Body = append([]byte(nil), resp.Body...)
+		return resp, false, nil
+	}
+	s.keys[idempotencyKey{userID, key}] = notestore.IdempotentResponse{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   time.Now(),
+	}
+	return notestore.IdempotentResponse{UserID: userID, Key: key}, true, nil
+}
+
+//SaveIdempotentResponse stores the response to the request that reserved the key
+func (s *Store) SaveIdempotentResponse(ctx context.Context, resp notestore.IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reserved, ok := s.keys[idempotencyKey{resp.UserID, resp.Key}]
+	if !ok {
+		return nil
+	}
+	reserved.Status = resp.Status
+	reserved.ContentType = resp.ContentType
+	reserved.Body = append([]byte(nil), resp.Body...)
+	s.keys[idempotencyKey{resp.UserID, resp.Key}] = reserved
+	return nil
+}
+
+//ReleaseIdempotencyKey deletes the reservation of a key whose request failed
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keys[idempotencyKey{userID, key}].Status == 0 {
+		delete(s.keys, idempotencyKey{userID, key})
+	}
+	return nil
+}
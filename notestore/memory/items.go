@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+//ListItems lists the checklist items of a note ordered by position
+func (s *Store) ListItems(ctx context.Context, noteID, userID string) ([]notestore.Item, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, err := s.note(noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := append([]notestore.Item{}, r.items...)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Position < items[j].Position
+	})
+	return items, nil
+}
+
+//CreateItem appends an item to the checklist of a note
+func (s *Store) CreateItem(ctx context.Context, userID string, item notestore.Item) error {
+	text, err := notestore.NormalizeItemText(item.Text)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.note(item.NoteID, userID)
+	if err != nil {
+		return err
+	}
+
+	item.Text = text
+	item.Position = 0
+	for _, existing := range r.items {
+		if existing.Position >= item.Position {
+			item.Position = existing.Position + 1
+		}
+	}
+	item.CreatedAt = now()
+	r.items = append(r.items, item)
+	bumpVersion(r)
+	return nil
+}
+
+//UpdateItem updates the text and checked state of a checklist item
+func (s *Store) UpdateItem(ctx context.Context, userID string, item notestore.Item) error {
+	text, err := notestore.NormalizeItemText(item.Text)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.note(item.NoteID, userID)
+	if err != nil {
+		return err
+	}
+
+	i := itemIndex(r, item.ID)
+	if i < 0 {
+		return notestore.NotFoundf("Item '%s' is not found", item.ID)
+	}
+	r.items[i].Text, r.items[i].Checked = text, item.Checked
+	bumpVersion(r)
+	return nil
+}
+
+//DeleteItem deletes a checklist item
+func (s *Store) DeleteItem(ctx context.Context, noteID, itemID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.note(noteID, userID)
+	if err != nil {
+		return err
+	}
+
+	i := itemIndex(r, itemID)
+	if i < 0 {
+		return notestore.NotFoundf("Item '%s' is not found", itemID)
+	}
+	r.items = append(r.items[:i], r.items[i+1:]...)
+	bumpVersion(r)
+	return nil
+}
+
+//ReorderItems sets the positions of the checklist items to their order in itemIDs
+func (s *Store) ReorderItems(ctx context.Context, noteID, userID string, itemIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.note(noteID, userID)
+	if err != nil {
+		return err
+	}
+
+	existing := map[string]bool{}
+	for _, item := range r.items {
+		existing[item.ID] = true
+	}
+	err = notestore.ValidateItemOrder(existing, itemIDs)
+	if err != nil {
+		return err
+	}
+
+	for position, id := range itemIDs {
+		r.items[itemIndex(r, id)].Position = position
+	}
+	bumpVersion(r)
+	return nil
+}
+
+// itemIndex returns the index of an item of the checklist, -1 when it is not found
+func itemIndex(r *record, itemID string) int {
+	for i, item := range r.items {
+		if item.ID == itemID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,426 @@
+// Package memory is a note store keeping the notes in memory, for the tests and
+// for running the app without Postgres. It follows the semantics of the postgres
+// store: the notes are only accessible to their owner, deleted notes are kept in
+// the trash and every change of a note increments its version.
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/google/uuid"
+)
+
+//Store is a thread-safe note store in memory, the zero value is not usable, see New
+type Store struct {
+	mu       sync.RWMutex
+	notes    map[noteKey]*record
+	projects map[string]notestore.Project
+	keys     map[idempotencyKey]notestore.IdempotentResponse
+}
+
+// noteKey identifies a note of a user
+type noteKey struct {
+	userID, noteID string
+}
+
+// record is a note with its checklist and revisions
+type record struct {
+	note      notestore.Note
+	items     []notestore.Item
+	revisions []notestore.Revision
+}
+
+// copy returns a deep copy of the record
+func (r *record) copy() *record {
+	cp := *r
+	cp.note.Tags = append([]string{}, r.note.Tags...)
+	cp.items = append([]notestore.Item(nil), r.items...)
+	cp.revisions = append([]notestore.Revision(nil), r.revisions...)
+	return &cp
+}
+
+// New returns an empty store
+func New() *Store {
+	return &Store{
+		notes:    map[noteKey]*record{},
+		projects: map[string]notestore.Project{},
+		keys:     map[idempotencyKey]notestore.IdempotentResponse{},
+	}
+}
+
+// now returns the current time in the format of the timestamps of the notes
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+// parseTime parses a timestamp of the store, the zero time is returned for empty timestamps
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
+// note returns the record of the note owned by the user, notes in the trash are not found
+func (s *Store) note(noteID, userID string) (*record, error) {
+	r, ok := s.notes[noteKey{userID, noteID}]
+	if !ok || r.note.DeletedAt != "" {
+		return nil, notestore.NotFoundf("Note '%s' is not found", noteID)
+	}
+	return r, nil
+}
+
+// bumpVersion increments the version of a note whose checklist or tags changed
+func bumpVersion(r *record) {
+	r.note.Version++
+}
+
+// read returns a copy of the note of the record
+func read(r *record) notestore.Note {
+	note := r.note
+	note.Tags = append([]string{}, r.note.Tags...)
+	return note
+}
+
+//Create creates a note along with its tags
+func (s *Store) Create(ctx context.Context, note notestore.Note) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.createNote(note)
+}
+
+// createNote normalizes and inserts a note after checking its project
+func (s *Store) createNote(note notestore.Note) error {
+	tags, err := notestore.NormalizeTags(note.Tags)
+	if err != nil {
+		return err
+	}
+	note, err = notestore.NormalizeTodo(note)
+	if err != nil {
+		return err
+	}
+	note.Tags = tags
+
+	if note.ProjectID != "" {
+		err := s.checkProject(note.ProjectID, note.UserID)
+		if err != nil {
+			return err
+		}
+	}
+	if _, ok := s.notes[noteKey{note.UserID, note.ID}]; ok {
+		return notestore.Conflictf("Note '%s' already exists", note.ID)
+	}
+	s.insertNote(note)
+	return nil
+}
+
+// insertNote inserts a normalized note and returns its record
+func (s *Store) insertNote(note notestore.Note) *record {
+	note.CreatedAt = now()
+	note.UpdatedAt = note.CreatedAt
+	note.CompletedAt = ""
+	if note.Status == notestore.StatusDone {
+		note.CompletedAt = note.CreatedAt
+	}
+	note.DeletedAt = ""
+	note.Version = 1
+	sort.Strings(note.Tags)
+
+	r := &record{note: note}
+	s.notes[noteKey{note.UserID, note.ID}] = r
+	return r
+}
+
+// Load inserts the notes as they are, keeping their timestamps, versions and trash
+// state, for indexing notes kept elsewhere. A loaded note replaces the note of the
+// same user and ID along with its checklist and revisions.
+func (s *Store) Load(notes ...notestore.Note) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, note := range notes {
+		note.Tags = append([]string{}, note.Tags...)
+		s.notes[noteKey{note.UserID, note.ID}] = &record{note: note}
+	}
+}
+
+//Read reads a note
+func (s *Store) Read(ctx context.Context, noteID, userID string) (notestore.Note, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, err := s.note(noteID, userID)
+	if err != nil {
+		return notestore.Note{}, err
+	}
+	return read(r), nil
+}
+
+//ReadAll reads a page of notes for the given user ID, filtered and sorted as by
+//the postgres store
+func (s *Store) ReadAll(ctx context.Context, userID string, opts notestore.ReadAllOptions) (notestore.Page, error) {
+	opts, cursor, err := opts.Normalize()
+	if err != nil {
+		return notestore.Page{}, err
+	}
+	if cursor != nil && opts.SortBy != notestore.SortByTitle {
+		_, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return notestore.Page{}, notestore.Invalidf("invalid cursor: %s", err)
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var notes []notestore.Note
+	for key, r := range s.notes {
+		if key.userID == userID && r.note.DeletedAt == "" && s.matches(r.note, opts) {
+			notes = append(notes, read(r))
+		}
+	}
+
+	// Paging backward walks the list in reverse order
+	desc := opts.Order == notestore.Descending
+	if cursor != nil && cursor.Backward {
+		desc = !desc
+	}
+	less := func(a, b notestore.Note) bool {
+		c := compareKeys(opts.SortBy, notestore.SortKey(a, opts.SortBy), notestore.SortKey(b, opts.SortBy))
+		if c == 0 {
+			c = strings.Compare(a.ID, b.ID)
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		return less(notes[i], notes[j])
+	})
+
+	if cursor != nil {
+		at := notestore.Note{ID: cursor.NoteID, CreatedAt: cursor.Key, UpdatedAt: cursor.Key, Title: cursor.Key}
+		i := sort.Search(len(notes), func(i int) bool {
+			return less(at, notes[i])
+		})
+		notes = notes[i:]
+	}
+	if len(notes) > opts.Limit+1 {
+		notes = notes[:opts.Limit+1]
+	}
+
+	return notestore.NewPage(notes, opts, cursor), nil
+}
+
+// matches reports whether the note passes the filters of normalized options
+func (s *Store) matches(note notestore.Note, opts notestore.ReadAllOptions) bool {
+	if opts.ProjectID != "" && note.ProjectID != opts.ProjectID {
+		return false
+	}
+	if opts.ProjectID == "" && s.projects[note.ProjectID].Archived {
+		return false
+	}
+	for _, tag := range opts.Tags {
+		if !contains(note.Tags, tag) {
+			return false
+		}
+	}
+	if len(opts.Statuses) > 0 {
+		found := false
+		for _, status := range opts.Statuses {
+			found = found || note.Status == status
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Notes without a due date never match a due date filter
+	if !opts.DueAfter.IsZero() || !opts.DueBefore.IsZero() {
+		if note.DueDate == "" || !inRange(parseTime(note.DueDate), opts.DueAfter, opts.DueBefore) {
+			return false
+		}
+	}
+	return inRange(parseTime(note.CreatedAt), opts.CreatedAfter, opts.CreatedBefore) &&
+		inRange(parseTime(note.UpdatedAt), opts.UpdatedAfter, opts.UpdatedBefore)
+}
+
+// inRange reports whether after <= t < before, zero bounds are ignored
+func inRange(t, after, before time.Time) bool {
+	return (after.IsZero() || !t.Before(after)) && (before.IsZero() || t.Before(before))
+}
+
+// compareKeys compares the values of a sort field, timestamps are compared as times
+func compareKeys(field notestore.SortField, a, b string) int {
+	if field == notestore.SortByTitle {
+		return strings.Compare(a, b)
+	}
+	ta, tb := parseTime(a), parseTime(b)
+	switch {
+	case ta.Before(tb):
+		return -1
+	case ta.After(tb):
+		return 1
+	}
+	return 0
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+//Update updates a note. The previous title and body are kept as a revision when they change.
+func (s *Store) Update(ctx context.Context, note notestore.Note) error {
+	note, err := notestore.NormalizeTodo(note)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updateNote(note)
+}
+
+//UpdateFields updates the listed fields of the note on top of its current content
+func (s *Store) UpdateFields(ctx context.Context, note notestore.Note, fields []notestore.NoteField) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updateFields(note, fields)
+}
+
+// updateFields merges the listed fields of the note into its current content and updates it
+func (s *Store) updateFields(note notestore.Note, fields []notestore.NoteField) error {
+	r, err := s.note(note.ID, note.UserID)
+	if err != nil {
+		return err
+	}
+	if note.Version != 0 && note.Version != r.note.Version {
+		return notestore.ErrVersionMismatch
+	}
+
+	current, err := notestore.NormalizeTodo(notestore.MergeFields(r.note, note, fields))
+	if err != nil {
+		return err
+	}
+	return s.updateNote(current)
+}
+
+// updateNote updates a normalized note and keeps a revision of its previous content.
+// The update is a compare-and-swap on the version of the note when note.Version is set.
+func (s *Store) updateNote(note notestore.Note) error {
+	r, err := s.note(note.ID, note.UserID)
+	if err != nil {
+		return err
+	}
+	if note.Version != 0 && note.Version != r.note.Version {
+		return notestore.ErrVersionMismatch
+	}
+	err = notestore.CheckDone(r.note, note)
+	if err != nil {
+		return err
+	}
+
+	updatedAt := now()
+	if r.note.Title != note.Title || r.note.Body != note.Body {
+		r.revisions = append(r.revisions, notestore.Revision{
+			NoteID:    note.ID,
+			Number:    len(r.revisions) + 1,
+			Title:     r.note.Title,
+			Body:      r.note.Body,
+			UserID:    note.UserID,
+			CreatedAt: updatedAt,
+		})
+	}
+
+	// CompletedAt is kept while the note stays done
+	switch {
+	case note.Status != notestore.StatusDone:
+		r.note.CompletedAt = ""
+	case r.note.CompletedAt == "":
+		r.note.CompletedAt = updatedAt
+	}
+	r.note.Title, r.note.Body = note.Title, note.Body
+	r.note.Status, r.note.Priority, r.note.DueDate = note.Status, note.Priority, note.DueDate
+	r.note.Recurrence, r.note.Occurrence = note.Recurrence, note.Occurrence
+	r.note.UpdatedAt = updatedAt
+	r.note.Version++
+	return nil
+}
+
+//Complete sets the status of a note to done. For a recurring note, the next
+//occurrence is created with the tags and an unchecked copy of the checklist of the note.
+func (s *Store) Complete(ctx context.Context, noteID, userID string) (notestore.Note, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.note(noteID, userID)
+	if err != nil {
+		return notestore.Note{}, err
+	}
+	// Completing twice must not spawn another occurrence
+	if r.note.Status == notestore.StatusDone {
+		return notestore.Note{}, nil
+	}
+
+	completedAt := now()
+	r.note.Status = notestore.StatusDone
+	r.note.CompletedAt, r.note.UpdatedAt = completedAt, completedAt
+	r.note.Version++
+
+	occurrence, ok := notestore.NextOccurrence(r.note)
+	if !ok {
+		return notestore.Note{}, nil
+	}
+	occurrence.ID = uuid.New().String()
+	occurrence.Tags = append([]string{}, occurrence.Tags...)
+	next := s.insertNote(occurrence)
+	for _, item := range r.items {
+		item.ID = uuid.New().String()
+		item.NoteID = occurrence.ID
+		item.Checked = false
+		item.CreatedAt = now()
+		next.items = append(next.items, item)
+	}
+	return read(next), nil
+}
+
+// Delete moves a note to the trash, see Purge for deleting it permanently
+func (s *Store) Delete(ctx context.Context, noteID, userID string, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleteNote(noteID, userID, version)
+}
+
+// deleteNote moves a note to the trash, when version is set only if the note is at that version
+func (s *Store) deleteNote(noteID, userID string, version int) error {
+	r, err := s.note(noteID, userID)
+	if err != nil {
+		return err
+	}
+	if version != 0 && version != r.note.Version {
+		return notestore.ErrVersionMismatch
+	}
+
+	r.note.DeletedAt = now()
+	r.note.Version++
+	return nil
+}
+
+// The store implements the interfaces of every feature of the notes
+var (
+	_ notestore.NoteStore        = (*Store)(nil)
+	_ notestore.TagStore         = (*Store)(nil)
+	_ notestore.ItemStore        = (*Store)(nil)
+	_ notestore.ProjectStore     = (*Store)(nil)
+	_ notestore.TrashStore       = (*Store)(nil)
+	_ notestore.RevisionStore    = (*Store)(nil)
+	_ notestore.IdempotencyStore = (*Store)(nil)
+)
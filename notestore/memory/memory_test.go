@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"local/sidharthjs/todo/notestore"
+	"local/sidharthjs/todo/notestore/notestoretest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConformance(t *testing.T) {
+	notestoretest.Run(t, New())
+}
+
+func TestCreateDuplicateNote(t *testing.T) {
+	store := New()
+	userID := "Notes_user_1"
+	noteID := uuid.New().String()
+
+	assert := assert.New(t)
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Groceries", UserID: userID})
+	assert.Nil(err)
+	err = store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Duplicate", UserID: userID})
+	assert.ErrorIs(err, notestore.ErrConflict)
+}
+
+func TestSearchRank(t *testing.T) {
+	store := New()
+	userID := "Search_user_1"
+	notes := []notestore.Note{
+		{ID: uuid.New().String(), Title: "Release checklist", Body: "Deploy the service and update the changelog", UserID: userID},
+		{ID: uuid.New().String(), Title: "Draft release notes", Body: "Deployment notes for the next release", UserID: userID},
+	}
+	for _, note := range notes {
+		err := store.Create(context.Background(), note)
+		assert.Nil(t, err)
+	}
+
+	// Matches in the title rank higher
+	assert := assert.New(t)
+	results, err := store.Search(context.Background(), userID, "release", 0)
+	assert.Nil(err)
+	if assert.Len(results, 2) {
+		assert.Equal(notes[1].ID, results[0].Note.ID)
+		assert.Equal("Draft <mark>release</mark> notes", results[0].TitleSnippet)
+	}
+}
@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+// checkProject checks that the project is owned by the user and is not archived
+func (s *Store) checkProject(projectID, userID string) error {
+	project, ok := s.projects[projectID]
+	if !ok || project.UserID != userID {
+		return notestore.NotFoundf("Project '%s' is not found", projectID)
+	}
+	if project.Archived {
+		return notestore.Conflictf("Project '%s' is archived", projectID)
+	}
+	return nil
+}
+
+// checkProjectName checks that the name is not used by another project of the user
+func (s *Store) checkProjectName(projectID, userID, name string) error {
+	for _, project := range s.projects {
+		if project.UserID == userID && project.Name == name && project.ID != projectID {
+			return notestore.Conflictf("Project '%s' already exists", name)
+		}
+	}
+	return nil
+}
+
+// project returns the project owned by the user with its note count
+func (s *Store) project(projectID, userID string) (notestore.Project, error) {
+	project, ok := s.projects[projectID]
+	if !ok || project.UserID != userID {
+		return notestore.Project{}, notestore.NotFoundf("Project '%s' is not found", projectID)
+	}
+
+	project.NoteCount = 0
+	for _, r := range s.notes {
+		if r.note.ProjectID == projectID && r.note.DeletedAt == "" {
+			project.NoteCount++
+		}
+	}
+	return project, nil
+}
+
+//CreateProject creates a project
+func (s *Store) CreateProject(ctx context.Context, project notestore.Project) error {
+	name, err := notestore.NormalizeProjectName(project.Name)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.checkProjectName(project.ID, project.UserID, name)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.projects[project.ID]; ok {
+		return notestore.Conflictf("Project '%s' already exists", project.ID)
+	}
+
+	project.Name = name
+	project.CreatedAt = now()
+	project.UpdatedAt = project.CreatedAt
+	project.NoteCount = 0
+	s.projects[project.ID] = project
+	return nil
+}
+
+//ReadProject reads a project
+func (s *Store) ReadProject(ctx context.Context, projectID, userID string) (notestore.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.project(projectID, userID)
+}
+
+//ListProjects lists the projects of the user ordered by name
+func (s *Store) ListProjects(ctx context.Context, userID string, includeArchived bool) ([]notestore.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	projects := []notestore.Project{}
+	for id, project := range s.projects {
+		if project.UserID != userID || (project.Archived && !includeArchived) {
+			continue
+		}
+		project, _ = s.project(id, userID)
+		projects = append(projects, project)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Name != projects[j].Name {
+			return projects[i].Name < projects[j].Name
+		}
+		return projects[i].ID < projects[j].ID
+	})
+	return projects, nil
+}
+
+//RenameProject renames a project of the user
+func (s *Store) RenameProject(ctx context.Context, projectID, userID, name string) error {
+	name, err := notestore.NormalizeProjectName(name)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.checkProjectName(projectID, userID, name)
+	if err != nil {
+		return err
+	}
+	project, ok := s.projects[projectID]
+	if !ok || project.UserID != userID {
+		return notestore.NotFoundf("Project '%s' is not found", projectID)
+	}
+
+	project.Name = name
+	project.UpdatedAt = now()
+	// Assigning replaces the key, which must not be the projectID of the caller as it
+	// may be a view of the request that Fiber reuses
+	s.projects[project.ID] = project
+	return nil
+}
+
+//ArchiveProject archives or restores a project of the user
+func (s *Store) ArchiveProject(ctx context.Context, projectID, userID string, archived bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[projectID]
+	if !ok || project.UserID != userID {
+		return notestore.NotFoundf("Project '%s' is not found", projectID)
+	}
+
+	project.Archived = archived
+	project.UpdatedAt = now()
+	s.projects[project.ID] = project
+	return nil
+}
+
+//DeleteProject deletes a project and moves its notes, deleted or not, to the inbox
+func (s *Store) DeleteProject(ctx context.Context, projectID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[projectID]
+	if !ok || project.UserID != userID {
+		return notestore.NotFoundf("Project '%s' is not found", projectID)
+	}
+
+	delete(s.projects, projectID)
+	for _, r := range s.notes {
+		if r.note.ProjectID == projectID {
+			r.note.ProjectID = ""
+		}
+	}
+	return nil
+}
+
+//MoveNote moves a note to a project, or to the inbox when projectID is empty
+func (s *Store) MoveNote(ctx context.Context, noteID, userID, projectID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.note(noteID, userID)
+	if err != nil {
+		return err
+	}
+	if r.note.ProjectID == projectID {
+		return nil
+	}
+	if r.note.ProjectID != "" {
+		err := s.checkProject(r.note.ProjectID, userID)
+		if err != nil {
+			return err
+		}
+	}
+	if projectID != "" {
+		err := s.checkProject(projectID, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.note.ProjectID = projectID
+	r.note.UpdatedAt = now()
+	r.note.Version++
+	return nil
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+//ListRevisions lists the revisions of a note, newest first
+func (s *Store) ListRevisions(ctx context.Context, noteID, userID string) ([]notestore.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, err := s.note(noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []notestore.Revision{}
+	for i := len(r.revisions) - 1; i >= 0; i-- {
+		revisions = append(revisions, r.revisions[i])
+	}
+	return revisions, nil
+}
+
+//ReadRevision reads a revision of a note
+func (s *Store) ReadRevision(ctx context.Context, noteID, userID string, number int) (notestore.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.revision(noteID, userID, number)
+}
+
+func (s *Store) revision(noteID, userID string, number int) (notestore.Revision, error) {
+	r, err := s.note(noteID, userID)
+	if err != nil {
+		return notestore.Revision{}, err
+	}
+	if number < 1 || number > len(r.revisions) {
+		return notestore.Revision{}, notestore.NotFoundf("Revision %d of note '%s' is not found", number, noteID)
+	}
+	return r.revisions[number-1], nil
+}
+
+//RestoreRevision rolls the title and body of a note back to a revision through
+//the same update as Update, so the replaced content becomes a new revision
+func (s *Store) RestoreRevision(ctx context.Context, noteID, userID string, number int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revision, err := s.revision(noteID, userID, number)
+	if err != nil {
+		return err
+	}
+	r, err := s.note(noteID, userID)
+	if err != nil {
+		return err
+	}
+
+	note := read(r)
+	note.Title, note.Body = revision.Title, revision.Body
+	note, err = notestore.NormalizeTodo(note)
+	if err != nil {
+		return err
+	}
+	return s.updateNote(note)
+}
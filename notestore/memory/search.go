@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+// Weights of the matches in the title and the body of a note, as in the search vector
+// of the postgres store
+const (
+	titleWeight = 1.0
+	bodyWeight  = 0.4
+)
+
+// headlineWords is the maximum number of words of the body snippets
+const headlineWords = 25
+
+// word is a word of a text with its position
+type word struct {
+	text       string
+	start, end int
+}
+
+// words splits a text into its lower-cased words of letters and digits
+func words(text string) []word {
+	var ws []word
+	start := -1
+	for i, r := range text + " " {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			ws = append(ws, word{text: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	return ws
+}
+
+// matchTerm returns the positions of the words of the text matched by the term.
+// The words are compared as they are, without the stemming of the postgres store.
+func matchTerm(ws []word, term notestore.SearchTerm) []int {
+	termWords := words(term.Text)
+	if len(termWords) == 0 {
+		return nil
+	}
+
+	var matched []int
+	if term.Phrase {
+		for i := 0; i+len(termWords) <= len(ws); i++ {
+			j := 0
+			for j < len(termWords) && ws[i+j].text == termWords[j].text {
+				j++
+			}
+			if j == len(termWords) {
+				for j := range termWords {
+					matched = append(matched, i+j)
+				}
+			}
+		}
+		return matched
+	}
+
+	// Every word of the term must match
+	for _, tw := range termWords {
+		found := false
+		for i, w := range ws {
+			if w.text == tw.text || (term.Prefix && strings.HasPrefix(w.text, tw.text)) {
+				matched = append(matched, i)
+				found = true
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return matched
+}
+
+// headline returns the text with the matched words wrapped in <mark></mark>. At most
+// maxWords words are kept around the first match when maxWords is set.
+func headline(text string, ws []word, matched map[int]bool, maxWords int) string {
+	from, to := 0, len(ws)
+	if maxWords > 0 && len(ws) > maxWords {
+		first := 0
+		for first < len(ws) && !matched[first] {
+			first++
+		}
+		if first == len(ws) {
+			first = 0
+		}
+		from = first - maxWords/4
+		if from < 0 {
+			from = 0
+		}
+		to = from + maxWords
+		if to > len(ws) {
+			to, from = len(ws), len(ws)-maxWords
+		}
+	}
+	if len(ws) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	start := 0
+	if from > 0 {
+		start = ws[from].start
+	}
+	end := len(text)
+	if to < len(ws) {
+		end = ws[to-1].end
+	}
+	pos := start
+	for i := from; i < to; i++ {
+		if !matched[i] {
+			continue
+		}
+		b.WriteString(text[pos:ws[i].start])
+		b.WriteString("<mark>" + text[ws[i].start:ws[i].end] + "</mark>")
+		pos = ws[i].end
+	}
+	b.WriteString(text[pos:end])
+	return b.String()
+}
+
+//Search searches the title and body of the notes of the given user ID, most relevant first.
+//Words are matched case-insensitively, matches in the title rank higher than in the body.
+func (s *Store) Search(ctx context.Context, userID, query string, limit int) ([]notestore.SearchResult, error) {
+	terms, err := notestore.ParseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = notestore.DefaultSearchLimit
+	}
+	if limit > notestore.MaxSearchLimit {
+		limit = notestore.MaxSearchLimit
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := []notestore.SearchResult{}
+	for key, r := range s.notes {
+		if key.userID != userID || r.note.DeletedAt != "" {
+			continue
+		}
+
+		titleWords, bodyWords := words(r.note.Title), words(r.note.Body)
+		titleMatched, bodyMatched := map[int]bool{}, map[int]bool{}
+		matches := true
+		for _, term := range terms {
+			inTitle, inBody := matchTerm(titleWords, term), matchTerm(bodyWords, term)
+			found := len(inTitle) > 0 || len(inBody) > 0
+			if found == term.Negated {
+				matches = false
+				break
+			}
+			for _, i := range inTitle {
+				titleMatched[i] = true
+			}
+			for _, i := range inBody {
+				bodyMatched[i] = true
+			}
+		}
+		if !matches {
+			continue
+		}
+
+		results = append(results, notestore.SearchResult{
+			Note:         read(r),
+			Rank:         titleWeight*float64(len(titleMatched)) + bodyWeight*float64(len(bodyMatched)),
+			TitleSnippet: headline(r.note.Title, titleWords, titleMatched, 0),
+			BodySnippet:  headline(r.note.Body, bodyWords, bodyMatched, headlineWords),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if c := compareKeys(notestore.SortByCreated, a.Note.CreatedAt, b.Note.CreatedAt); c != 0 {
+			return c > 0
+		}
+		return a.Note.ID < b.Note.ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+// hasTag reports whether a note of the user, deleted or not, has the tag
+func (s *Store) hasTag(userID, tag string) bool {
+	for key, r := range s.notes {
+		if key.userID == userID && contains(r.note.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// addTags attaches the normalized tags to the note
+func addTags(r *record, tags []string) {
+	for _, tag := range tags {
+		if !contains(r.note.Tags, tag) {
+			r.note.Tags = append(r.note.Tags, tag)
+		}
+	}
+	sort.Strings(r.note.Tags)
+}
+
+// removeTag detaches a tag from the note and reports whether it was attached
+func removeTag(r *record, tag string) bool {
+	for i, t := range r.note.Tags {
+		if t == tag {
+			r.note.Tags = append(r.note.Tags[:i:i], r.note.Tags[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+//AddTags attaches the tags to a note
+func (s *Store) AddTags(ctx context.Context, noteID, userID string, tags []string) error {
+	tags, err := notestore.NormalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.note(noteID, userID)
+	if err != nil {
+		return err
+	}
+	addTags(r, tags)
+	bumpVersion(r)
+	return nil
+}
+
+//RemoveTag detaches a tag from a note
+func (s *Store) RemoveTag(ctx context.Context, noteID, userID, tag string) error {
+	tag, err := notestore.NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.note(noteID, userID)
+	if err != nil {
+		return err
+	}
+	if !removeTag(r, tag) {
+		return notestore.NotFoundf("Tag '%s' is not found on note '%s'", tag, noteID)
+	}
+	bumpVersion(r)
+	return nil
+}
+
+//ListTags lists the tags of the user with the number of notes for each tag
+func (s *Store) ListTags(ctx context.Context, userID string) ([]notestore.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := map[string]int{}
+	for key, r := range s.notes {
+		if key.userID != userID || r.note.DeletedAt != "" {
+			continue
+		}
+		for _, tag := range r.note.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := []notestore.Tag{}
+	for name, count := range counts {
+		tags = append(tags, notestore.Tag{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+//RenameTag renames a tag of the user
+func (s *Store) RenameTag(ctx context.Context, userID, name, newName string) error {
+	name, err := notestore.NormalizeTag(name)
+	if err != nil {
+		return err
+	}
+	newName, err = notestore.NormalizeTag(newName)
+	if err != nil {
+		return err
+	}
+	if name == newName {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hasTag(userID, newName) {
+		return notestore.Conflictf("Tag '%s' already exists", newName)
+	}
+	if !s.hasTag(userID, name) {
+		return notestore.NotFoundf("Tag '%s' is not found", name)
+	}
+	for key, r := range s.notes {
+		if key.userID == userID && removeTag(r, name) {
+			addTags(r, []string{newName})
+			bumpVersion(r)
+		}
+	}
+	return nil
+}
+
+//MergeTags moves the notes of the source tags to the target tag and deletes the source tags
+func (s *Store) MergeTags(ctx context.Context, userID string, sources []string, target string) error {
+	sources, err := notestore.NormalizeTags(sources)
+	if err != nil {
+		return err
+	}
+	target, err = notestore.NormalizeTag(target)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The sources are checked first so that a missing one leaves the tags unchanged
+	for _, source := range sources {
+		if source != target && !s.hasTag(userID, source) {
+			return notestore.NotFoundf("Tag '%s' is not found", source)
+		}
+	}
+	for _, source := range sources {
+		if source == target {
+			continue
+		}
+		for key, r := range s.notes {
+			if key.userID == userID && removeTag(r, source) {
+				addTags(r, []string{target})
+				bumpVersion(r)
+			}
+		}
+	}
+	return nil
+}
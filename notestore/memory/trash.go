@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+//ListTrash lists the deleted notes of the user, most recently deleted first
+func (s *Store) ListTrash(ctx context.Context, userID string) ([]notestore.Note, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notes := []notestore.Note{}
+	for key, r := range s.notes {
+		if key.userID == userID && r.note.DeletedAt != "" {
+			notes = append(notes, read(r))
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		if c := compareKeys(notestore.SortByCreated, notes[i].DeletedAt, notes[j].DeletedAt); c != 0 {
+			return c > 0
+		}
+		return notes[i].ID < notes[j].ID
+	})
+	return notes, nil
+}
+
+// deleted returns the record of the note of the user in the trash
+func (s *Store) deleted(noteID, userID string) (*record, error) {
+	r, ok := s.notes[noteKey{userID, noteID}]
+	if !ok || r.note.DeletedAt == "" {
+		return nil, notestore.NotFoundf("Note '%s' is not found in the trash", noteID)
+	}
+	return r, nil
+}
+
+//Restore moves a note out of the trash
+func (s *Store) Restore(ctx context.Context, noteID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.deleted(noteID, userID)
+	if err != nil {
+		return err
+	}
+	r.note.DeletedAt = ""
+	r.note.UpdatedAt = now()
+	r.note.Version++
+	return nil
+}
+
+//Purge permanently deletes a note from the trash along with its checklist and revisions
+func (s *Store) Purge(ctx context.Context, noteID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.deleted(noteID, userID)
+	if err != nil {
+		return err
+	}
+	delete(s.notes, noteKey{userID, noteID})
+	return nil
+}
+
+//PurgeExpired permanently deletes the notes of every user deleted before the given time
+func (s *Store) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for key, r := range s.notes {
+		if r.note.DeletedAt != "" && parseTime(r.note.DeletedAt).Before(before) {
+			delete(s.notes, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
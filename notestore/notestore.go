@@ -2,6 +2,7 @@ package notestore
 
 import (
 	"context"
+	"time"
 )
 
 //Note is the model for the Notes
@@ -11,13 +12,116 @@ type Note struct {
 	Body      string
 	UserID    string
 	CreatedAt string
+	UpdatedAt string
+	Tags      []string
+	// ProjectID is the project of the note, empty for notes in the inbox
+	ProjectID string
+
+	Status   Status
+	Priority int
+	// DueDate is an RFC 3339 timestamp, empty when the note has no due date
+	DueDate string
+	// CompletedAt is set when the status is done
+	CompletedAt string
+
+	// Recurrence is the RRULE of a recurring note, see ParseRecurrence
+	Recurrence string
+	// Occurrence is the position of a recurring note in its series, starting at 1
+	Occurrence int
+
+	// DeletedAt is set while the note is in the trash
+	DeletedAt string
+
+	// Version is incremented on every change of the note, starting at 1
+	Version int
+}
+
+//SortField is the field the notes are sorted by
+type SortField string
+
+//Sort fields supported by ReadAll
+const (
+	SortByCreated SortField = "created"
+	SortByUpdated SortField = "updated"
+	SortByTitle   SortField = "title"
+)
+
+//SortOrder is the direction of the sort
+type SortOrder string
+
+//Sort orders supported by ReadAll
+const (
+	Ascending  SortOrder = "asc"
+	Descending SortOrder = "desc"
+)
+
+//Page size limits for ReadAll
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+//ReadAllOptions controls the pagination, sorting and filtering of ReadAll.
+//The zero value returns the first page of notes sorted by creation time, oldest first.
+type ReadAllOptions struct {
+	// Limit is the page size, defaults to DefaultPageSize and is capped at MaxPageSize
+	Limit int
+	// Cursor is an opaque cursor returned in a previous Page. When set, its sort
+	// field and order take precedence over SortBy and Order.
+	Cursor string
+	SortBy SortField
+	Order  SortOrder
+
+	// ProjectID filters the notes of the project. When empty, the notes of
+	// archived projects are left out.
+	ProjectID string
+
+	// Tags filters the notes having all of the tags
+	Tags []string
+
+	// Statuses filters the notes having any of the statuses
+	Statuses []Status
+	// Due narrows DueAfter and DueBefore down to a preset range
+	Due DueFilter
+
+	// Date-range filters, zero values are ignored
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	DueAfter      time.Time
+	DueBefore     time.Time
+}
+
+//Page is a page of notes returned by ReadAll
+type Page struct {
+	Notes      []Note
+	NextCursor string
+	PrevCursor string
 }
 
 //NoteStore is the interface for the note storage
 type NoteStore interface {
 	Create(ctx context.Context, note Note) error
 	Read(ctx context.Context, noteID, userID string) (Note, error)
-	ReadAll(ctx context.Context, userID string) ([]Note, error)
+	ReadAll(ctx context.Context, userID string, opts ReadAllOptions) (Page, error)
+	// Update replaces the note. When note.Version is set, the note is only updated
+	// if it is still at that version and ErrVersionMismatch is returned otherwise.
 	Update(ctx context.Context, note Note) error
-	Delete(ctx context.Context, noteID, userID string) error
+	// UpdateFields updates the listed fields of the note and leaves the others
+	// unchanged, with the same version check as Update
+	UpdateFields(ctx context.Context, note Note, fields []NoteField) error
+	// Delete moves the note to the trash when the store is a TrashStore. When
+	// version is set, the note is only deleted if it is still at that version.
+	Delete(ctx context.Context, noteID, userID string, version int) error
+	// Complete sets the status of a note to done. Completing a recurring note
+	// creates and returns the next occurrence of its series, the returned note
+	// is empty otherwise.
+	Complete(ctx context.Context, noteID, userID string) (Note, error)
+	Search(ctx context.Context, userID, query string, limit int) ([]SearchResult, error)
+	// Batch runs the operations on the notes of the user in a single transaction and
+	// returns their results. An atomic batch is rolled back when any operation fails,
+	// otherwise only the failed operations are. The error is only set when the batch
+	// could not be run.
+	Batch(ctx context.Context, userID string, ops []BatchOperation, atomic bool) ([]BatchResult, error)
 }
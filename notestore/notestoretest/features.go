@@ -0,0 +1,447 @@
+package notestoretest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func testVersions(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	noteID := uuid.New().String()
+
+	assert := assert.New(t)
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Deploy", UserID: userID})
+	assert.Nil(err)
+	version := 1
+
+	// Checklist and tag changes are changes of the note
+	if items, ok := store.(notestore.ItemStore); ok {
+		err = items.CreateItem(context.Background(), userID, notestore.Item{ID: uuid.New().String(), NoteID: noteID, Text: "Tag the release"})
+		assert.Nil(err)
+		version++
+	}
+	if tags, ok := store.(notestore.TagStore); ok {
+		err = tags.AddTags(context.Background(), noteID, userID, []string{"release"})
+		assert.Nil(err)
+		version++
+	}
+	note, err := store.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.Equal(version, note.Version)
+
+	err = store.Update(context.Background(), notestore.Note{ID: noteID, Title: "Deploy v2", UserID: userID, Version: version - 1})
+	assert.Equal(notestore.ErrVersionMismatch, err)
+	err = store.Update(context.Background(), notestore.Note{ID: noteID, Title: "Deploy v2", UserID: userID, Version: version})
+	assert.Nil(err)
+	err = store.Update(context.Background(), notestore.Note{ID: noteID, Title: "Deploy v3", UserID: userID, Version: version})
+	assert.Equal(notestore.ErrVersionMismatch, err)
+
+	// Updates without a version always apply
+	err = store.Update(context.Background(), notestore.Note{ID: noteID, Title: "Deploy v3", UserID: userID})
+	assert.Nil(err)
+	note, err = store.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.Equal("Deploy v3", note.Title)
+	assert.Equal(version+2, note.Version)
+
+	err = store.Delete(context.Background(), noteID, userID, version+1)
+	assert.Equal(notestore.ErrVersionMismatch, err)
+	err = store.Delete(context.Background(), noteID, userID, version+2)
+	assert.Nil(err)
+}
+
+func testTags(t *testing.T, store notestore.NoteStore) {
+	tagStore, ok := store.(notestore.TagStore)
+	if !ok {
+		t.Skip("The store does not implement notestore.TagStore")
+	}
+	userID := newUserID()
+	noteID1 := uuid.New().String()
+	noteID2 := uuid.New().String()
+
+	assert := assert.New(t)
+	err := store.Create(context.Background(), notestore.Note{ID: noteID1, Title: "Tagged note 1", Body: "Infra work", UserID: userID, Tags: []string{"Infra", "urgent"}})
+	assert.Nil(err)
+	err = store.Create(context.Background(), notestore.Note{ID: noteID2, Title: "Tagged note 2", Body: "Ops work", UserID: userID})
+	assert.Nil(err)
+	err = tagStore.AddTags(context.Background(), noteID2, userID, []string{"ops", "urgent"})
+	assert.Nil(err)
+
+	note, err := store.Read(context.Background(), noteID1, userID)
+	assert.Nil(err)
+	assert.Equal([]string{"infra", "urgent"}, note.Tags)
+
+	tags, err := tagStore.ListTags(context.Background(), userID)
+	assert.Nil(err)
+	assert.Equal([]notestore.Tag{{Name: "infra", Count: 1}, {Name: "ops", Count: 1}, {Name: "urgent", Count: 2}}, tags)
+
+	page, err := store.ReadAll(context.Background(), userID, notestore.ReadAllOptions{Tags: []string{"urgent", "ops"}})
+	assert.Nil(err)
+	assert.Equal([]string{noteID2}, noteIDs(page.Notes))
+
+	// Tagging a note of another user fails
+	err = tagStore.AddTags(context.Background(), noteID1, newUserID(), []string{"stolen"})
+	assert.EqualError(err, fmt.Sprintf("Note '%s' is not found", noteID1))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+
+	err = tagStore.RenameTag(context.Background(), userID, "ops", "urgent")
+	assert.EqualError(err, "Tag 'urgent' already exists")
+	assert.ErrorIs(err, notestore.ErrConflict)
+	err = tagStore.RenameTag(context.Background(), userID, "ops", "operations")
+	assert.Nil(err)
+
+	err = tagStore.MergeTags(context.Background(), userID, []string{"infra", "missing"}, "work")
+	assert.ErrorIs(err, notestore.ErrNotFound)
+	err = tagStore.MergeTags(context.Background(), userID, []string{"infra", "operations"}, "work")
+	assert.Nil(err)
+	tags, err = tagStore.ListTags(context.Background(), userID)
+	assert.Nil(err)
+	assert.Equal([]notestore.Tag{{Name: "urgent", Count: 2}, {Name: "work", Count: 2}}, tags)
+
+	err = tagStore.RemoveTag(context.Background(), noteID1, userID, "urgent")
+	assert.Nil(err)
+	err = tagStore.RemoveTag(context.Background(), noteID1, userID, "urgent")
+	assert.EqualError(err, fmt.Sprintf("Tag 'urgent' is not found on note '%s'", noteID1))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+
+	// Tags are not listed once the last note using them is in the trash
+	err = store.Delete(context.Background(), noteID2, userID, 0)
+	assert.Nil(err)
+	tags, err = tagStore.ListTags(context.Background(), userID)
+	assert.Nil(err)
+	assert.Equal([]notestore.Tag{{Name: "work", Count: 1}}, tags)
+}
+
+func testItems(t *testing.T, store notestore.NoteStore) {
+	itemStore, ok := store.(notestore.ItemStore)
+	if !ok {
+		t.Skip("The store does not implement notestore.ItemStore")
+	}
+	userID := newUserID()
+	noteID := uuid.New().String()
+
+	assert := assert.New(t)
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Shopping list", UserID: userID})
+	assert.Nil(err)
+
+	var itemIDs []string
+	for _, text := range []string{"Milk", "Eggs", "Bread"} {
+		item := notestore.Item{ID: uuid.New().String(), NoteID: noteID, Text: text}
+		err := itemStore.CreateItem(context.Background(), userID, item)
+		assert.Nil(err)
+		itemIDs = append(itemIDs, item.ID)
+	}
+
+	// Items of another user's note are not accessible
+	otherID := newUserID()
+	err = itemStore.CreateItem(context.Background(), otherID, notestore.Item{ID: uuid.New().String(), NoteID: noteID, Text: "Cheese"})
+	assert.EqualError(err, fmt.Sprintf("Note '%s' is not found", noteID))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+	_, err = itemStore.ListItems(context.Background(), noteID, otherID)
+	assert.EqualError(err, fmt.Sprintf("Note '%s' is not found", noteID))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+
+	err = itemStore.UpdateItem(context.Background(), userID, notestore.Item{ID: itemIDs[1], NoteID: noteID, Text: "Free range eggs", Checked: true})
+	assert.Nil(err)
+	err = itemStore.ReorderItems(context.Background(), noteID, userID, []string{itemIDs[2], itemIDs[0], itemIDs[1]})
+	assert.Nil(err)
+	err = itemStore.ReorderItems(context.Background(), noteID, userID, []string{itemIDs[2], itemIDs[0]})
+	assert.ErrorIs(err, notestore.ErrValidation)
+
+	items, err := itemStore.ListItems(context.Background(), noteID, userID)
+	assert.Nil(err)
+	var texts []string
+	for _, item := range items {
+		texts = append(texts, item.Text)
+	}
+	assert.Equal([]string{"Bread", "Milk", "Free range eggs"}, texts)
+	assert.Equal("1/3", notestore.ItemProgress(items).String())
+
+	err = itemStore.DeleteItem(context.Background(), noteID, itemIDs[0], userID)
+	assert.Nil(err)
+	err = itemStore.DeleteItem(context.Background(), noteID, itemIDs[0], userID)
+	assert.EqualError(err, fmt.Sprintf("Item '%s' is not found", itemIDs[0]))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+}
+
+func testProjects(t *testing.T, store notestore.NoteStore) {
+	projectStore, ok := store.(notestore.ProjectStore)
+	if !ok {
+		t.Skip("The store does not implement notestore.ProjectStore")
+	}
+	userID := newUserID()
+	projectID := uuid.New().String()
+
+	assert := assert.New(t)
+	err := projectStore.CreateProject(context.Background(), notestore.Project{ID: projectID, Name: " Work ", UserID: userID})
+	assert.Nil(err)
+	err = projectStore.CreateProject(context.Background(), notestore.Project{ID: uuid.New().String(), Name: "Work", UserID: userID})
+	assert.EqualError(err, "Project 'Work' already exists")
+	assert.ErrorIs(err, notestore.ErrConflict)
+	otherID := uuid.New().String()
+	err = projectStore.CreateProject(context.Background(), notestore.Project{ID: otherID, Name: "Home", UserID: userID})
+	assert.Nil(err)
+
+	// Notes can be created in a project of the user only
+	inProject, inbox := uuid.New().String(), uuid.New().String()
+	createNotes(t, store,
+		notestore.Note{ID: inProject, Title: "Quarterly review", UserID: userID, ProjectID: projectID},
+		notestore.Note{ID: inbox, Title: "Call the plumber", UserID: userID},
+	)
+	err = store.Create(context.Background(), notestore.Note{ID: uuid.New().String(), Title: "Sneaky", UserID: newUserID(), ProjectID: projectID})
+	assert.EqualError(err, fmt.Sprintf("Project '%s' is not found", projectID))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+
+	page, err := store.ReadAll(context.Background(), userID, notestore.ReadAllOptions{ProjectID: projectID})
+	assert.Nil(err)
+	if assert.Len(page.Notes, 1) {
+		assert.Equal(inProject, page.Notes[0].ID)
+		assert.Equal(projectID, page.Notes[0].ProjectID)
+	}
+
+	err = projectStore.MoveNote(context.Background(), inbox, userID, otherID)
+	assert.Nil(err)
+	note, err := store.Read(context.Background(), inbox, userID)
+	assert.Nil(err)
+	assert.Equal(otherID, note.ProjectID)
+
+	err = projectStore.RenameProject(context.Background(), otherID, userID, "Work")
+	assert.EqualError(err, "Project 'Work' already exists")
+	assert.ErrorIs(err, notestore.ErrConflict)
+	err = projectStore.RenameProject(context.Background(), otherID, userID, "House")
+	assert.Nil(err)
+
+	// Archived projects and their notes are hidden unless requested
+	err = projectStore.ArchiveProject(context.Background(), projectID, userID, true)
+	assert.Nil(err)
+	projects, err := projectStore.ListProjects(context.Background(), userID, false)
+	assert.Nil(err)
+	if assert.Len(projects, 1) {
+		assert.Equal("House", projects[0].Name)
+		assert.Equal(1, projects[0].NoteCount)
+	}
+	projects, err = projectStore.ListProjects(context.Background(), userID, true)
+	assert.Nil(err)
+	assert.Len(projects, 2)
+
+	page, err = store.ReadAll(context.Background(), userID, notestore.ReadAllOptions{})
+	assert.Nil(err)
+	assert.Equal([]string{inbox}, noteIDs(page.Notes))
+	page, err = store.ReadAll(context.Background(), userID, notestore.ReadAllOptions{ProjectID: projectID})
+	assert.Nil(err)
+	assert.Len(page.Notes, 1)
+
+	err = projectStore.MoveNote(context.Background(), inbox, userID, projectID)
+	assert.EqualError(err, fmt.Sprintf("Project '%s' is archived", projectID))
+	assert.ErrorIs(err, notestore.ErrConflict)
+	err = projectStore.MoveNote(context.Background(), inProject, userID, "")
+	assert.EqualError(err, fmt.Sprintf("Project '%s' is archived", projectID))
+	assert.ErrorIs(err, notestore.ErrConflict)
+
+	// Deleting a project moves its notes to the inbox
+	err = projectStore.DeleteProject(context.Background(), otherID, userID)
+	assert.Nil(err)
+	note, err = store.Read(context.Background(), inbox, userID)
+	assert.Nil(err)
+	assert.Empty(note.ProjectID)
+	_, err = projectStore.ReadProject(context.Background(), otherID, userID)
+	assert.EqualError(err, fmt.Sprintf("Project '%s' is not found", otherID))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+}
+
+func testTrash(t *testing.T, store notestore.NoteStore) {
+	trashStore, ok := store.(notestore.TrashStore)
+	if !ok {
+		t.Skip("The store does not implement notestore.TrashStore")
+	}
+	userID := newUserID()
+	noteID1 := uuid.New().String()
+	noteID2 := uuid.New().String()
+
+	assert := assert.New(t)
+	for _, noteID := range []string{noteID1, noteID2} {
+		createNotes(t, store, notestore.Note{ID: noteID, Title: "Trash me", UserID: userID, Tags: []string{"junk"}})
+		err := store.Delete(context.Background(), noteID, userID, 0)
+		assert.Nil(err)
+	}
+
+	// Notes in the trash are left out of the other methods
+	err := store.Delete(context.Background(), noteID1, userID, 0)
+	assert.EqualError(err, fmt.Sprintf("Note '%s' is not found", noteID1))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+	err = store.Update(context.Background(), notestore.Note{ID: noteID1, Title: "Updated", UserID: userID})
+	assert.EqualError(err, fmt.Sprintf("Note '%s' is not found", noteID1))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+	page, err := store.ReadAll(context.Background(), userID, notestore.ReadAllOptions{})
+	assert.Nil(err)
+	assert.Empty(page.Notes)
+	results, err := store.Search(context.Background(), userID, "trash", 0)
+	assert.Nil(err)
+	assert.Empty(results)
+
+	notes, err := trashStore.ListTrash(context.Background(), userID)
+	assert.Nil(err)
+	if assert.Len(notes, 2) {
+		assert.Equal(noteID2, notes[0].ID)
+		assert.NotEmpty(notes[0].DeletedAt)
+	}
+	otherID := newUserID()
+	notes, err = trashStore.ListTrash(context.Background(), otherID)
+	assert.Nil(err)
+	assert.Empty(notes)
+
+	err = trashStore.Restore(context.Background(), noteID1, userID)
+	assert.Nil(err)
+	note, err := store.Read(context.Background(), noteID1, userID)
+	assert.Nil(err)
+	assert.Empty(note.DeletedAt)
+	assert.Equal([]string{"junk"}, note.Tags)
+	err = trashStore.Restore(context.Background(), noteID1, userID)
+	assert.EqualError(err, fmt.Sprintf("Note '%s' is not found in the trash", noteID1))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+
+	// Only notes in the trash can be purged
+	err = trashStore.Purge(context.Background(), noteID1, userID)
+	assert.EqualError(err, fmt.Sprintf("Note '%s' is not found in the trash", noteID1))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+	err = trashStore.Purge(context.Background(), noteID2, otherID)
+	assert.EqualError(err, fmt.Sprintf("Note '%s' is not found in the trash", noteID2))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+
+	// Expired notes are purged for every user, including the notes of the other tests
+	err = store.Delete(context.Background(), noteID1, userID, 0)
+	assert.Nil(err)
+	n, err := trashStore.PurgeExpired(context.Background(), time.Now().Add(-time.Hour))
+	assert.Nil(err)
+	assert.Equal(int64(0), n)
+	n, err = trashStore.PurgeExpired(context.Background(), time.Now().Add(time.Minute))
+	assert.Nil(err)
+	assert.GreaterOrEqual(n, int64(2))
+	notes, err = trashStore.ListTrash(context.Background(), userID)
+	assert.Nil(err)
+	assert.Empty(notes)
+	err = trashStore.Restore(context.Background(), noteID1, userID)
+	assert.ErrorIs(err, notestore.ErrNotFound)
+}
+
+func testRevisions(t *testing.T, store notestore.NoteStore) {
+	revisionStore, ok := store.(notestore.RevisionStore)
+	if !ok {
+		t.Skip("The store does not implement notestore.RevisionStore")
+	}
+	userID := newUserID()
+	noteID := uuid.New().String()
+
+	assert := assert.New(t)
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Draft", Body: "First draft", UserID: userID})
+	assert.Nil(err)
+
+	err = store.Update(context.Background(), notestore.Note{ID: noteID, Title: "Draft", Body: "Second draft", UserID: userID})
+	assert.Nil(err)
+	// Updates that keep the title and body are not revisions
+	err = store.Update(context.Background(), notestore.Note{ID: noteID, Title: "Draft", Body: "Second draft", UserID: userID, Priority: 2})
+	assert.Nil(err)
+	err = store.Update(context.Background(), notestore.Note{ID: noteID, Title: "Final", Body: "Third draft", UserID: userID})
+	assert.Nil(err)
+
+	revisions, err := revisionStore.ListRevisions(context.Background(), noteID, userID)
+	assert.Nil(err)
+	if assert.Len(revisions, 2) {
+		assert.Equal(2, revisions[0].Number)
+		assert.Equal("Second draft", revisions[0].Body)
+		assert.Equal(1, revisions[1].Number)
+		assert.Equal("First draft", revisions[1].Body)
+		assert.Equal(userID, revisions[1].UserID)
+	}
+
+	_, err = revisionStore.ListRevisions(context.Background(), noteID, newUserID())
+	assert.EqualError(err, fmt.Sprintf("Note '%s' is not found", noteID))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+	_, err = revisionStore.ReadRevision(context.Background(), noteID, userID, 3)
+	assert.EqualError(err, fmt.Sprintf("Revision 3 of note '%s' is not found", noteID))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+
+	// Restoring stores the replaced content as a new revision
+	err = revisionStore.RestoreRevision(context.Background(), noteID, userID, 1)
+	assert.Nil(err)
+	note, err := store.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.Equal("Draft", note.Title)
+	assert.Equal("First draft", note.Body)
+	revision, err := revisionStore.ReadRevision(context.Background(), noteID, userID, 3)
+	assert.Nil(err)
+	assert.Equal("Final", revision.Title)
+	assert.Equal("Third draft", revision.Body)
+}
+
+func testIdempotencyKeys(t *testing.T, store notestore.NoteStore) {
+	keyStore, ok := store.(notestore.IdempotencyStore)
+	if !ok {
+		t.Skip("The store does not implement notestore.IdempotencyStore")
+	}
+	userID := newUserID()
+
+	assert := assert.New(t)
+	_, reserved, err := keyStore.ReserveIdempotencyKey(context.Background(), userID, "key-1", "hash-1", time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+	assert.Nil(err)
+	assert.True(reserved)
+
+	// The key is in progress until its response is saved
+	resp, reserved, err := keyStore.ReserveIdempotencyKey(context.Background(), userID, "key-1", "hash-1", time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+	assert.Nil(err)
+	assert.False(reserved)
+	assert.Equal(0, resp.Status)
+
+	err = keyStore.SaveIdempotentResponse(context.Background(), notestore.IdempotentResponse{
+		UserID: userID, Key: "key-1", Status: 201, ContentType: "application/json", Body: []byte(`{"msg":"created"}`),
+	})
+	assert.Nil(err)
+	resp, reserved, err = keyStore.ReserveIdempotencyKey(context.Background(), userID, "key-1", "hash-1", time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+	assert.Nil(err)
+	assert.False(reserved)
+	assert.Equal("hash-1", resp.RequestHash)
+	assert.Equal(201, resp.Status)
+	assert.Equal(`{"msg":"created"}`, string(resp.Body))
+
+	// Keys are per user
+	_, reserved, err = keyStore.ReserveIdempotencyKey(context.Background(), newUserID(), "key-1", "hash-2", time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+	assert.Nil(err)
+	assert.True(reserved)
+
+	// Saved responses are kept until they expire
+	err = keyStore.ReleaseIdempotencyKey(context.Background(), userID, "key-1")
+	assert.Nil(err)
+	_, reserved, err = keyStore.ReserveIdempotencyKey(context.Background(), userID, "key-1", "hash-1", time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+	assert.Nil(err)
+	assert.False(reserved)
+	_, reserved, err = keyStore.ReserveIdempotencyKey(context.Background(), userID, "key-1", "hash-3", time.Now().Add(time.Minute), time.Now().Add(-time.Hour))
+	assert.Nil(err)
+	assert.True(reserved)
+
+	// Failed requests are released
+	err = keyStore.ReleaseIdempotencyKey(context.Background(), userID, "key-1")
+	assert.Nil(err)
+	_, reserved, err = keyStore.ReserveIdempotencyKey(context.Background(), userID, "key-1", "hash-4", time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+	assert.Nil(err)
+	assert.True(reserved)
+
+	// Keys in progress are taken over once abandoned, saved responses are kept
+	_, reserved, err = keyStore.ReserveIdempotencyKey(context.Background(), userID, "key-1", "hash-4", time.Now().Add(-time.Hour), time.Now().Add(time.Minute))
+	assert.Nil(err)
+	assert.True(reserved)
+	err = keyStore.SaveIdempotentResponse(context.Background(), notestore.IdempotentResponse{
+		UserID: userID, Key: "key-1", Status: 201, ContentType: "application/json", Body: []byte(`{"msg":"created again"}`),
+	})
+	assert.Nil(err)
+	resp, reserved, err = keyStore.ReserveIdempotencyKey(context.Background(), userID, "key-1", "hash-4", time.Now().Add(-time.Hour), time.Now().Add(time.Minute))
+	assert.Nil(err)
+	assert.False(reserved)
+	assert.Equal(`{"msg":"created again"}`, string(resp.Body))
+}
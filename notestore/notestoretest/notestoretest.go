@@ -0,0 +1,797 @@
+// Package notestoretest is the conformance suite of the note stores. Every
+// implementation of notestore.NoteStore runs it from a test with Run, so that all
+// the stores are verified against the same contract. The tests of the optional
+// interfaces, such as notestore.TagStore, are skipped when the store does not
+// implement them.
+package notestoretest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// Run runs the conformance suite against the store. The store may hold the data of
+// other tests, the suite only uses users of its own.
+func Run(t *testing.T, store notestore.NoteStore) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, store notestore.NoteStore)
+	}{
+		{"CreateNote", testCreateNote},
+		{"ReadNotes", testReadNotes},
+		{"ReadNotesOrder", testReadNotesOrder},
+		{"ReadNotesPagination", testReadNotesPagination},
+		{"ReadNotesDateFilter", testReadNotesDateFilter},
+		{"UpdateNote", testUpdateNote},
+		{"UpdateFields", testUpdateFields},
+		{"DeleteNote", testDeleteNote},
+		{"NotFound", testNotFound},
+		{"Isolation", testIsolation},
+		{"ConcurrentWriters", testConcurrentWriters},
+		{"Versions", testVersions},
+		{"Todos", testTodos},
+		{"RecurringTodos", testRecurringTodos},
+		{"SearchNotes", testSearchNotes},
+		{"Batch", testBatch},
+		{"Tags", testTags},
+		{"Items", testItems},
+		{"Projects", testProjects},
+		{"Trash", testTrash},
+		{"Revisions", testRevisions},
+		{"IdempotencyKeys", testIdempotencyKeys},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, store)
+		})
+	}
+}
+
+// newUserID returns the ID of a user without notes
+func newUserID() string {
+	return "user_" + uuid.New().String()
+}
+
+// createNotes creates the notes one after the other, so that they are ordered by creation
+func createNotes(t *testing.T, store notestore.NoteStore, notes ...notestore.Note) {
+	for _, note := range notes {
+		err := store.Create(context.Background(), note)
+		assert.Nil(t, err, note.Title)
+		// The creation times of the notes differ with the precision of any store
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+// noteIDs returns the IDs of the notes
+func noteIDs(notes []notestore.Note) []string {
+	ids := []string{}
+	for _, note := range notes {
+		ids = append(ids, note.ID)
+	}
+	return ids
+}
+
+func testCreateNote(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	note := notestore.Note{
+		ID:       uuid.New().String(),
+		Title:    "Note 1",
+		Body:     "This is a sample Note 1",
+		UserID:   userID,
+		Tags:     []string{"Work", "home", "work"},
+		Priority: notestore.PriorityLow,
+		DueDate:  "2021-10-31",
+	}
+
+	assert := assert.New(t)
+	err := store.Create(context.Background(), note)
+	assert.Nil(err)
+
+	read, err := store.Read(context.Background(), note.ID, userID)
+	assert.Nil(err)
+	assert.Equal(note.ID, read.ID)
+	assert.Equal(note.Title, read.Title)
+	assert.Equal(note.Body, read.Body)
+	assert.Equal(userID, read.UserID)
+	assert.Equal([]string{"home", "work"}, read.Tags)
+	assert.Equal(notestore.StatusOpen, read.Status)
+	assert.Equal(notestore.PriorityLow, read.Priority)
+	assert.Empty(read.CompletedAt)
+	assert.Empty(read.DeletedAt)
+	assert.Equal(1, read.Version)
+	due, err := time.Parse(time.RFC3339Nano, read.DueDate)
+	assert.Nil(err)
+	assert.True(due.Equal(time.Date(2021, 10, 31, 0, 0, 0, 0, time.UTC)))
+	created, err := time.Parse(time.RFC3339Nano, read.CreatedAt)
+	assert.Nil(err)
+	assert.WithinDuration(time.Now(), created, time.Minute)
+	assert.Equal(read.CreatedAt, read.UpdatedAt)
+
+	// Notes without tags have an empty list of tags
+	noTags := notestore.Note{ID: uuid.New().String(), Title: "Note 2", UserID: userID, Status: notestore.StatusDone}
+	err = store.Create(context.Background(), noTags)
+	assert.Nil(err)
+	read, err = store.Read(context.Background(), noTags.ID, userID)
+	assert.Nil(err)
+	assert.Equal([]string{}, read.Tags)
+	assert.NotEmpty(read.CompletedAt)
+
+	err = store.Create(context.Background(), notestore.Note{ID: uuid.New().String(), Title: "Invalid", UserID: userID, Status: "later"})
+	assert.EqualError(err, "invalid status 'later'")
+	assert.ErrorIs(err, notestore.ErrValidation)
+	err = store.Create(context.Background(), notestore.Note{ID: uuid.New().String(), Title: "Invalid", UserID: userID, Tags: []string{"a/b"}})
+	assert.ErrorIs(err, notestore.ErrValidation)
+}
+
+func testReadNotes(t *testing.T, store notestore.NoteStore) {
+	userID1, userID2 := newUserID(), newUserID()
+	notes1 := []notestore.Note{
+		{ID: uuid.New().String(), Title: "Sample note 1", Body: "This is a sample Note to be tested - 1", UserID: userID1},
+		{ID: uuid.New().String(), Title: "Sample note 2", Body: "This is a sample Note to be tested - 2", UserID: userID1},
+	}
+	notes2 := []notestore.Note{
+		{ID: uuid.New().String(), Title: "Sample note 3", Body: "This is a sample Note to be tested - 3", UserID: userID2},
+	}
+	createNotes(t, store, append(notes1, notes2...)...)
+
+	assert := assert.New(t)
+	page, err := store.ReadAll(context.Background(), userID1, notestore.ReadAllOptions{})
+	assert.Nil(err)
+	assert.Equal(noteIDs(notes1), noteIDs(page.Notes))
+	for i, note := range page.Notes {
+		assert.Equal(notes1[i].Title, note.Title)
+		assert.Equal(notes1[i].Body, note.Body)
+		assert.Equal(userID1, note.UserID)
+	}
+	assert.Empty(page.NextCursor)
+	assert.Empty(page.PrevCursor)
+
+	page, err = store.ReadAll(context.Background(), userID2, notestore.ReadAllOptions{})
+	assert.Nil(err)
+	assert.Equal(noteIDs(notes2), noteIDs(page.Notes))
+
+	// Users without notes get an empty page
+	page, err = store.ReadAll(context.Background(), newUserID(), notestore.ReadAllOptions{})
+	assert.Nil(err)
+	assert.NotNil(page.Notes)
+	assert.Empty(page.Notes)
+
+	_, err = store.ReadAll(context.Background(), userID1, notestore.ReadAllOptions{SortBy: "priority"})
+	assert.ErrorIs(err, notestore.ErrValidation)
+	_, err = store.ReadAll(context.Background(), userID1, notestore.ReadAllOptions{Cursor: "not a cursor"})
+	assert.ErrorIs(err, notestore.ErrValidation)
+}
+
+func testReadNotesOrder(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	notes := []notestore.Note{
+		{ID: uuid.New().String(), Title: "b", UserID: userID},
+		{ID: uuid.New().String(), Title: "c", UserID: userID},
+		{ID: uuid.New().String(), Title: "a", UserID: userID},
+	}
+	createNotes(t, store, notes...)
+
+	// Updating a note moves it to the end of the update order
+	assert := assert.New(t)
+	err := store.Update(context.Background(), notestore.Note{ID: notes[0].ID, Title: "b", Body: "updated", UserID: userID})
+	assert.Nil(err)
+
+	var testCases = []struct {
+		description string
+		opts        notestore.ReadAllOptions
+		expectedIDs []string
+	}{
+		{
+			description: "Creation order",
+			opts:        notestore.ReadAllOptions{},
+			expectedIDs: []string{notes[0].ID, notes[1].ID, notes[2].ID},
+		},
+		{
+			description: "Creation order descending",
+			opts:        notestore.ReadAllOptions{Order: notestore.Descending},
+			expectedIDs: []string{notes[2].ID, notes[1].ID, notes[0].ID},
+		},
+		{
+			description: "Update order",
+			opts:        notestore.ReadAllOptions{SortBy: notestore.SortByUpdated},
+			expectedIDs: []string{notes[1].ID, notes[2].ID, notes[0].ID},
+		},
+		{
+			description: "Title order",
+			opts:        notestore.ReadAllOptions{SortBy: notestore.SortByTitle},
+			expectedIDs: []string{notes[2].ID, notes[0].ID, notes[1].ID},
+		},
+	}
+
+	for _, testCase := range testCases {
+		page, err := store.ReadAll(context.Background(), userID, testCase.opts)
+		assert.Nil(err, testCase.description)
+		assert.Equal(testCase.expectedIDs, noteIDs(page.Notes), testCase.description)
+	}
+}
+
+func testReadNotesPagination(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	for _, title := range []string{"e", "c", "a", "d", "b"} {
+		createNotes(t, store, notestore.Note{
+			ID:     uuid.New().String(),
+			Title:  title,
+			Body:   "Pagination note " + title,
+			UserID: userID,
+		})
+	}
+
+	var testCases = []struct {
+		description    string
+		opts           notestore.ReadAllOptions
+		expectedTitles [][]string
+	}{
+		{
+			description:    "Creation order, two per page",
+			opts:           notestore.ReadAllOptions{Limit: 2},
+			expectedTitles: [][]string{{"e", "c"}, {"a", "d"}, {"b"}},
+		},
+		{
+			description:    "Title ascending, three per page",
+			opts:           notestore.ReadAllOptions{Limit: 3, SortBy: notestore.SortByTitle},
+			expectedTitles: [][]string{{"a", "b", "c"}, {"d", "e"}},
+		},
+		{
+			description:    "Title descending, two per page",
+			opts:           notestore.ReadAllOptions{Limit: 2, SortBy: notestore.SortByTitle, Order: notestore.Descending},
+			expectedTitles: [][]string{{"e", "d"}, {"c", "b"}, {"a"}},
+		},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		// Walk forward through every page
+		var pages []notestore.Page
+		opts := testCase.opts
+		for {
+			page, err := store.ReadAll(context.Background(), userID, opts)
+			assert.Nil(err, testCase.description)
+			pages = append(pages, page)
+			if page.NextCursor == "" || len(pages) > len(testCase.expectedTitles) {
+				break
+			}
+			opts.Cursor = page.NextCursor
+		}
+
+		if !assert.Equal(len(testCase.expectedTitles), len(pages), testCase.description) {
+			continue
+		}
+		for i, page := range pages {
+			var titles []string
+			for _, note := range page.Notes {
+				titles = append(titles, note.Title)
+			}
+			assert.Equal(testCase.expectedTitles[i], titles, testCase.description)
+		}
+		assert.Empty(pages[0].PrevCursor, testCase.description)
+
+		// Walk back from the last page to the first one
+		last := len(pages) - 1
+		opts.Cursor = pages[last].PrevCursor
+		page, err := store.ReadAll(context.Background(), userID, opts)
+		assert.Nil(err, testCase.description)
+		assert.Equal(pages[last-1].Notes, page.Notes, testCase.description)
+	}
+}
+
+func testReadNotesDateFilter(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	createNotes(t, store, notestore.Note{
+		ID:     uuid.New().String(),
+		Title:  "Date filter note",
+		Body:   "This note is filtered by date",
+		UserID: userID,
+	})
+
+	var testCases = []struct {
+		description   string
+		opts          notestore.ReadAllOptions
+		expectedCount int
+	}{
+		{
+			description:   "Created after an hour ago",
+			opts:          notestore.ReadAllOptions{CreatedAfter: time.Now().Add(-time.Hour)},
+			expectedCount: 1,
+		},
+		{
+			description:   "Created before an hour ago",
+			opts:          notestore.ReadAllOptions{CreatedBefore: time.Now().Add(-time.Hour)},
+			expectedCount: 0,
+		},
+		{
+			description:   "Updated after an hour from now",
+			opts:          notestore.ReadAllOptions{UpdatedAfter: time.Now().Add(time.Hour)},
+			expectedCount: 0,
+		},
+		{
+			description:   "Created after half an hour ago, given with a positive offset",
+			opts:          notestore.ReadAllOptions{CreatedAfter: time.Now().Add(-30 * time.Minute).In(time.FixedZone("+02:00", 2*60*60))},
+			expectedCount: 1,
+		},
+		{
+			description:   "Created before half an hour from now, given with a negative offset",
+			opts:          notestore.ReadAllOptions{CreatedBefore: time.Now().Add(30 * time.Minute).In(time.FixedZone("-07:00", -7*60*60))},
+			expectedCount: 1,
+		},
+		{
+			description:   "Notes without due date are left out of due date filters",
+			opts:          notestore.ReadAllOptions{DueBefore: time.Now().Add(time.Hour)},
+			expectedCount: 0,
+		},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		page, err := store.ReadAll(context.Background(), userID, testCase.opts)
+		assert.Nil(err, testCase.description)
+		assert.Len(page.Notes, testCase.expectedCount, testCase.description)
+	}
+}
+
+func testUpdateNote(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	note := notestore.Note{ID: uuid.New().String(), Title: "Update Note 1", Body: "This is a sample Note to be updated.",
+		UserID: userID, Tags: []string{"work"}}
+	createNotes(t, store, note)
+
+	assert := assert.New(t)
+	err := store.Update(context.Background(), notestore.Note{ID: note.ID, Title: "Updated Note 1", Body: "This note is updated",
+		UserID: userID, Priority: notestore.PriorityHigh})
+	assert.Nil(err)
+
+	read, err := store.Read(context.Background(), note.ID, userID)
+	assert.Nil(err)
+	assert.Equal("Updated Note 1", read.Title)
+	assert.Equal("This note is updated", read.Body)
+	assert.Equal(notestore.PriorityHigh, read.Priority)
+	// The tags are not changed by an update
+	assert.Equal([]string{"work"}, read.Tags)
+	assert.Equal(2, read.Version)
+	created, _ := time.Parse(time.RFC3339Nano, read.CreatedAt)
+	updated, _ := time.Parse(time.RFC3339Nano, read.UpdatedAt)
+	assert.True(updated.After(created))
+
+	err = store.Update(context.Background(), notestore.Note{ID: note.ID, Title: "Invalid", UserID: userID, Priority: 7})
+	assert.ErrorIs(err, notestore.ErrValidation)
+}
+
+func testUpdateFields(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	noteID := uuid.New().String()
+
+	assert := assert.New(t)
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Groceries", Body: "Milk, eggs", UserID: userID, Priority: 2})
+	assert.Nil(err)
+
+	// Fields that are not listed are left unchanged
+	err = store.UpdateFields(context.Background(), notestore.Note{ID: noteID, UserID: userID, Title: "Shopping"},
+		[]notestore.NoteField{notestore.FieldTitle})
+	assert.Nil(err)
+	note, err := store.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.Equal("Shopping", note.Title)
+	assert.Equal("Milk, eggs", note.Body)
+	assert.Equal(2, note.Priority)
+	assert.Equal(2, note.Version)
+
+	err = store.UpdateFields(context.Background(), notestore.Note{ID: noteID, UserID: userID, Version: 1},
+		[]notestore.NoteField{notestore.FieldPriority})
+	assert.Equal(notestore.ErrVersionMismatch, err)
+	err = store.UpdateFields(context.Background(), notestore.Note{ID: noteID, UserID: userID, Recurrence: "FREQ=DAILY"},
+		[]notestore.NoteField{notestore.FieldRecurrence})
+	assert.ErrorIs(err, notestore.ErrValidation)
+}
+
+func testDeleteNote(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	notes := []notestore.Note{
+		{ID: uuid.New().String(), Title: "Delete Note 1", Body: "This is a sample Note to be deleted.", UserID: userID},
+		{ID: uuid.New().String(), Title: "Delete Note 2", Body: "This is a sample Note to be kept.", UserID: userID},
+	}
+	createNotes(t, store, notes...)
+
+	assert := assert.New(t)
+	err := store.Delete(context.Background(), notes[0].ID, userID, 0)
+	assert.Nil(err)
+
+	_, err = store.Read(context.Background(), notes[0].ID, userID)
+	assert.EqualError(err, fmt.Sprintf("Note '%s' is not found", notes[0].ID))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+	page, err := store.ReadAll(context.Background(), userID, notestore.ReadAllOptions{})
+	assert.Nil(err)
+	assert.Equal([]string{notes[1].ID}, noteIDs(page.Notes))
+
+	err = store.Delete(context.Background(), notes[0].ID, userID, 0)
+	assert.EqualError(err, fmt.Sprintf("Note '%s' is not found", notes[0].ID))
+	assert.ErrorIs(err, notestore.ErrNotFound)
+}
+
+// testNotFound checks that every method of the store fails with the same not found
+// error for unknown notes
+func testNotFound(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	noteID := uuid.New().String()
+	message := fmt.Sprintf("Note '%s' is not found", noteID)
+	title := []notestore.NoteField{notestore.FieldTitle}
+
+	calls := map[string]func() error{
+		"Read": func() error {
+			_, err := store.Read(context.Background(), noteID, userID)
+			return err
+		},
+		"Update": func() error {
+			return store.Update(context.Background(), notestore.Note{ID: noteID, Title: "Unknown", UserID: userID})
+		},
+		"Update with version": func() error {
+			return store.Update(context.Background(), notestore.Note{ID: noteID, Title: "Unknown", UserID: userID, Version: 1})
+		},
+		"UpdateFields": func() error {
+			return store.UpdateFields(context.Background(), notestore.Note{ID: noteID, Title: "Unknown", UserID: userID}, title)
+		},
+		"Delete": func() error {
+			return store.Delete(context.Background(), noteID, userID, 0)
+		},
+		"Delete with version": func() error {
+			return store.Delete(context.Background(), noteID, userID, 1)
+		},
+		"Complete": func() error {
+			_, err := store.Complete(context.Background(), noteID, userID)
+			return err
+		},
+	}
+
+	assert := assert.New(t)
+	for name, call := range calls {
+		err := call()
+		assert.EqualError(err, message, name)
+		assert.ErrorIs(err, notestore.ErrNotFound, name)
+	}
+}
+
+// testIsolation checks that the notes of a user are not accessible to the other users
+func testIsolation(t *testing.T, store notestore.NoteStore) {
+	owner, other := newUserID(), newUserID()
+	note := notestore.Note{ID: uuid.New().String(), Title: "Private release plan", Body: "Owned by a single user", UserID: owner}
+	createNotes(t, store, note)
+	message := fmt.Sprintf("Note '%s' is not found", note.ID)
+
+	assert := assert.New(t)
+	_, err := store.Read(context.Background(), note.ID, other)
+	assert.EqualError(err, message)
+	err = store.Update(context.Background(), notestore.Note{ID: note.ID, Title: "Stolen", UserID: other})
+	assert.EqualError(err, message)
+	err = store.UpdateFields(context.Background(), notestore.Note{ID: note.ID, Title: "Stolen", UserID: other},
+		[]notestore.NoteField{notestore.FieldTitle})
+	assert.EqualError(err, message)
+	_, err = store.Complete(context.Background(), note.ID, other)
+	assert.EqualError(err, message)
+	err = store.Delete(context.Background(), note.ID, other, 0)
+	assert.EqualError(err, message)
+	results, err := store.Batch(context.Background(), other, []notestore.BatchOperation{
+		{Action: notestore.BatchDelete, Note: notestore.Note{ID: note.ID}},
+	}, false)
+	assert.Nil(err)
+	assert.EqualError(results[0].Err, message)
+
+	page, err := store.ReadAll(context.Background(), other, notestore.ReadAllOptions{})
+	assert.Nil(err)
+	assert.Empty(page.Notes)
+	found, err := store.Search(context.Background(), other, "release", 0)
+	assert.Nil(err)
+	assert.Empty(found)
+
+	// The note is unchanged
+	read, err := store.Read(context.Background(), note.ID, owner)
+	assert.Nil(err)
+	assert.Equal(note.Title, read.Title)
+	assert.Equal(notestore.StatusOpen, read.Status)
+	assert.Equal(1, read.Version)
+}
+
+// testConcurrentWriters checks that concurrent updates are neither lost nor applied twice
+func testConcurrentWriters(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	noteID := uuid.New().String()
+	createNotes(t, store, notestore.Note{ID: noteID, Title: "Counter", UserID: userID})
+	const writers = 8
+
+	// Every writer reads the note and updates it at the version it read until it succeeds
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for {
+				note, err := store.Read(context.Background(), noteID, userID)
+				if !assert.Nil(t, err) {
+					return
+				}
+				note.Body += fmt.Sprintf("[%d]", i)
+				err = store.UpdateFields(context.Background(), note, []notestore.NoteField{notestore.FieldBody})
+				if err != notestore.ErrVersionMismatch {
+					assert.Nil(t, err)
+					return
+				}
+			}
+		}(i)
+	}
+
+	// Notes are created concurrently too
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := store.Create(context.Background(), notestore.Note{ID: uuid.New().String(), Title: fmt.Sprintf("Note %d", i), UserID: userID})
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	assert := assert.New(t)
+	note, err := store.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.Equal(writers+1, note.Version)
+	for i := 0; i < writers; i++ {
+		assert.Contains(note.Body, fmt.Sprintf("[%d]", i))
+	}
+	page, err := store.ReadAll(context.Background(), userID, notestore.ReadAllOptions{})
+	assert.Nil(err)
+	assert.Len(page.Notes, writers+1)
+}
+
+func testTodos(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	yesterday := time.Now().AddDate(0, 0, -1).UTC().Format(time.RFC3339)
+	tomorrow := time.Now().AddDate(0, 0, 1).UTC().Format(time.RFC3339)
+	overdueID := uuid.New().String()
+	upcomingID := uuid.New().String()
+	doneID := uuid.New().String()
+
+	assert := assert.New(t)
+	createNotes(t, store,
+		notestore.Note{ID: overdueID, Title: "Overdue", UserID: userID, Priority: notestore.PriorityHigh, DueDate: yesterday},
+		notestore.Note{ID: upcomingID, Title: "Upcoming", UserID: userID, Status: notestore.StatusInProgress, DueDate: tomorrow},
+		notestore.Note{ID: doneID, Title: "Done late", UserID: userID, DueDate: yesterday},
+	)
+
+	note, err := store.Read(context.Background(), overdueID, userID)
+	assert.Nil(err)
+	assert.Equal(notestore.StatusOpen, note.Status)
+	assert.Equal(notestore.PriorityHigh, note.Priority)
+	assert.NotEmpty(note.DueDate)
+	assert.Empty(note.CompletedAt)
+
+	next, err := store.Complete(context.Background(), doneID, userID)
+	assert.Nil(err)
+	assert.Empty(next.ID)
+	note, err = store.Read(context.Background(), doneID, userID)
+	assert.Nil(err)
+	assert.Equal(notestore.StatusDone, note.Status)
+	assert.NotEmpty(note.CompletedAt)
+	assert.Equal(2, note.Version)
+
+	page, err := store.ReadAll(context.Background(), userID, notestore.ReadAllOptions{Due: notestore.DueOverdue})
+	assert.Nil(err)
+	assert.Equal([]string{overdueID}, noteIDs(page.Notes))
+
+	page, err = store.ReadAll(context.Background(), userID, notestore.ReadAllOptions{Statuses: []notestore.Status{notestore.StatusInProgress}})
+	assert.Nil(err)
+	assert.Equal([]string{upcomingID}, noteIDs(page.Notes))
+
+	// Reopening a note clears its completion time
+	note.Status = notestore.StatusOpen
+	err = store.Update(context.Background(), note)
+	assert.Nil(err)
+	note, err = store.Read(context.Background(), doneID, userID)
+	assert.Nil(err)
+	assert.Equal(notestore.StatusOpen, note.Status)
+	assert.Empty(note.CompletedAt)
+}
+
+func testRecurringTodos(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	noteID := uuid.New().String()
+
+	assert := assert.New(t)
+	err := store.Create(context.Background(), notestore.Note{
+		ID:         noteID,
+		Title:      "Water the plants",
+		UserID:     userID,
+		Tags:       []string{"home"},
+		DueDate:    "2021-10-18T08:00:00Z",
+		Recurrence: "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=2",
+	})
+	assert.Nil(err)
+	items, hasItems := store.(notestore.ItemStore)
+	if hasItems {
+		err = items.CreateItem(context.Background(), userID, notestore.Item{ID: uuid.New().String(), NoteID: noteID, Text: "Balcony", Checked: true})
+		assert.Nil(err)
+	}
+
+	// Only completing the note sets it to done, as it creates the next occurrence
+	err = store.Update(context.Background(), notestore.Note{ID: noteID, Title: "Water the plants", UserID: userID,
+		Status: notestore.StatusDone, DueDate: "2021-10-18T08:00:00Z", Recurrence: "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=2"})
+	assert.True(errors.Is(err, notestore.ErrValidation), err)
+	err = store.UpdateFields(context.Background(), notestore.Note{ID: noteID, UserID: userID, Status: notestore.StatusDone},
+		[]notestore.NoteField{notestore.FieldStatus})
+	assert.True(errors.Is(err, notestore.ErrValidation), err)
+
+	next, err := store.Complete(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.NotEmpty(next.ID)
+	assert.Equal("Water the plants", next.Title)
+	assert.Equal(notestore.StatusOpen, next.Status)
+	assert.Equal([]string{"home"}, next.Tags)
+	assert.Equal(2, next.Occurrence)
+	assert.Equal(1, next.Version)
+	due, err := time.Parse(time.RFC3339, next.DueDate)
+	assert.Nil(err)
+	assert.True(due.Equal(time.Date(2021, 10, 21, 8, 0, 0, 0, time.UTC)))
+
+	// The checklist is copied unchecked
+	if hasItems {
+		copied, err := items.ListItems(context.Background(), next.ID, userID)
+		assert.Nil(err)
+		if assert.Len(copied, 1) {
+			assert.Equal("Balcony", copied[0].Text)
+			assert.False(copied[0].Checked)
+		}
+	}
+
+	// Completing again does not spawn another occurrence
+	again, err := store.Complete(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.Empty(again.ID)
+	// The completed note stays done through its updates
+	err = store.UpdateFields(context.Background(), notestore.Note{ID: noteID, UserID: userID, Title: "Watered the plants"},
+		[]notestore.NoteField{notestore.FieldTitle})
+	assert.Nil(err)
+
+	// The series ends after COUNT occurrences
+	last, err := store.Complete(context.Background(), next.ID, userID)
+	assert.Nil(err)
+	assert.Empty(last.ID)
+}
+
+func testSearchNotes(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	notes := []notestore.Note{
+		{ID: uuid.New().String(), Title: "Release checklist", Body: "Deploy the service and update the changelog", UserID: userID},
+		{ID: uuid.New().String(), Title: "Groceries", Body: "Milk, eggs and a check list for the party", UserID: userID},
+		{ID: uuid.New().String(), Title: "Draft release notes", Body: "Deployment notes for the next release", UserID: userID},
+		{ID: uuid.New().String(), Title: "Release checklist", Body: "Belongs to another user", UserID: newUserID()},
+	}
+	createNotes(t, store, notes...)
+
+	var testCases = []struct {
+		description string
+		query       string
+		expectedIDs []string
+	}{
+		{
+			description: "Word",
+			query:       "release",
+			expectedIDs: []string{notes[0].ID, notes[2].ID},
+		},
+		{
+			description: "Phrase",
+			query:       `"check list"`,
+			expectedIDs: []string{notes[1].ID},
+		},
+		{
+			description: "Prefix",
+			query:       "deploy*",
+			expectedIDs: []string{notes[0].ID, notes[2].ID},
+		},
+		{
+			description: "Negated term",
+			query:       "release -draft",
+			expectedIDs: []string{notes[0].ID},
+		},
+		{
+			description: "No match",
+			query:       "holidays",
+			expectedIDs: []string{},
+		},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		results, err := store.Search(context.Background(), userID, testCase.query, 0)
+		assert.Nil(err, testCase.description)
+
+		ids := []string{}
+		for _, result := range results {
+			ids = append(ids, result.Note.ID)
+		}
+		assert.ElementsMatch(testCase.expectedIDs, ids, testCase.description)
+	}
+
+	results, err := store.Search(context.Background(), userID, "changelog", 0)
+	assert.Nil(err)
+	if assert.Len(results, 1) {
+		assert.Contains(results[0].BodySnippet, "<mark>changelog</mark>")
+	}
+	results, err = store.Search(context.Background(), userID, "release", 1)
+	assert.Nil(err)
+	assert.Len(results, 1)
+
+	_, err = store.Search(context.Background(), userID, "-draft", 0)
+	assert.ErrorIs(err, notestore.ErrValidation)
+}
+
+func testBatch(t *testing.T, store notestore.NoteStore) {
+	userID := newUserID()
+	noteID := uuid.New().String()
+
+	assert := assert.New(t)
+	err := store.Create(context.Background(), notestore.Note{ID: noteID, Title: "Groceries", UserID: userID})
+	assert.Nil(err)
+
+	// An atomic batch is rolled back when an operation fails
+	createdID := uuid.New().String()
+	results, err := store.Batch(context.Background(), userID, []notestore.BatchOperation{
+		{Action: notestore.BatchCreate, Note: notestore.Note{ID: createdID, Title: "Laundry"}},
+		{Action: notestore.BatchUpdate, Note: notestore.Note{ID: noteID, Title: "Shopping"},
+			Fields: []notestore.NoteField{notestore.FieldTitle}},
+		{Action: notestore.BatchUpdate, Note: notestore.Note{ID: noteID, Title: "Errands", Version: 5},
+			Fields: []notestore.NoteField{notestore.FieldTitle}},
+	}, true)
+	assert.Nil(err)
+	if assert.Len(results, 3) {
+		assert.Equal(createdID, results[0].NoteID)
+		assert.Equal(notestore.ErrBatchAborted, results[0].Err)
+		assert.Equal(notestore.ErrBatchAborted, results[1].Err)
+		assert.Equal(notestore.ErrVersionMismatch, results[2].Err)
+	}
+	_, err = store.Read(context.Background(), createdID, userID)
+	assert.ErrorIs(err, notestore.ErrNotFound)
+	note, err := store.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.Equal("Groceries", note.Title)
+	assert.Equal(1, note.Version)
+
+	// A best effort batch only skips the failed operations
+	results, err = store.Batch(context.Background(), userID, []notestore.BatchOperation{
+		{Action: notestore.BatchCreate, Note: notestore.Note{ID: createdID, Title: "Laundry"}},
+		{Action: notestore.BatchDelete, Note: notestore.Note{ID: uuid.New().String()}},
+		{Action: notestore.BatchUpdate, Note: notestore.Note{ID: noteID, Title: "Shopping", Version: 1},
+			Fields: []notestore.NoteField{notestore.FieldTitle}},
+	}, false)
+	assert.Nil(err)
+	if assert.Len(results, 3) {
+		assert.Nil(results[0].Err)
+		assert.ErrorIs(results[1].Err, notestore.ErrNotFound)
+		assert.Nil(results[2].Err)
+	}
+	note, err = store.Read(context.Background(), createdID, userID)
+	assert.Nil(err)
+	assert.Equal("Laundry", note.Title)
+	assert.Equal(userID, note.UserID)
+	note, err = store.Read(context.Background(), noteID, userID)
+	assert.Nil(err)
+	assert.Equal("Shopping", note.Title)
+
+	results, err = store.Batch(context.Background(), userID, []notestore.BatchOperation{
+		{Action: notestore.BatchDelete, Note: notestore.Note{ID: createdID, Version: 1}},
+	}, true)
+	assert.Nil(err)
+	assert.Nil(results[0].Err)
+	_, err = store.Read(context.Background(), createdID, userID)
+	assert.ErrorIs(err, notestore.ErrNotFound)
+}
@@ -0,0 +1,189 @@
+package notestore
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// NoteField is a field of a note that can be updated with UpdateFields, named
+// like the fields of the note requests
+type NoteField string
+
+// Fields of a note that can be updated with UpdateFields
+const (
+	FieldTitle      NoteField = "title"
+	FieldBody       NoteField = "body"
+	FieldStatus     NoteField = "status"
+	FieldPriority   NoteField = "priority"
+	FieldDueDate    NoteField = "due_date"
+	FieldRecurrence NoteField = "recurrence"
+)
+
+// readOnlyFields are the fields of a note that cannot be patched, the tags and
+// project have their own endpoints
+var readOnlyFields = map[string]bool{
+	"id": true, "user_id": true, "created_at": true, "updated_at": true, "completed_at": true,
+	"occurrence": true, "deleted_at": true, "version": true, "tags": true, "project_id": true,
+}
+
+// MergeFields returns the current note with the fields listed in fields taken from patch
+func MergeFields(current, patch Note, fields []NoteField) Note {
+	for _, field := range fields {
+		switch field {
+		case FieldTitle:
+			current.Title = patch.Title
+		case FieldBody:
+			current.Body = patch.Body
+		case FieldStatus:
+			current.Status = patch.Status
+		case FieldPriority:
+			current.Priority = patch.Priority
+		case FieldDueDate:
+			current.DueDate = patch.DueDate
+		case FieldRecurrence:
+			current.Recurrence = patch.Recurrence
+		}
+	}
+	return current
+}
+
+// ApplyMergePatch applies an RFC 7396 JSON merge patch such as
+//   {"title": "New title", "due_date": null}
+// to the note and returns the patched fields. A null value resets the field to
+// its default.
+func ApplyMergePatch(note Note, patch []byte) (Note, []NoteField, error) {
+	var values map[string]json.RawMessage
+	err := json.Unmarshal(patch, &values)
+	if err != nil || values == nil {
+		return note, nil, Invalidf("merge patch must be a JSON object")
+	}
+
+	var fields []NoteField
+	for name, value := range values {
+		field, err := setField(&note, name, value)
+		if err != nil {
+			return note, nil, err
+		}
+		fields = append(fields, field)
+	}
+	return note, fields, nil
+}
+
+// jsonPatchOperation is an operation of an RFC 6902 JSON patch
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ApplyJSONPatch applies an RFC 6902 JSON patch such as
+//   [{"op": "test", "path": "/status", "value": "open"}, {"op": "replace", "path": "/title", "value": "New title"}]
+// to the note and returns the patched fields. The add, replace, remove and test
+// operations are supported on the top-level fields, remove resets a field to its default.
+func ApplyJSONPatch(note Note, patch []byte) (Note, []NoteField, error) {
+	var operations []jsonPatchOperation
+	err := json.Unmarshal(patch, &operations)
+	if err != nil {
+		return note, nil, Invalidf("JSON patch must be an array of operations")
+	}
+
+	var fields []NoteField
+	for _, operation := range operations {
+		name := strings.TrimPrefix(operation.Path, "/")
+		if !strings.HasPrefix(operation.Path, "/") || strings.Contains(name, "/") {
+			return note, nil, Invalidf("invalid path '%s'", operation.Path)
+		}
+
+		switch operation.Op {
+		case "add", "replace":
+			if operation.Value == nil {
+				return note, nil, Invalidf("'%s' operation on '%s' has no value", operation.Op, operation.Path)
+			}
+			field, err := setField(&note, name, operation.Value)
+			if err != nil {
+				return note, nil, err
+			}
+			fields = append(fields, field)
+		case "remove":
+			field, err := setField(&note, name, json.RawMessage("null"))
+			if err != nil {
+				return note, nil, err
+			}
+			fields = append(fields, field)
+		case "test":
+			var expected Note
+			_, err := setField(&expected, name, operation.Value)
+			if err != nil {
+				return note, nil, err
+			}
+			// The patch is valid but the note is not as expected, see RFC 5789 section 2.2
+			if !bytes.Equal(fieldJSON(note, name), fieldJSON(expected, name)) {
+				return note, nil, Conflictf("test of '%s' failed", operation.Path)
+			}
+		default:
+			return note, nil, Invalidf("unsupported JSON patch operation '%s'", operation.Op)
+		}
+	}
+	return note, fields, nil
+}
+
+// setField sets a field of the note to a JSON value, null resets the field
+func setField(note *Note, name string, value json.RawMessage) (NoteField, error) {
+	if readOnlyFields[name] {
+		return "", Invalidf("field '%s' is read-only", name)
+	}
+
+	field := NoteField(name)
+	var dst interface{}
+	switch field {
+	case FieldTitle:
+		dst = &note.Title
+	case FieldBody:
+		dst = &note.Body
+	case FieldStatus:
+		dst = &note.Status
+	case FieldPriority:
+		dst = &note.Priority
+	case FieldDueDate:
+		dst = &note.DueDate
+	case FieldRecurrence:
+		dst = &note.Recurrence
+	default:
+		return "", Invalidf("unknown field '%s'", name)
+	}
+
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 || bytes.Equal(value, []byte("null")) {
+		// Reset the field to the zero value of its type
+		reflect.ValueOf(dst).Elem().Set(reflect.Zero(reflect.TypeOf(dst).Elem()))
+		return field, nil
+	}
+	err := json.Unmarshal(value, dst)
+	if err != nil {
+		return "", Invalidf("invalid value for field '%s': %s", name, err)
+	}
+	return field, nil
+}
+
+// fieldJSON returns the JSON value of a field of the note
+func fieldJSON(note Note, name string) []byte {
+	var v interface{}
+	switch NoteField(name) {
+	case FieldTitle:
+		v = note.Title
+	case FieldBody:
+		v = note.Body
+	case FieldStatus:
+		v = note.Status
+	case FieldPriority:
+		v = note.Priority
+	case FieldDueDate:
+		v = note.DueDate
+	case FieldRecurrence:
+		v = note.Recurrence
+	}
+	b, _ := json.Marshal(v)
+	return b
+}
@@ -0,0 +1,110 @@
+package notestore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	current := Note{ID: "1", Title: "Groceries", Body: "Milk", Status: StatusOpen, Priority: 2, DueDate: "2021-06-01T00:00:00Z"}
+
+	testCases := []struct {
+		description    string
+		patch          string
+		expectedNote   Note
+		expectedFields []NoteField
+		expectedError  string
+	}{
+		{
+			description:    "Only the title",
+			patch:          `{"title": "Shopping"}`,
+			expectedNote:   Note{ID: "1", Title: "Shopping", Body: "Milk", Status: StatusOpen, Priority: 2, DueDate: "2021-06-01T00:00:00Z"},
+			expectedFields: []NoteField{FieldTitle},
+		},
+		{
+			description:    "Null resets",
+			patch:          `{"due_date": null, "priority": null}`,
+			expectedNote:   Note{ID: "1", Title: "Groceries", Body: "Milk", Status: StatusOpen},
+			expectedFields: []NoteField{FieldDueDate, FieldPriority},
+		},
+		{
+			description:  "Empty patch",
+			patch:        `{}`,
+			expectedNote: current,
+		},
+		{description: "Unknown field", patch: `{"colour": "red"}`, expectedError: "unknown field 'colour'"},
+		{description: "Read-only field", patch: `{"user_id": "2"}`, expectedError: "field 'user_id' is read-only"},
+		{description: "Wrong type", patch: `{"priority": "high"}`, expectedError: "invalid value for field 'priority'"},
+		{description: "Not an object", patch: `["title"]`, expectedError: "merge patch must be a JSON object"},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		note, fields, err := ApplyMergePatch(current, []byte(testCase.patch))
+		if testCase.expectedError != "" {
+			if assert.NotNil(err, testCase.description) {
+				assert.Contains(err.Error(), testCase.expectedError, testCase.description)
+			}
+			continue
+		}
+		assert.Nil(err, testCase.description)
+		assert.Equal(testCase.expectedNote, note, testCase.description)
+		assert.ElementsMatch(testCase.expectedFields, fields, testCase.description)
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	current := Note{ID: "1", Title: "Groceries", Body: "Milk", Status: StatusOpen, Recurrence: "FREQ=WEEKLY"}
+
+	testCases := []struct {
+		description    string
+		patch          string
+		expectedNote   Note
+		expectedFields []NoteField
+		expectedError  string
+	}{
+		{
+			description:    "Test and replace",
+			patch:          `[{"op": "test", "path": "/status", "value": "open"}, {"op": "replace", "path": "/body", "value": "Milk, eggs"}]`,
+			expectedNote:   Note{ID: "1", Title: "Groceries", Body: "Milk, eggs", Status: StatusOpen, Recurrence: "FREQ=WEEKLY"},
+			expectedFields: []NoteField{FieldBody},
+		},
+		{
+			description:    "Remove resets",
+			patch:          `[{"op": "remove", "path": "/recurrence"}]`,
+			expectedNote:   Note{ID: "1", Title: "Groceries", Body: "Milk", Status: StatusOpen},
+			expectedFields: []NoteField{FieldRecurrence},
+		},
+		{description: "Failed test", patch: `[{"op": "test", "path": "/title", "value": "Chores"}]`, expectedError: "test of '/title' failed"},
+		{description: "Missing value", patch: `[{"op": "add", "path": "/title"}]`, expectedError: "'add' operation on '/title' has no value"},
+		{description: "Nested path", patch: `[{"op": "replace", "path": "/tags/0", "value": "home"}]`, expectedError: "invalid path '/tags/0'"},
+		{description: "Read-only field", patch: `[{"op": "replace", "path": "/version", "value": 3}]`, expectedError: "field 'version' is read-only"},
+		{description: "Unsupported operation", patch: `[{"op": "move", "from": "/title", "path": "/body"}]`, expectedError: "unsupported JSON patch operation 'move'"},
+		{description: "Not an array", patch: `{"title": "Chores"}`, expectedError: "JSON patch must be an array of operations"},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		note, fields, err := ApplyJSONPatch(current, []byte(testCase.patch))
+		if testCase.expectedError != "" {
+			assert.EqualError(err, testCase.expectedError, testCase.description)
+			continue
+		}
+		assert.Nil(err, testCase.description)
+		assert.Equal(testCase.expectedNote, note, testCase.description)
+		assert.Equal(testCase.expectedFields, fields, testCase.description)
+	}
+
+	// A failed test is a conflict with the current note, not an invalid patch
+	_, _, err := ApplyJSONPatch(current, []byte(`[{"op": "test", "path": "/title", "value": "Chores"}]`))
+	assert.ErrorIs(err, ErrConflict)
+}
+
+func TestMergeFields(t *testing.T) {
+	current := Note{ID: "1", Title: "Groceries", Body: "Milk", Priority: 2, Version: 4}
+	patch := Note{Title: "Shopping", Body: "", Priority: 3}
+
+	note := MergeFields(current, patch, []NoteField{FieldTitle, FieldPriority})
+	assert.Equal(t, Note{ID: "1", Title: "Shopping", Body: "Milk", Priority: 3, Version: 4}, note)
+}
@@ -1,19 +1,20 @@
 package postgres
 
 import (
-	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"local/sidharthjs/todo/notestore"
+	"local/sidharthjs/todo/notestore/sqlstore"
 
 	_ "github.com/jackc/pgx/v4/stdlib"
 )
 
 //DB struct that represents the Client
 type DB struct {
-	*sql.DB
+	*sqlstore.Store
 }
 
 // NewClient returns the DB client and error
@@ -23,98 +24,61 @@ func NewClient(dbURL string) (*DB, error) {
 		return nil, fmt.Errorf("unable to connect to db: %s", err)
 	}
 
-	return &DB{conn}, nil
+	return newDB(conn), nil
 }
 
-//Create creates a note in the DB
-func (db *DB) Create(ctx context.Context, note notestore.Note) error {
-	sql := "INSERT INTO notes(user_id, id, title, body, created_at) VALUES($1, $2, $3, $4, $5);"
-	ct, err := db.ExecContext(ctx, sql, note.UserID, note.ID, note.Title, note.Body, time.Now())
-	if err != nil {
-		return fmt.Errorf("unable to store note '%s': %s", note.ID, err)
-	}
-
-	n, err := ct.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error in getting rows affected: %s", err)
-	}
-	if n == 0 {
-		return fmt.Errorf("rows affected for store note call is 0")
-	}
-	return nil
+// newDB returns the client of an open database
+func newDB(conn *sql.DB) *DB {
+	return &DB{sqlstore.New(conn, dialect{})}
 }
 
-//Read reads a note from the DB
-func (db *DB) Read(ctx context.Context, noteID, userID string) (notestore.Note, error) {
-	sqlQuery := "SELECT id, title, body, user_id, created_at FROM notes WHERE id=$1 and user_id=$2;"
-	row := db.QueryRowContext(ctx, sqlQuery, noteID, userID)
+// dialect is the sqlstore.Dialect of Postgres
+type dialect struct{}
 
-	var note notestore.Note
-	err := row.Scan(&note.ID, &note.Title, &note.Body, &note.UserID, &note.CreatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return notestore.Note{}, fmt.Errorf("Note '%s' is not found", noteID)
-		}
-		return notestore.Note{}, fmt.Errorf("error occurred while retrieving the note: %s", err)
-	}
-
-	return note, nil
+// Time returns the time in UTC, the timestamp columns keep only its wall clock
+func (dialect) Time(t time.Time) interface{} {
+	return t.UTC()
 }
 
-//ReadAll reads all the notes for the given user ID
-func (db *DB) ReadAll(ctx context.Context, userID string) ([]notestore.Note, error) {
-	sql := "SELECT id, title, body, user_id, created_at FROM notes WHERE user_id=$1;"
-	rows, err := db.QueryContext(ctx, sql, userID)
-	if err != nil {
-		return []notestore.Note{}, fmt.Errorf("error occurred while querying the note: %s", err)
-	}
-	defer rows.Close()
-
-	var notes []notestore.Note
-	for rows.Next() {
-		var note notestore.Note
-		err := rows.Scan(&note.ID, &note.Title, &note.Body, &note.UserID, &note.CreatedAt)
-		if err != nil {
-			return []notestore.Note{}, fmt.Errorf("error occurred while scanning the rows: %s", err)
-		}
-		notes = append(notes, note)
-	}
-
-	return notes, nil
+// ForUpdate locks the rows, a concurrent transaction locking them waits until the end of the transaction
+func (dialect) ForUpdate(table string) string {
+	return " FOR UPDATE OF " + table
 }
 
-//Update updates a note
-func (db *DB) Update(ctx context.Context, note notestore.Note) error {
-	sql := "UPDATE notes SET title=$1, body=$2 WHERE id=$3 AND user_id=$4;"
-	ct, err := db.ExecContext(ctx, sql, note.Title, note.Body, note.ID, note.UserID)
-	if err != nil {
-		return fmt.Errorf("unable to update note '%s': %s", note.ID, err)
-	}
-
-	n, err := ct.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error in getting rows affected: %s", err)
-	}
-	if n == 0 {
-		return fmt.Errorf("rows affected for update note call is 0")
-	}
-	return nil
+func (dialect) TagsColumn() string {
+	return `coalesce((SELECT json_agg(t.name ORDER BY t.name) FROM note_tags nt JOIN tags t ON t.id=nt.tag_id
+		WHERE nt.note_s_no=notes.s_no), '[]')`
 }
 
-// Delete deletes a note
-func (db *DB) Delete(ctx context.Context, noteID, userID string) error {
-	sql := "DELETE FROM notes WHERE id=$1 AND user_id=$2;"
-	ct, err := db.ExecContext(ctx, sql, noteID, userID)
-	if err != nil {
-		return fmt.Errorf("unable to delete note '%s': %s", noteID, err)
+// headlineOptions configures the snippets returned by Search
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=25, MinWords=8, MaxFragments=2"
+
+// SearchQuery matches the terms against the search_vector column maintained by a trigger
+func (dialect) SearchQuery(columns string, terms []notestore.SearchTerm) (string, []interface{}) {
+	args := []interface{}{headlineOptions}
+	var parts []string
+	for _, term := range terms {
+		args = append(args, term.Text)
+		part := fmt.Sprintf("plainto_tsquery('english', $%d)", len(args)+2)
+		if term.Phrase {
+			part = fmt.Sprintf("phraseto_tsquery('english', $%d)", len(args)+2)
+		}
+		if term.Prefix {
+			part = fmt.Sprintf("to_tsquery('english', $%d || ':*')", len(args)+2)
+		}
+		if term.Negated {
+			part = "!!(" + part + ")"
+		}
+		parts = append(parts, part)
 	}
 
-	n, err := ct.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error in getting rows affected: %s", err)
-	}
-	if n == 0 {
-		return fmt.Errorf("rows affected for delete note call is 0")
-	}
-	return nil
+	sql := fmt.Sprintf(`WITH q AS (SELECT %s AS query)
+		SELECT %s, ts_rank_cd(search_vector, q.query) AS rank,
+			ts_headline('english', coalesce(title, ''), q.query, $3),
+			ts_headline('english', coalesce(body, ''), q.query, $3)
+		FROM notes, q
+		WHERE user_id=$1 AND deleted_at IS NULL AND search_vector @@ q.query
+		ORDER BY rank DESC, created_at DESC, id
+		LIMIT $2;`, strings.Join(parts, " && "), columns)
+	return sql, args
 }
@@ -0,0 +1,54 @@
+package notestore
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxProjectNameLength is the maximum length of a project name in characters
+const MaxProjectNameLength = 100
+
+// Project is a list grouping the notes of a user
+type Project struct {
+	ID     string
+	Name   string
+	UserID string
+	// Archived projects are read-only, their notes are left out of ReadAll
+	// unless the project is requested explicitly
+	Archived  bool
+	CreatedAt string
+	UpdatedAt string
+	// NoteCount is the number of notes in the project
+	NoteCount int
+}
+
+// ProjectStore is the interface for storing the projects of the notes.
+// A note belongs to at most one project, notes without a project are in the inbox.
+type ProjectStore interface {
+	CreateProject(ctx context.Context, project Project) error
+	ReadProject(ctx context.Context, projectID, userID string) (Project, error)
+	// ListProjects returns the projects ordered by name, archived projects are
+	// only included when includeArchived is set
+	ListProjects(ctx context.Context, userID string, includeArchived bool) ([]Project, error)
+	// RenameProject fails when the name is used by another project of the user
+	RenameProject(ctx context.Context, projectID, userID, name string) error
+	ArchiveProject(ctx context.Context, projectID, userID string, archived bool) error
+	// DeleteProject deletes the project and moves its notes to the inbox
+	DeleteProject(ctx context.Context, projectID, userID string) error
+	// MoveNote moves the note to the project, an empty projectID moves it to the inbox.
+	// Notes cannot be moved into or out of an archived project.
+	MoveNote(ctx context.Context, noteID, userID, projectID string) error
+}
+
+// NormalizeProjectName trims the name of a project and checks its length
+func NormalizeProjectName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", Invalidf("project name is empty")
+	}
+	if utf8.RuneCountInString(name) > MaxProjectNameLength {
+		return "", Invalidf("project name is longer than %d characters", MaxProjectNameLength)
+	}
+	return name, nil
+}
@@ -0,0 +1,33 @@
+package notestore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeProjectName(t *testing.T) {
+	testCases := []struct {
+		description   string
+		name          string
+		expectedName  string
+		expectedError bool
+	}{
+		{description: "Trimmed", name: "  Home renovation ", expectedName: "Home renovation"},
+		{description: "Case is kept", name: "Q4 OKRs", expectedName: "Q4 OKRs"},
+		{description: "Empty", name: "   ", expectedError: true},
+		{description: "Too long", name: strings.Repeat("a", MaxProjectNameLength+1), expectedError: true},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		name, err := NormalizeProjectName(testCase.name)
+		if testCase.expectedError {
+			assert.NotNil(err, testCase.description)
+			continue
+		}
+		assert.Nil(err, testCase.description)
+		assert.Equal(testCase.expectedName, name, testCase.description)
+	}
+}
@@ -0,0 +1,279 @@
+package notestore
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxPreviewOccurrences is the maximum number of occurrences returned by Occurrences
+const MaxPreviewOccurrences = 100
+
+// Frequency is the base period of a recurrence
+type Frequency string
+
+// Frequencies of a recurrence
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// Recurrence is a schedule for repeating a note. It supports the subset of the
+// RFC 5545 RRULE made of FREQ, INTERVAL, BYDAY (weekly), BYMONTHDAY (monthly),
+// COUNT and UNTIL.
+type Recurrence struct {
+	Freq     Frequency
+	Interval int
+	// ByDay lists the weekdays of a weekly recurrence
+	ByDay []time.Weekday
+	// ByMonthDay lists the days of a monthly recurrence, negative days count
+	// from the end of the month
+	ByMonthDay []int
+	// Count limits the number of occurrences of the series
+	Count int
+	// Until is the last time an occurrence may be due
+	Until time.Time
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var weekdayNames = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// presets are shorthands for the common recurrences
+var presets = map[string]string{
+	"daily":   "FREQ=DAILY",
+	"weekly":  "FREQ=WEEKLY",
+	"monthly": "FREQ=MONTHLY",
+	"yearly":  "FREQ=YEARLY",
+}
+
+// ParseRecurrence parses one of the presets daily, weekly, monthly and yearly
+// or an RRULE such as FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10
+func ParseRecurrence(rule string) (Recurrence, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if preset, ok := presets[strings.ToLower(rule)]; ok {
+		rule = preset
+	}
+
+	r := Recurrence{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return Recurrence{}, Invalidf("invalid recurrence rule part '%s'", part)
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			if r.Freq != Daily && r.Freq != Weekly && r.Freq != Monthly && r.Freq != Yearly {
+				return Recurrence{}, Invalidf("unsupported recurrence frequency '%s'", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err != nil || r.Interval < 1 {
+				return Recurrence{}, Invalidf("invalid recurrence interval '%s'", value)
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err != nil || r.Count < 1 {
+				return Recurrence{}, Invalidf("invalid recurrence count '%s'", value)
+			}
+		case "UNTIL":
+			r.Until, err = time.Parse("20060102T150405Z", value)
+			if err != nil {
+				// A date includes the whole day
+				r.Until, err = time.Parse("20060102", value)
+				r.Until = r.Until.AddDate(0, 0, 1).Add(-time.Second)
+			}
+			if err != nil {
+				return Recurrence{}, Invalidf("invalid recurrence until '%s'", value)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return Recurrence{}, Invalidf("invalid recurrence day '%s'", day)
+				}
+				r.ByDay = append(r.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return Recurrence{}, Invalidf("invalid recurrence month day '%s'", day)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		default:
+			return Recurrence{}, Invalidf("unsupported recurrence rule part '%s'", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return Recurrence{}, Invalidf("recurrence frequency is missing")
+	}
+	if len(r.ByDay) > 0 && r.Freq != Weekly {
+		return Recurrence{}, Invalidf("BYDAY is only supported for weekly recurrences")
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != Monthly {
+		return Recurrence{}, Invalidf("BYMONTHDAY is only supported for monthly recurrences")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return Recurrence{}, Invalidf("COUNT and UNTIL must not be used together")
+	}
+	sort.Slice(r.ByDay, func(i, j int) bool { return r.ByDay[i] < r.ByDay[j] })
+	return r, nil
+}
+
+// String formats the recurrence as an RRULE
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, day := range r.ByDay {
+			days = append(days, weekdayNames[day])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		var days []string
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the occurrence following the occurrence-th one of the series,
+// due at due. It returns false when the series has ended.
+func (r Recurrence) Next(due time.Time, occurrence int) (time.Time, bool) {
+	if r.Count > 0 && occurrence >= r.Count {
+		return time.Time{}, false
+	}
+
+	var next time.Time
+	switch r.Freq {
+	case Daily:
+		next = due.AddDate(0, 0, r.Interval)
+	case Weekly:
+		next = r.nextWeekly(due)
+	case Monthly:
+		next = r.nextMonthly(due)
+	case Yearly:
+		next = r.nextYearly(due)
+	default:
+		return time.Time{}, false
+	}
+
+	if next.IsZero() || (!r.Until.IsZero() && next.After(r.Until)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// Occurrences returns up to n occurrences following the occurrence-th one of the series
+func (r Recurrence) Occurrences(due time.Time, occurrence, n int) []time.Time {
+	if n > MaxPreviewOccurrences {
+		n = MaxPreviewOccurrences
+	}
+	occurrences := []time.Time{}
+	for len(occurrences) < n {
+		next, ok := r.Next(due, occurrence)
+		if !ok {
+			break
+		}
+		occurrences = append(occurrences, next)
+		due, occurrence = next, occurrence+1
+	}
+	return occurrences
+}
+
+func (r Recurrence) nextWeekly(due time.Time) time.Time {
+	if len(r.ByDay) == 0 {
+		return due.AddDate(0, 0, 7*r.Interval)
+	}
+
+	// Later days of the same week, weeks starting on Monday
+	offset := (int(due.Weekday()) + 6) % 7
+	for _, day := range r.mondayFirstDays() {
+		dayOffset := (int(day) + 6) % 7
+		if dayOffset > offset {
+			return due.AddDate(0, 0, dayOffset-offset)
+		}
+	}
+
+	// Otherwise the first day of the week interval weeks later
+	monday := due.AddDate(0, 0, -offset+7*r.Interval)
+	return monday.AddDate(0, 0, (int(r.mondayFirstDays()[0])+6)%7)
+}
+
+// mondayFirstDays returns ByDay ordered from Monday to Sunday
+func (r Recurrence) mondayFirstDays() []time.Weekday {
+	days := append([]time.Weekday{}, r.ByDay...)
+	sort.Slice(days, func(i, j int) bool { return (int(days[i])+6)%7 < (int(days[j])+6)%7 })
+	return days
+}
+
+func (r Recurrence) nextMonthly(due time.Time) time.Time {
+	monthDays := r.ByMonthDay
+	if len(monthDays) == 0 {
+		monthDays = []int{due.Day()}
+	}
+
+	// Months without the requested days are skipped, like the 31st in April
+	for i := 0; i <= 48; i++ {
+		month := time.Date(due.Year(), due.Month()+time.Month(i*r.Interval), 1,
+			due.Hour(), due.Minute(), due.Second(), due.Nanosecond(), due.Location())
+		length := month.AddDate(0, 1, -1).Day()
+
+		var days []int
+		for _, day := range monthDays {
+			if day < 0 {
+				day = length + day + 1
+			}
+			if day >= 1 && day <= length {
+				days = append(days, day)
+			}
+		}
+		sort.Ints(days)
+
+		for _, day := range days {
+			candidate := month.AddDate(0, 0, day-1)
+			if candidate.After(due) {
+				return candidate
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func (r Recurrence) nextYearly(due time.Time) time.Time {
+	// Years without the day are skipped, like February 29th
+	for i := 1; i <= 8*r.Interval; i++ {
+		year := due.Year() + i*r.Interval
+		candidate := time.Date(year, due.Month(), due.Day(),
+			due.Hour(), due.Minute(), due.Second(), due.Nanosecond(), due.Location())
+		if candidate.Day() == due.Day() {
+			return candidate
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,155 @@
+package notestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	testCases := []struct {
+		description   string
+		rule          string
+		expectedRule  string
+		expectedError bool
+	}{
+		{description: "Preset", rule: "weekly", expectedRule: "FREQ=WEEKLY"},
+		{description: "RRULE prefix", rule: "RRULE:FREQ=DAILY;INTERVAL=1", expectedRule: "FREQ=DAILY"},
+		{description: "Weekdays are ordered", rule: "freq=weekly;interval=2;byday=TH,MO;count=10", expectedRule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10"},
+		{description: "Until date", rule: "FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20211231", expectedRule: "FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20211231T235959Z"},
+		{description: "Missing frequency", rule: "INTERVAL=2", expectedError: true},
+		{description: "Unsupported frequency", rule: "FREQ=HOURLY", expectedError: true},
+		{description: "Unsupported part", rule: "FREQ=DAILY;BYHOUR=9", expectedError: true},
+		{description: "BYDAY on a monthly recurrence", rule: "FREQ=MONTHLY;BYDAY=MO", expectedError: true},
+		{description: "COUNT with UNTIL", rule: "FREQ=DAILY;COUNT=2;UNTIL=20211231", expectedError: true},
+		{description: "Invalid interval", rule: "FREQ=DAILY;INTERVAL=0", expectedError: true},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		r, err := ParseRecurrence(testCase.rule)
+		if testCase.expectedError {
+			assert.NotNil(err, testCase.description)
+			continue
+		}
+		assert.Nil(err, testCase.description)
+		assert.Equal(testCase.expectedRule, r.String(), testCase.description)
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		description string
+		rule        string
+		due         time.Time
+		occurrence  int
+		expected    []time.Time
+	}{
+		{
+			description: "Every other day",
+			rule:        "FREQ=DAILY;INTERVAL=2",
+			due:         date(2021, 10, 30),
+			occurrence:  1,
+			expected:    []time.Time{date(2021, 11, 1), date(2021, 11, 3), date(2021, 11, 5)},
+		},
+		{
+			description: "Mondays and Thursdays every other week",
+			rule:        "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			due:         date(2021, 10, 18),
+			occurrence:  1,
+			expected:    []time.Time{date(2021, 10, 21), date(2021, 11, 1), date(2021, 11, 4)},
+		},
+		{
+			description: "Weekly on the day of the due date",
+			rule:        "weekly",
+			due:         date(2021, 10, 20),
+			occurrence:  1,
+			expected:    []time.Time{date(2021, 10, 27), date(2021, 11, 3), date(2021, 11, 10)},
+		},
+		{
+			description: "Months without the day are skipped",
+			rule:        "monthly",
+			due:         date(2021, 1, 31),
+			occurrence:  1,
+			expected:    []time.Time{date(2021, 3, 31), date(2021, 5, 31), date(2021, 7, 31)},
+		},
+		{
+			description: "First and last day of the month",
+			rule:        "FREQ=MONTHLY;BYMONTHDAY=1,-1",
+			due:         date(2021, 1, 31),
+			occurrence:  1,
+			expected:    []time.Time{date(2021, 2, 1), date(2021, 2, 28), date(2021, 3, 1)},
+		},
+		{
+			description: "Leap day",
+			rule:        "yearly",
+			due:         date(2020, 2, 29),
+			occurrence:  1,
+			expected:    []time.Time{date(2024, 2, 29), date(2028, 2, 29), date(2032, 2, 29)},
+		},
+		{
+			description: "Count ends the series",
+			rule:        "FREQ=DAILY;COUNT=3",
+			due:         date(2021, 10, 20),
+			occurrence:  2,
+			expected:    []time.Time{date(2021, 10, 21)},
+		},
+		{
+			description: "Until ends the series",
+			rule:        "FREQ=DAILY;UNTIL=20211021",
+			due:         date(2021, 10, 20),
+			occurrence:  1,
+			expected:    []time.Time{date(2021, 10, 21)},
+		},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		r, err := ParseRecurrence(testCase.rule)
+		assert.Nil(err, testCase.description)
+		assert.Equal(testCase.expected, r.Occurrences(testCase.due, testCase.occurrence, 3), testCase.description)
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	note, err := NormalizeTodo(Note{
+		ID:         "note_1",
+		Title:      "Monthly report",
+		UserID:     "user_1",
+		ProjectID:  "project_1",
+		Tags:       []string{"work"},
+		Status:     StatusDone,
+		Priority:   PriorityMedium,
+		DueDate:    "2021-10-29",
+		Recurrence: "FREQ=MONTHLY;COUNT=2",
+	})
+
+	assert := assert.New(t)
+	assert.Nil(err)
+	assert.Equal(1, note.Occurrence)
+
+	next, ok := NextOccurrence(note)
+	assert.True(ok)
+	assert.Equal(Note{
+		Title:      "Monthly report",
+		UserID:     "user_1",
+		ProjectID:  "project_1",
+		Tags:       []string{"work"},
+		Status:     StatusOpen,
+		Priority:   PriorityMedium,
+		DueDate:    "2021-11-29T00:00:00Z",
+		Recurrence: "FREQ=MONTHLY;COUNT=2",
+		Occurrence: 2,
+	}, next)
+
+	_, ok = NextOccurrence(next)
+	assert.False(ok)
+
+	_, err = NormalizeTodo(Note{Recurrence: "daily"})
+	assert.NotNil(err)
+}
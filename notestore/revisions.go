@@ -0,0 +1,198 @@
+package notestore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Revision is the content of a note before one of its updates
+type Revision struct {
+	NoteID string
+	// Number orders the revisions of a note, starting at 1
+	Number int
+	Title  string
+	Body   string
+	// UserID is the user who updated the note
+	UserID string
+	// CreatedAt is the time of the update
+	CreatedAt string
+}
+
+// RevisionStore is the interface for the revision history of the notes.
+// Every update changing the title or body of a note stores its previous content
+// as a revision.
+type RevisionStore interface {
+	// ListRevisions returns the revisions of the note, newest first
+	ListRevisions(ctx context.Context, noteID, userID string) ([]Revision, error)
+	ReadRevision(ctx context.Context, noteID, userID string, number int) (Revision, error)
+	// RestoreRevision sets the title and body of the note back to the revision,
+	// the replaced content is stored as a new revision
+	RestoreRevision(ctx context.Context, noteID, userID string, number int) error
+}
+
+// RevisionText returns the text compared by the diffs of a note, its title
+// followed by a blank line and its body
+func RevisionText(title, body string) string {
+	return title + "\n\n" + body
+}
+
+// diffContext is the number of unchanged lines around the changes of a hunk
+const diffContext = 3
+
+// maxDiffCells bounds the memory used by the line matching of UnifiedDiff.
+// Larger texts are diffed as a single replacement.
+const maxDiffCells = 1 << 22
+
+// diffOp is a line of a diff, kind is ' ' for unchanged lines, '-' for deleted
+// lines and '+' for inserted lines
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// UnifiedDiff returns the unified diff between two texts, empty when they are equal
+func UnifiedDiff(fromName, toName, from, to string) string {
+	a, b := splitLines(from), splitLines(to)
+	ops := diffLines(a, b)
+
+	// Line numbers of the texts before each op
+	aPos, bPos := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for k, op := range ops {
+		aPos[k+1], bPos[k+1] = aPos[k], bPos[k]
+		if op.kind != '+' {
+			aPos[k+1]++
+		}
+		if op.kind != '-' {
+			bPos[k+1]++
+		}
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(ops); {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		// Changes separated by at most twice the context share a hunk
+		start, end := i-diffContext, i
+		if start < 0 {
+			start = 0
+		}
+		for {
+			for end < len(ops) && ops[end].kind != ' ' {
+				end++
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end += diffContext
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = run
+		}
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aPos[start], aPos[end]-aPos[start]),
+			hunkRange(bPos[start], bPos[end]-bPos[start]))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the range of a hunk starting after the first start lines
+func hunkRange(start, length int) string {
+	switch length {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, length)
+	}
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines matches the lines of a and b with a longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+
+	// The common prefix and suffix do not need to be matched
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		ops = append(ops, diffOp{' ', a[prefix]})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	if len(ma)*len(mb) > maxDiffCells {
+		for _, line := range ma {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range mb {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of ma[i:] and mb[j:]
+		lcs := make([][]int, len(ma)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(mb)+1)
+		}
+		for i := len(ma) - 1; i >= 0; i-- {
+			for j := len(mb) - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < len(ma) || j < len(mb) {
+			switch {
+			case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+				ops = append(ops, diffOp{' ', ma[i]})
+				i, j = i+1, j+1
+			case j == len(mb) || (i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]):
+				ops = append(ops, diffOp{'-', ma[i]})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', mb[j]})
+				j++
+			}
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
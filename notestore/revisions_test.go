@@ -0,0 +1,79 @@
+package notestore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(n int, change map[int]string) string {
+		var out []string
+		for i := 1; i <= n; i++ {
+			line := "line " + strings.Repeat("x", i%3) + string(rune('a'+i-1))
+			if c, ok := change[i]; ok {
+				line = c
+			}
+			out = append(out, line)
+		}
+		return strings.Join(out, "\n")
+	}
+
+	testCases := []struct {
+		description  string
+		from         string
+		to           string
+		expectedDiff string
+	}{
+		{
+			description:  "Equal texts",
+			from:         "Groceries\n\nMilk\nEggs",
+			to:           "Groceries\n\nMilk\nEggs",
+			expectedDiff: "",
+		},
+		{
+			description: "Changed line",
+			from:        "Groceries\n\nMilk\nEggs",
+			to:          "Groceries\n\nOat milk\nEggs",
+			expectedDiff: "--- revision 1\n+++ current\n" +
+				"@@ -1,4 +1,4 @@\n Groceries\n \n-Milk\n+Oat milk\n Eggs\n",
+		},
+		{
+			description: "Insertion into an empty text",
+			from:        "",
+			to:          "Milk\nEggs",
+			expectedDiff: "--- revision 1\n+++ current\n" +
+				"@@ -0,0 +1,2 @@\n+Milk\n+Eggs\n",
+		},
+		{
+			description: "Distant changes are split into hunks",
+			from:        lines(20, nil),
+			to:          lines(20, map[int]string{2: "second", 18: "eighteenth"}),
+			expectedDiff: "--- revision 1\n+++ current\n" +
+				"@@ -1,5 +1,5 @@\n line xa\n-line xxb\n+second\n line c\n line xd\n line xxe\n" +
+				"@@ -15,6 +15,6 @@\n line o\n line xp\n line xxq\n-line r\n+eighteenth\n line xs\n line xxt\n",
+		},
+		{
+			description: "Close changes share a hunk",
+			from:        lines(10, nil),
+			to:          lines(10, map[int]string{3: "third", 8: "eighth"}),
+			expectedDiff: "--- revision 1\n+++ current\n" +
+				"@@ -1,10 +1,10 @@\n line xa\n line xxb\n-line c\n+third\n line xd\n line xxe\n line f\n line xg\n" +
+				"-line xxh\n+eighth\n line i\n line xj\n",
+		},
+		{
+			description: "Deleted line",
+			from:        "a\nb\nc",
+			to:          "a\nc",
+			expectedDiff: "--- revision 1\n+++ current\n" +
+				"@@ -1,3 +1,2 @@\n a\n-b\n c\n",
+		},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		diff := UnifiedDiff("revision 1", "current", testCase.from, testCase.to)
+		assert.Equal(testCase.expectedDiff, diff, testCase.description)
+	}
+}
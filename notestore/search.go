@@ -0,0 +1,101 @@
+package notestore
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Search result limits
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// SearchResult is a note matching a search query
+type SearchResult struct {
+	Note Note
+	// Rank orders the results, higher is more relevant
+	Rank float64
+	// TitleSnippet and BodySnippet are excerpts with the matched terms
+	// wrapped in <mark></mark>
+	TitleSnippet string
+	BodySnippet  string
+}
+
+// SearchTerm is a single term of a search query
+type SearchTerm struct {
+	Text string
+	// Phrase is set for quoted terms that must match as a whole
+	Phrase bool
+	// Prefix is set for terms ending with '*' that match any word starting with Text
+	Prefix bool
+	// Negated is set for terms starting with '-' that must not match
+	Negated bool
+}
+
+// ParseSearchQuery parses a search query such as
+//   release "check list" deploy* -draft
+// into its terms. Words are matched individually, quoted phrases as a whole,
+// words ending with '*' as prefixes and terms starting with '-' are excluded.
+func ParseSearchQuery(q string) ([]SearchTerm, error) {
+	var terms []SearchTerm
+	positive := false
+
+	rs := []rune(q)
+	for i := 0; i < len(rs); {
+		if unicode.IsSpace(rs[i]) {
+			i++
+			continue
+		}
+
+		var term SearchTerm
+		if rs[i] == '-' {
+			term.Negated = true
+			i++
+		}
+
+		if i < len(rs) && rs[i] == '"' {
+			end := i + 1
+			for end < len(rs) && rs[end] != '"' {
+				end++
+			}
+			if end == len(rs) {
+				return nil, Invalidf("unterminated phrase in search query")
+			}
+			term.Text = strings.TrimSpace(string(rs[i+1 : end]))
+			term.Phrase = true
+			i = end + 1
+		} else {
+			end := i
+			for end < len(rs) && !unicode.IsSpace(rs[end]) {
+				end++
+			}
+			term.Text = string(rs[i:end])
+			i = end
+			if strings.HasSuffix(term.Text, "*") {
+				term.Text = strings.TrimRight(term.Text, "*")
+				term.Prefix = true
+				// Prefixes are matched as lexemes, so only letters and digits are kept
+				term.Text = strings.Map(func(r rune) rune {
+					if unicode.IsLetter(r) || unicode.IsDigit(r) {
+						return r
+					}
+					return -1
+				}, term.Text)
+			}
+		}
+
+		if term.Text == "" {
+			continue
+		}
+		if !term.Negated {
+			positive = true
+		}
+		terms = append(terms, term)
+	}
+
+	if !positive {
+		return nil, Invalidf("search query must contain at least one term that is not excluded")
+	}
+	return terms, nil
+}
@@ -0,0 +1,58 @@
+package notestore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	testCases := []struct {
+		description   string
+		query         string
+		expectedTerms []SearchTerm
+		expectedError bool
+	}{
+		{
+			description:   "Words",
+			query:         "release  notes",
+			expectedTerms: []SearchTerm{{Text: "release"}, {Text: "notes"}},
+		},
+		{
+			description:   "Phrase, prefix and negated terms",
+			query:         `"check list" deploy* -draft -"first cut"`,
+			expectedTerms: []SearchTerm{{Text: "check list", Phrase: true}, {Text: "deploy", Prefix: true}, {Text: "draft", Negated: true}, {Text: "first cut", Phrase: true, Negated: true}},
+		},
+		{
+			description:   "Prefixes keep only letters and digits",
+			query:         "v1.2*",
+			expectedTerms: []SearchTerm{{Text: "v12", Prefix: true}},
+		},
+		{
+			description:   "Empty query",
+			query:         "  ",
+			expectedError: true,
+		},
+		{
+			description:   "Only negated terms",
+			query:         "-draft",
+			expectedError: true,
+		},
+		{
+			description:   "Unterminated phrase",
+			query:         `"check list`,
+			expectedError: true,
+		},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		terms, err := ParseSearchQuery(testCase.query)
+		if testCase.expectedError {
+			assert.NotNil(err, testCase.description)
+			continue
+		}
+		assert.Nil(err, testCase.description)
+		assert.Equal(testCase.expectedTerms, terms, testCase.description)
+	}
+}
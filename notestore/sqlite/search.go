@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+// matchQuery returns the FTS5 query of the search terms. The terms are quoted so that
+// their text is never read as FTS5 syntax, the excluded terms are subtracted with NOT.
+func matchQuery(terms []notestore.SearchTerm) string {
+	var included, excluded []string
+	for _, term := range terms {
+		part := `"` + strings.ReplaceAll(term.Text, `"`, `""`) + `"`
+		if term.Prefix {
+			part += "*"
+		}
+		if term.Negated {
+			excluded = append(excluded, part)
+		} else {
+			included = append(included, part)
+		}
+	}
+
+	query := "(" + strings.Join(included, " AND ") + ")"
+	for _, part := range excluded {
+		query += " NOT " + part
+	}
+	return query
+}
+
+// SearchQuery matches the terms against the notes_search index maintained by triggers.
+// Words are matched without stemming, and matches in the title weigh more as in Postgres.
+func (dialect) SearchQuery(columns string, terms []notestore.SearchTerm) (string, []interface{}) {
+	sql := fmt.Sprintf(`SELECT %s, s.score, s.title_snippet, s.body_snippet
+		FROM notes JOIN (
+			SELECT rowid, -bm25(notes_search, 1.0, 0.4) AS score,
+				highlight(notes_search, 0, '<mark>', '</mark>') AS title_snippet,
+				snippet(notes_search, 1, '<mark>', '</mark>', ' ... ', 25) AS body_snippet
+			FROM notes_search WHERE notes_search MATCH $3
+		) s ON s.rowid=notes.s_no
+		WHERE user_id=$1 AND deleted_at IS NULL
+		ORDER BY s.score DESC, created_at DESC, id
+		LIMIT $2;`, columns)
+	return sql, []interface{}{matchQuery(terms)}
+}
@@ -0,0 +1,73 @@
+// Package sqlite is a note store kept in a single SQLite file, for the deployments
+// that do not run Postgres. The database is migrated with the files of
+// db/migrations/sqlite.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"local/sidharthjs/todo/notestore/sqlstore"
+
+	_ "modernc.org/sqlite"
+)
+
+//DB struct that represents the Client
+type DB struct {
+	*sqlstore.Store
+}
+
+// NewClient returns the DB client of the SQLite file at path and error. SQLite has a
+// single writer, so the client uses a single connection: the transactions run one
+// after the other and the rows they read cannot change before they commit.
+func NewClient(path string) (*DB, error) {
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to db: %s", err)
+	}
+	conn.SetMaxOpenConns(1)
+
+	return &DB{sqlstore.New(conn, dialect{})}, nil
+}
+
+// Check is the health check of the database, it runs a query. The driver interrupts the
+// connection of a query once its context is done, even when the query has returned and
+// the connection is closed by then, so the query runs without the context of the check
+// and the check gives up when its context is done, leaving the query to end on its own.
+func (db *DB) Check(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		var one int
+		done <- db.QueryRow("SELECT 1;").Scan(&one)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// dialect is the sqlstore.Dialect of SQLite
+type dialect struct{}
+
+// timeLayout is the fixed width format of the times stored as text, so that they sort as text
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// Time formats the time for a text column
+func (dialect) Time(t time.Time) interface{} {
+	return t.UTC().Format(timeLayout)
+}
+
+// ForUpdate is empty as the transactions run one after the other
+func (dialect) ForUpdate(table string) string {
+	return ""
+}
+
+func (dialect) TagsColumn() string {
+	return `(SELECT json_group_array(name) FROM (SELECT t.name FROM note_tags nt JOIN tags t ON t.id=nt.tag_id
+		WHERE nt.note_s_no=notes.s_no ORDER BY t.name))`
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	migrate "local/sidharthjs/todo/db"
+	"local/sidharthjs/todo/notestore"
+	"local/sidharthjs/todo/notestore/notestoretest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDB returns a client of a migrated database in a temporary file
+func testDB(t *testing.T) *DB {
+	path := filepath.Join(t.TempDir(), "todo.db")
+	err := migrate.Migrate("sqlite://"+path, "file://../../db/migrations/sqlite")
+	if err != nil {
+		t.Fatalf("error performing db migration: %s", err)
+	}
+
+	db, err := NewClient(path)
+	if err != nil {
+		t.Fatalf("error creating db client: %s", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db
+}
+
+func TestConformance(t *testing.T) {
+	notestoretest.Run(t, testDB(t))
+}
+
+func TestPurgeNote(t *testing.T) {
+	db := testDB(t)
+	userID := "Purge_user_1"
+	noteID := uuid.New().String()
+	itemID := uuid.New().String()
+
+	assert := assert.New(t)
+	err := db.Create(context.Background(), notestore.Note{ID: noteID, Title: "Shopping list", UserID: userID, Tags: []string{"junk"}})
+	assert.Nil(err)
+	err = db.CreateItem(context.Background(), userID, notestore.Item{ID: itemID, NoteID: noteID, Text: "Milk"})
+	assert.Nil(err)
+	err = db.Update(context.Background(), notestore.Note{ID: noteID, Title: "Groceries", UserID: userID})
+	assert.Nil(err)
+
+	// Purging the note deletes its items, revisions and the tags it was the last one to use
+	err = db.Delete(context.Background(), noteID, userID, 0)
+	assert.Nil(err)
+	err = db.Purge(context.Background(), noteID, userID)
+	assert.Nil(err)
+	for _, table := range []string{"note_items", "note_revisions", "note_tags", "tags"} {
+		var count int
+		err = db.QueryRow("SELECT count(*) FROM " + table + ";").Scan(&count)
+		assert.Nil(err, table)
+		assert.Equal(0, count, table)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	db := testDB(t)
+
+	assert := assert.New(t)
+	assert.Nil(db.Check(context.Background()))
+
+	// The check gives up when the connection stays busy past its context
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("error beginning the transaction: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(context.DeadlineExceeded, db.Check(ctx))
+	assert.Nil(tx.Rollback())
+}
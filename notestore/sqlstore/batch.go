@@ -0,0 +1,68 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+// errBatchFailed rolls back the transaction of an atomic batch whose operation failed
+var errBatchFailed = errors.New("batch failed")
+
+//Batch runs the operations of a batch in a transaction. The operations of a best
+//effort batch run in savepoints so that a failed operation only rolls back itself.
+func (s *Store) Batch(ctx context.Context, userID string, ops []notestore.BatchOperation, atomic bool) ([]notestore.BatchResult, error) {
+	results := make([]notestore.BatchResult, len(ops))
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		for i, op := range ops {
+			op.Note.UserID = userID
+			results[i].NoteID = op.Note.ID
+
+			if !atomic {
+				_, err := tx.ExecContext(ctx, "SAVEPOINT batch_operation;")
+				if err != nil {
+					return fmt.Errorf("unable to create savepoint: %s", err)
+				}
+			}
+
+			err := s.runOperation(ctx, tx, op)
+			switch {
+			case err != nil && atomic:
+				results = notestore.AbortBatch(results, i, err)
+				return errBatchFailed
+			case err != nil:
+				results[i].Err = err
+				_, err = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_operation;")
+			case !atomic:
+				_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_operation;")
+			}
+			if err != nil {
+				return fmt.Errorf("unable to end savepoint: %s", err)
+			}
+		}
+		return nil
+	})
+	if err == errBatchFailed {
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// runOperation runs an operation of a batch
+func (s *Store) runOperation(ctx context.Context, tx *sql.Tx, op notestore.BatchOperation) error {
+	switch op.Action {
+	case notestore.BatchCreate:
+		return s.createNote(ctx, tx, op.Note)
+	case notestore.BatchUpdate:
+		return s.updateFields(ctx, tx, op.Note, op.Fields)
+	case notestore.BatchDelete:
+		return s.deleteNote(ctx, tx, op.Note.ID, op.Note.UserID, op.Note.Version)
+	}
+	return notestore.Invalidf("invalid batch operation '%s'", op.Action)
+}
@@ -0,0 +1,69 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+//ReserveIdempotencyKey reserves an idempotency key or returns the response stored for it
+func (s *Store) ReserveIdempotencyKey(ctx context.Context, userID, key, requestHash string, expiredBefore, abandonedBefore time.Time) (notestore.IdempotentResponse, bool, error) {
+	resp := notestore.IdempotentResponse{UserID: userID, Key: key}
+	var reserved bool
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		// The expired keys of the user are dropped as they are not replayed anymore, and
+		// so are the abandoned reservations
+		sqlQuery := "DELETE FROM idempotency_keys WHERE user_id=$1 AND (created_at<$2 OR (status=0 AND created_at<$3));"
+		_, err := tx.ExecContext(ctx, sqlQuery, userID, s.dialect.Time(expiredBefore), s.dialect.Time(abandonedBefore))
+		if err != nil {
+			return fmt.Errorf("unable to delete the expired idempotency keys: %s", err)
+		}
+
+		// A concurrent request with the same key waits here until the first one commits,
+		// or does not run at all before it commits when the transactions are serialized
+		sqlQuery = `INSERT INTO idempotency_keys(user_id, key, request_hash, created_at) VALUES($1, $2, $3, $4)
+			ON CONFLICT (user_id, key) DO NOTHING;`
+		ct, err := tx.ExecContext(ctx, sqlQuery, userID, key, requestHash, s.now())
+		if err != nil {
+			return fmt.Errorf("unable to reserve idempotency key '%s': %s", key, err)
+		}
+		n, err := ct.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error in getting rows affected: %s", err)
+		}
+		if n == 1 {
+			reserved = true
+			return nil
+		}
+
+		sqlQuery = "SELECT request_hash, status, content_type, body, created_at FROM idempotency_keys WHERE user_id=$1 AND key=$2;"
+		err = tx.QueryRowContext(ctx, sqlQuery, userID, key).Scan(&resp.RequestHash, &resp.Status, &resp.ContentType, &resp.Body, timeColumn{&resp.CreatedAt})
+		if err != nil {
+			return fmt.Errorf("error occurred while retrieving idempotency key '%s': %s", key, err)
+		}
+		return nil
+	})
+	return resp, reserved, err
+}
+
+//SaveIdempotentResponse stores the response to the request that reserved the key
+func (s *Store) SaveIdempotentResponse(ctx context.Context, resp notestore.IdempotentResponse) error {
+	sql := "UPDATE idempotency_keys SET status=$1, content_type=$2, body=$3 WHERE user_id=$4 AND key=$5;"
+	_, err := s.ExecContext(ctx, sql, resp.Status, resp.ContentType, resp.Body, resp.UserID, resp.Key)
+	if err != nil {
+		return fmt.Errorf("unable to store the response of idempotency key '%s': %s", resp.Key, err)
+	}
+	return nil
+}
+
+//ReleaseIdempotencyKey deletes the reservation of a key whose request failed
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	_, err := s.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE user_id=$1 AND key=$2 AND status=0;", userID, key)
+	if err != nil {
+		return fmt.Errorf("unable to release idempotency key '%s': %s", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+//ListItems lists the checklist items of a note ordered by position
+func (s *Store) ListItems(ctx context.Context, noteID, userID string) ([]notestore.Item, error) {
+	sNo, err := noteSerial(ctx, s, noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sql := "SELECT id, text, checked, position, created_at FROM note_items WHERE note_s_no=$1 ORDER BY position, created_at;"
+	rows, err := s.QueryContext(ctx, sql, sNo)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while querying the items: %s", err)
+	}
+	defer rows.Close()
+
+	items := []notestore.Item{}
+	for rows.Next() {
+		item := notestore.Item{NoteID: noteID}
+		err := rows.Scan(&item.ID, &item.Text, &item.Checked, &item.Position, timeColumn{&item.CreatedAt})
+		if err != nil {
+			return nil, fmt.Errorf("error occurred while scanning the rows: %s", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred while iterating the rows: %s", err)
+	}
+
+	return items, nil
+}
+
+//CreateItem appends an item to the checklist of a note
+func (s *Store) CreateItem(ctx context.Context, userID string, item notestore.Item) error {
+	text, err := notestore.NormalizeItemText(item.Text)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		sNo, err := noteSerial(ctx, tx, item.NoteID, userID)
+		if err != nil {
+			return err
+		}
+
+		sql := `INSERT INTO note_items(id, note_s_no, text, checked, position, created_at)
+			SELECT $1, $2, $3, $4, coalesce(max(position)+1, 0), $5 FROM note_items WHERE note_s_no=$2;`
+		_, err = tx.ExecContext(ctx, sql, item.ID, sNo, text, item.Checked, s.now())
+		if err != nil {
+			return fmt.Errorf("unable to store item '%s': %s", item.ID, err)
+		}
+		return bumpVersion(ctx, tx, sNo)
+	})
+}
+
+//UpdateItem updates the text and checked state of a checklist item
+func (s *Store) UpdateItem(ctx context.Context, userID string, item notestore.Item) error {
+	text, err := notestore.NormalizeItemText(item.Text)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		sNo, err := noteSerial(ctx, tx, item.NoteID, userID)
+		if err != nil {
+			return err
+		}
+
+		sql := "UPDATE note_items SET text=$1, checked=$2 WHERE id=$3 AND note_s_no=$4;"
+		ct, err := tx.ExecContext(ctx, sql, text, item.Checked, item.ID, sNo)
+		if err != nil {
+			return fmt.Errorf("unable to update item '%s': %s", item.ID, err)
+		}
+
+		n, err := ct.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error in getting rows affected: %s", err)
+		}
+		if n == 0 {
+			return notestore.NotFoundf("Item '%s' is not found", item.ID)
+		}
+		return bumpVersion(ctx, tx, sNo)
+	})
+}
+
+//DeleteItem deletes a checklist item
+func (s *Store) DeleteItem(ctx context.Context, noteID, itemID, userID string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		sNo, err := noteSerial(ctx, tx, noteID, userID)
+		if err != nil {
+			return err
+		}
+
+		ct, err := tx.ExecContext(ctx, "DELETE FROM note_items WHERE id=$1 AND note_s_no=$2;", itemID, sNo)
+		if err != nil {
+			return fmt.Errorf("unable to delete item '%s': %s", itemID, err)
+		}
+
+		n, err := ct.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error in getting rows affected: %s", err)
+		}
+		if n == 0 {
+			return notestore.NotFoundf("Item '%s' is not found", itemID)
+		}
+		return bumpVersion(ctx, tx, sNo)
+	})
+}
+
+//ReorderItems sets the positions of the checklist items to their order in itemIDs
+func (s *Store) ReorderItems(ctx context.Context, noteID, userID string, itemIDs []string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		sNo, err := noteSerial(ctx, tx, noteID, userID)
+		if err != nil {
+			return err
+		}
+
+		// Lock the items so that the checklist does not change while reordering
+		rows, err := tx.QueryContext(ctx, "SELECT id FROM note_items WHERE note_s_no=$1"+s.dialect.ForUpdate("note_items")+";", sNo)
+		if err != nil {
+			return fmt.Errorf("error occurred while querying the items: %s", err)
+		}
+		existing := map[string]bool{}
+		for rows.Next() {
+			var id string
+			err := rows.Scan(&id)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("error occurred while scanning the rows: %s", err)
+			}
+			existing[id] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error occurred while iterating the rows: %s", err)
+		}
+
+		err = notestore.ValidateItemOrder(existing, itemIDs)
+		if err != nil {
+			return err
+		}
+
+		for position, id := range itemIDs {
+			_, err := tx.ExecContext(ctx, "UPDATE note_items SET position=$1 WHERE id=$2 AND note_s_no=$3;", position, id, sNo)
+			if err != nil {
+				return fmt.Errorf("unable to reorder item '%s': %s", id, err)
+			}
+		}
+		return bumpVersion(ctx, tx, sNo)
+	})
+}
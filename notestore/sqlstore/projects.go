@@ -0,0 +1,215 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+// projectColumns are the columns scanned by scanProject
+const projectColumns = `id, name, user_id, archived, created_at, updated_at,
+	(SELECT count(*) FROM notes n WHERE n.project_id=projects.id AND n.deleted_at IS NULL)`
+
+func scanProject(row scanner) (notestore.Project, error) {
+	var project notestore.Project
+	err := row.Scan(&project.ID, &project.Name, &project.UserID, &project.Archived,
+		timeColumn{&project.CreatedAt}, timeColumn{&project.UpdatedAt}, &project.NoteCount)
+	return project, err
+}
+
+// checkProject checks that the project is owned by the user and is not archived
+func checkProject(ctx context.Context, q queryer, projectID, userID string) error {
+	var archived bool
+	err := q.QueryRowContext(ctx, "SELECT archived FROM projects WHERE id=$1 AND user_id=$2;", projectID, userID).Scan(&archived)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return notestore.NotFoundf("Project '%s' is not found", projectID)
+		}
+		return fmt.Errorf("error occurred while retrieving the project: %s", err)
+	}
+	if archived {
+		return notestore.Conflictf("Project '%s' is archived", projectID)
+	}
+	return nil
+}
+
+// checkProjectName checks that the name is not used by another project of the user
+func checkProjectName(ctx context.Context, q queryer, projectID, userID, name string) error {
+	var exists bool
+	sql := "SELECT EXISTS (SELECT 1 FROM projects WHERE user_id=$1 AND name=$2 AND id<>$3);"
+	err := q.QueryRowContext(ctx, sql, userID, name, projectID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("error occurred while retrieving the project: %s", err)
+	}
+	if exists {
+		return notestore.Conflictf("Project '%s' already exists", name)
+	}
+	return nil
+}
+
+//CreateProject creates a project in the DB
+func (s *Store) CreateProject(ctx context.Context, project notestore.Project) error {
+	name, err := notestore.NormalizeProjectName(project.Name)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		err := checkProjectName(ctx, tx, project.ID, project.UserID, name)
+		if err != nil {
+			return err
+		}
+
+		now := s.now()
+		sql := "INSERT INTO projects(id, user_id, name, archived, created_at, updated_at) VALUES($1, $2, $3, $4, $5, $6);"
+		_, err = tx.ExecContext(ctx, sql, project.ID, project.UserID, name, project.Archived, now, now)
+		if err != nil {
+			return fmt.Errorf("unable to store project '%s': %s", project.ID, err)
+		}
+		return nil
+	})
+}
+
+//ReadProject reads a project from the DB
+func (s *Store) ReadProject(ctx context.Context, projectID, userID string) (notestore.Project, error) {
+	sqlQuery := "SELECT " + projectColumns + " FROM projects WHERE id=$1 AND user_id=$2;"
+	project, err := scanProject(s.QueryRowContext(ctx, sqlQuery, projectID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return notestore.Project{}, notestore.NotFoundf("Project '%s' is not found", projectID)
+		}
+		return notestore.Project{}, fmt.Errorf("error occurred while retrieving the project: %s", err)
+	}
+	return project, nil
+}
+
+//ListProjects lists the projects of the user ordered by name
+func (s *Store) ListProjects(ctx context.Context, userID string, includeArchived bool) ([]notestore.Project, error) {
+	sql := "SELECT " + projectColumns + " FROM projects WHERE user_id=$1 AND (NOT archived OR $2) ORDER BY name, id;"
+	rows, err := s.QueryContext(ctx, sql, userID, includeArchived)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while querying the projects: %s", err)
+	}
+	defer rows.Close()
+
+	projects := []notestore.Project{}
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error occurred while scanning the rows: %s", err)
+		}
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred while iterating the rows: %s", err)
+	}
+
+	return projects, nil
+}
+
+//RenameProject renames a project of the user
+func (s *Store) RenameProject(ctx context.Context, projectID, userID, name string) error {
+	name, err := notestore.NormalizeProjectName(name)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		err := checkProjectName(ctx, tx, projectID, userID, name)
+		if err != nil {
+			return err
+		}
+
+		sql := "UPDATE projects SET name=$1, updated_at=$2 WHERE id=$3 AND user_id=$4;"
+		ct, err := tx.ExecContext(ctx, sql, name, s.now(), projectID, userID)
+		if err != nil {
+			return fmt.Errorf("unable to rename project '%s': %s", projectID, err)
+		}
+
+		n, err := ct.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error in getting rows affected: %s", err)
+		}
+		if n == 0 {
+			return notestore.NotFoundf("Project '%s' is not found", projectID)
+		}
+		return nil
+	})
+}
+
+//ArchiveProject archives or restores a project of the user
+func (s *Store) ArchiveProject(ctx context.Context, projectID, userID string, archived bool) error {
+	sql := "UPDATE projects SET archived=$1, updated_at=$2 WHERE id=$3 AND user_id=$4;"
+	ct, err := s.ExecContext(ctx, sql, archived, s.now(), projectID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to archive project '%s': %s", projectID, err)
+	}
+
+	n, err := ct.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error in getting rows affected: %s", err)
+	}
+	if n == 0 {
+		return notestore.NotFoundf("Project '%s' is not found", projectID)
+	}
+	return nil
+}
+
+//DeleteProject deletes a project, the database moves its notes to the inbox
+func (s *Store) DeleteProject(ctx context.Context, projectID, userID string) error {
+	ct, err := s.ExecContext(ctx, "DELETE FROM projects WHERE id=$1 AND user_id=$2;", projectID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to delete project '%s': %s", projectID, err)
+	}
+
+	n, err := ct.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error in getting rows affected: %s", err)
+	}
+	if n == 0 {
+		return notestore.NotFoundf("Project '%s' is not found", projectID)
+	}
+	return nil
+}
+
+//MoveNote moves a note to a project, or to the inbox when projectID is empty
+func (s *Store) MoveNote(ctx context.Context, noteID, userID, projectID string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		sNo, err := s.lockNote(ctx, tx, noteID, userID)
+		if err != nil {
+			return err
+		}
+
+		var current sql.NullString
+		err = tx.QueryRowContext(ctx, "SELECT project_id FROM notes WHERE s_no=$1;", sNo).Scan(&current)
+		if err != nil {
+			return fmt.Errorf("error occurred while retrieving the note: %s", err)
+		}
+		if current.String == projectID {
+			return nil
+		}
+		if current.Valid {
+			err := checkProject(ctx, tx, current.String, userID)
+			if err != nil {
+				return err
+			}
+		}
+
+		var target interface{}
+		if projectID != "" {
+			err := checkProject(ctx, tx, projectID, userID)
+			if err != nil {
+				return err
+			}
+			target = projectID
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE notes SET project_id=$1, updated_at=$2, version=version+1 WHERE s_no=$3;", target, s.now(), sNo)
+		if err != nil {
+			return fmt.Errorf("unable to move note '%s': %s", noteID, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,88 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+//ListRevisions lists the revisions of a note, newest first
+func (s *Store) ListRevisions(ctx context.Context, noteID, userID string) ([]notestore.Revision, error) {
+	sNo, err := noteSerial(ctx, s, noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sql := "SELECT number, title, body, user_id, created_at FROM note_revisions WHERE note_s_no=$1 ORDER BY number DESC;"
+	rows, err := s.QueryContext(ctx, sql, sNo)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while querying the revisions: %s", err)
+	}
+	defer rows.Close()
+
+	revisions := []notestore.Revision{}
+	for rows.Next() {
+		revision := notestore.Revision{NoteID: noteID}
+		err := rows.Scan(&revision.Number, &revision.Title, &revision.Body, &revision.UserID, timeColumn{&revision.CreatedAt})
+		if err != nil {
+			return nil, fmt.Errorf("error occurred while scanning the rows: %s", err)
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred while iterating the rows: %s", err)
+	}
+
+	return revisions, nil
+}
+
+//ReadRevision reads a revision of a note
+func (s *Store) ReadRevision(ctx context.Context, noteID, userID string, number int) (notestore.Revision, error) {
+	return readRevision(ctx, s, noteID, userID, number)
+}
+
+func readRevision(ctx context.Context, q queryer, noteID, userID string, number int) (notestore.Revision, error) {
+	sNo, err := noteSerial(ctx, q, noteID, userID)
+	if err != nil {
+		return notestore.Revision{}, err
+	}
+
+	revision := notestore.Revision{NoteID: noteID, Number: number}
+	sqlQuery := "SELECT title, body, user_id, created_at FROM note_revisions WHERE note_s_no=$1 AND number=$2;"
+	err = q.QueryRowContext(ctx, sqlQuery, sNo, number).Scan(&revision.Title, &revision.Body, &revision.UserID, timeColumn{&revision.CreatedAt})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return notestore.Revision{}, notestore.NotFoundf("Revision %d of note '%s' is not found", number, noteID)
+		}
+		return notestore.Revision{}, fmt.Errorf("error occurred while retrieving the revision: %s", err)
+	}
+	return revision, nil
+}
+
+//RestoreRevision rolls the title and body of a note back to a revision through
+//the same transactional update as Update, so the replaced content becomes a new revision
+func (s *Store) RestoreRevision(ctx context.Context, noteID, userID string, number int) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := s.lockNote(ctx, tx, noteID, userID)
+		if err != nil {
+			return err
+		}
+		revision, err := readRevision(ctx, tx, noteID, userID, number)
+		if err != nil {
+			return err
+		}
+		note, err := s.readNote(ctx, tx, noteID, userID)
+		if err != nil {
+			return err
+		}
+
+		note.Title, note.Body = revision.Title, revision.Body
+		note, err = notestore.NormalizeTodo(note)
+		if err != nil {
+			return err
+		}
+		return s.updateNote(ctx, tx, note)
+	})
+}
@@ -0,0 +1,635 @@
+// Package sqlstore is the note store shared by the SQL databases. The queries are
+// written once with the $1, $2... placeholders, which the drivers of Postgres and
+// SQLite both accept, and what differs between the databases is left to a Dialect.
+// The database is migrated with the files of db/migrations for Postgres and of
+// db/migrations/sqlite for SQLite.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+
+	"github.com/google/uuid"
+)
+
+//Dialect is what differs between the SQL databases of a Store
+type Dialect interface {
+	// Time returns the value of a time for the queries, the times are compared
+	// and sorted in the database
+	Time(t time.Time) interface{}
+	// ForUpdate returns the clause, with a leading space, locking the rows of the
+	// table selected by a query until the end of the transaction. It is empty when
+	// the transactions of the database run one after the other.
+	ForUpdate(table string) string
+	// TagsColumn returns the expression of the JSON array of the tag names of the
+	// note of the current notes row, sorted by name
+	TagsColumn() string
+	// SearchQuery returns the query searching the notes of the user $1 for the terms,
+	// with the limit $2 and the returned args from $3 on. It selects the columns
+	// followed by the rank and the title and body snippets, most relevant first.
+	SearchQuery(columns string, terms []notestore.SearchTerm) (string, []interface{})
+}
+
+//Store is a note store kept in a SQL database
+type Store struct {
+	*sql.DB
+	dialect Dialect
+	// noteColumns are the columns scanned by scanNote, the tags are aggregated into a JSON array
+	noteColumns string
+}
+
+//New returns the store of the database, queried with the dialect
+func New(db *sql.DB, dialect Dialect) *Store {
+	return &Store{
+		DB:      db,
+		dialect: dialect,
+		noteColumns: `id, title, body, user_id, created_at, updated_at, status, priority, due_date, completed_at,
+	recurrence, occurrence, coalesce(project_id, ''), deleted_at, version, ` + dialect.TagsColumn(),
+	}
+}
+
+//Dialect returns the dialect of the database, to query it outside of the store
+func (s *Store) Dialect() Dialect {
+	return s.dialect
+}
+
+// sortColumns maps the sort fields to the columns of the notes table
+var sortColumns = map[notestore.SortField]string{
+	notestore.SortByCreated: "created_at",
+	notestore.SortByUpdated: "updated_at",
+	notestore.SortByTitle:   "title",
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+type queryer interface {
+	execer
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// now returns the current time for the queries
+func (s *Store) now() interface{} {
+	return s.dialect.Time(time.Now())
+}
+
+//TimeColumn returns the destination of Scan for a time column of any dialect, dst is a
+//*time.Time or a *string in RFC 3339
+func TimeColumn(dst interface{}) sql.Scanner {
+	return timeColumn{dst}
+}
+
+// timeColumn scans a time column, read as a time or as text depending on the database,
+// into a *string in RFC 3339 or a *time.Time. NULL is scanned as the zero value.
+type timeColumn struct {
+	dst interface{}
+}
+
+func (c timeColumn) Scan(src interface{}) error {
+	var t time.Time
+	switch v := src.(type) {
+	case nil:
+	case time.Time:
+		t = v
+	case []byte:
+		return c.Scan(string(v))
+	case string:
+		var err error
+		t, err = time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return fmt.Errorf("invalid time '%s': %s", v, err)
+		}
+	default:
+		return fmt.Errorf("unable to scan %T into a time", src)
+	}
+
+	switch dst := c.dst.(type) {
+	case *time.Time:
+		*dst = t
+	case *string:
+		*dst = ""
+		if !t.IsZero() {
+			*dst = t.Format(time.RFC3339Nano)
+		}
+	default:
+		return fmt.Errorf("unable to scan a time into %T", c.dst)
+	}
+	return nil
+}
+
+// noteSerial returns the primary key of the note owned by the user, notes in the trash are not found
+func noteSerial(ctx context.Context, q queryer, noteID, userID string) (int64, error) {
+	var sNo int64
+	err := q.QueryRowContext(ctx, "SELECT s_no FROM notes WHERE id=$1 AND user_id=$2 AND deleted_at IS NULL;", noteID, userID).Scan(&sNo)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, notestore.NotFoundf("Note '%s' is not found", noteID)
+		}
+		return 0, fmt.Errorf("error occurred while retrieving the note: %s", err)
+	}
+	return sNo, nil
+}
+
+// lockNote locks the row of the note owned by the user until the end of the transaction
+// and returns its primary key
+func (s *Store) lockNote(ctx context.Context, tx *sql.Tx, noteID, userID string) (int64, error) {
+	var sNo int64
+	sqlQuery := "SELECT s_no FROM notes WHERE id=$1 AND user_id=$2 AND deleted_at IS NULL" + s.dialect.ForUpdate("notes") + ";"
+	err := tx.QueryRowContext(ctx, sqlQuery, noteID, userID).Scan(&sNo)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, notestore.NotFoundf("Note '%s' is not found", noteID)
+		}
+		return 0, fmt.Errorf("error occurred while locking the note: %s", err)
+	}
+	return sNo, nil
+}
+
+// bumpVersion increments the version of a note whose checklist or tags changed
+func bumpVersion(ctx context.Context, e execer, sNo int64) error {
+	_, err := e.ExecContext(ctx, "UPDATE notes SET version=version+1 WHERE s_no=$1;", sNo)
+	if err != nil {
+		return fmt.Errorf("unable to update the version of the note: %s", err)
+	}
+	return nil
+}
+
+// scanNote scans the noteColumns followed by the extra columns of a row
+func scanNote(row scanner, extra ...interface{}) (notestore.Note, error) {
+	var note notestore.Note
+	var tags jsonStrings
+	dest := []interface{}{&note.ID, &note.Title, &note.Body, &note.UserID, timeColumn{&note.CreatedAt},
+		timeColumn{&note.UpdatedAt}, &note.Status, &note.Priority, timeColumn{&note.DueDate},
+		timeColumn{&note.CompletedAt}, &note.Recurrence, &note.Occurrence, &note.ProjectID,
+		timeColumn{&note.DeletedAt}, &note.Version, &tags}
+	err := row.Scan(append(dest, extra...)...)
+	note.Tags = tags
+	return note, err
+}
+
+// dueDate returns the due date of a normalized note for a nullable column
+func (s *Store) dueDate(note notestore.Note) interface{} {
+	if note.DueDate == "" {
+		return nil
+	}
+	due, _ := time.Parse(time.RFC3339Nano, note.DueDate)
+	return s.dialect.Time(due)
+}
+
+// jsonStrings scans a JSON array of strings
+type jsonStrings []string
+
+func (j *jsonStrings) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, j)
+	case string:
+		return json.Unmarshal([]byte(v), j)
+	default:
+		return fmt.Errorf("unable to scan %T into a JSON array", src)
+	}
+}
+
+// withTx runs fn in a transaction that is committed if fn succeeds
+func (s *Store) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return WithTx(ctx, s.DB, fn)
+}
+
+//WithTx runs fn in a transaction of the database, committed unless fn returns an error
+func WithTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %s", err)
+	}
+
+	err = fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("unable to commit transaction: %s", err)
+	}
+	return nil
+}
+
+//Create creates a note in the DB along with its tags
+func (s *Store) Create(ctx context.Context, note notestore.Note) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		return s.createNote(ctx, tx, note)
+	})
+}
+
+// createNote normalizes and inserts a note after checking its project
+func (s *Store) createNote(ctx context.Context, tx *sql.Tx, note notestore.Note) error {
+	tags, err := notestore.NormalizeTags(note.Tags)
+	if err != nil {
+		return err
+	}
+	note, err = notestore.NormalizeTodo(note)
+	if err != nil {
+		return err
+	}
+	note.Tags = tags
+
+	if note.ProjectID != "" {
+		err := checkProject(ctx, tx, note.ProjectID, note.UserID)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = s.insertNote(ctx, tx, note)
+	return err
+}
+
+// insertNote inserts a normalized note with its tags and returns its primary key
+func (s *Store) insertNote(ctx context.Context, q queryer, note notestore.Note) (int64, error) {
+	now := s.now()
+	var completedAt interface{}
+	if note.Status == notestore.StatusDone {
+		completedAt = now
+	}
+
+	var projectID interface{}
+	if note.ProjectID != "" {
+		projectID = note.ProjectID
+	}
+
+	sql := `INSERT INTO notes(user_id, id, title, body, created_at, updated_at, status, priority, due_date, completed_at,
+		recurrence, occurrence, project_id) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING s_no;`
+	var sNo int64
+	err := q.QueryRowContext(ctx, sql, note.UserID, note.ID, note.Title, note.Body, now, now,
+		string(note.Status), note.Priority, s.dueDate(note), completedAt, note.Recurrence, note.Occurrence, projectID).Scan(&sNo)
+	if err != nil {
+		return 0, fmt.Errorf("unable to store note '%s': %s", note.ID, err)
+	}
+
+	return sNo, addTags(ctx, q, sNo, note.UserID, note.Tags)
+}
+
+//Read reads a note from the DB
+func (s *Store) Read(ctx context.Context, noteID, userID string) (notestore.Note, error) {
+	return s.readNote(ctx, s, noteID, userID)
+}
+
+func (s *Store) readNote(ctx context.Context, q queryer, noteID, userID string) (notestore.Note, error) {
+	sqlQuery := "SELECT " + s.noteColumns + " FROM notes WHERE id=$1 and user_id=$2 AND deleted_at IS NULL;"
+	row := q.QueryRowContext(ctx, sqlQuery, noteID, userID)
+
+	note, err := scanNote(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return notestore.Note{}, notestore.NotFoundf("Note '%s' is not found", noteID)
+		}
+		return notestore.Note{}, fmt.Errorf("error occurred while retrieving the note: %s", err)
+	}
+
+	return note, nil
+}
+
+//ReadAll reads a page of notes for the given user ID. Pages are fetched with
+//keyset queries on the sort column and the note ID.
+func (s *Store) ReadAll(ctx context.Context, userID string, opts notestore.ReadAllOptions) (notestore.Page, error) {
+	opts, cursor, err := opts.Normalize()
+	if err != nil {
+		return notestore.Page{}, err
+	}
+
+	where := []string{"user_id=$1", "deleted_at IS NULL"}
+	args := []interface{}{userID}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if opts.ProjectID != "" {
+		where = append(where, "project_id="+arg(opts.ProjectID))
+	} else {
+		where = append(where, "NOT EXISTS (SELECT 1 FROM projects p WHERE p.id=notes.project_id AND p.archived)")
+	}
+	for _, tag := range opts.Tags {
+		where = append(where, `EXISTS (SELECT 1 FROM note_tags nt JOIN tags t ON t.id=nt.tag_id
+			WHERE nt.note_s_no=notes.s_no AND t.name=`+arg(tag)+")")
+	}
+	if len(opts.Statuses) > 0 {
+		var statuses []string
+		for _, status := range opts.Statuses {
+			statuses = append(statuses, arg(string(status)))
+		}
+		where = append(where, "status IN ("+strings.Join(statuses, ", ")+")")
+	}
+	if !opts.DueAfter.IsZero() {
+		where = append(where, "due_date>="+arg(s.dialect.Time(opts.DueAfter)))
+	}
+	if !opts.DueBefore.IsZero() {
+		where = append(where, "due_date<"+arg(s.dialect.Time(opts.DueBefore)))
+	}
+	if !opts.CreatedAfter.IsZero() {
+		where = append(where, "created_at>="+arg(s.dialect.Time(opts.CreatedAfter)))
+	}
+	if !opts.CreatedBefore.IsZero() {
+		where = append(where, "created_at<"+arg(s.dialect.Time(opts.CreatedBefore)))
+	}
+	if !opts.UpdatedAfter.IsZero() {
+		where = append(where, "updated_at>="+arg(s.dialect.Time(opts.UpdatedAfter)))
+	}
+	if !opts.UpdatedBefore.IsZero() {
+		where = append(where, "updated_at<"+arg(s.dialect.Time(opts.UpdatedBefore)))
+	}
+
+	// Paging backward walks the list in reverse order
+	column := sortColumns[opts.SortBy]
+	desc := opts.Order == notestore.Descending
+	if cursor != nil && cursor.Backward {
+		desc = !desc
+	}
+	cmp, dir := ">", "ASC"
+	if desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	if cursor != nil {
+		var key interface{} = cursor.Key
+		if opts.SortBy != notestore.SortByTitle {
+			t, err := time.Parse(time.RFC3339Nano, cursor.Key)
+			if err != nil {
+				return notestore.Page{}, notestore.Invalidf("invalid cursor: %s", err)
+			}
+			key = s.dialect.Time(t)
+		}
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s, %s)", column, cmp, arg(key), arg(cursor.NoteID)))
+	}
+
+	sql := fmt.Sprintf("SELECT %s FROM notes WHERE %s ORDER BY %s %s, id %s LIMIT %s;",
+		s.noteColumns, strings.Join(where, " AND "), column, dir, dir, arg(opts.Limit+1))
+	rows, err := s.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return notestore.Page{}, fmt.Errorf("error occurred while querying the note: %s", err)
+	}
+	defer rows.Close()
+
+	var notes []notestore.Note
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return notestore.Page{}, fmt.Errorf("error occurred while scanning the rows: %s", err)
+		}
+		notes = append(notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return notestore.Page{}, fmt.Errorf("error occurred while iterating the rows: %s", err)
+	}
+
+	return notestore.NewPage(notes, opts, cursor), nil
+}
+
+//Search searches the title and body of the notes of the given user ID, most relevant
+//first, with the full-text search of the database
+func (s *Store) Search(ctx context.Context, userID, query string, limit int) ([]notestore.SearchResult, error) {
+	terms, err := notestore.ParseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = notestore.DefaultSearchLimit
+	}
+	if limit > notestore.MaxSearchLimit {
+		limit = notestore.MaxSearchLimit
+	}
+
+	sql, args := s.dialect.SearchQuery(s.noteColumns, terms)
+	rows, err := s.QueryContext(ctx, sql, append([]interface{}{userID, limit}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while searching the notes: %s", err)
+	}
+	defer rows.Close()
+
+	results := []notestore.SearchResult{}
+	for rows.Next() {
+		var result notestore.SearchResult
+		result.Note, err = scanNote(rows, &result.Rank, &result.TitleSnippet, &result.BodySnippet)
+		if err != nil {
+			return nil, fmt.Errorf("error occurred while scanning the rows: %s", err)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred while iterating the rows: %s", err)
+	}
+
+	return results, nil
+}
+
+//Update updates a note. The previous title and body are stored as a revision
+//in the same transaction when they change.
+func (s *Store) Update(ctx context.Context, note notestore.Note) error {
+	note, err := notestore.NormalizeTodo(note)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		return s.updateNote(ctx, tx, note)
+	})
+}
+
+//UpdateFields updates the listed fields of the note on top of its current content
+func (s *Store) UpdateFields(ctx context.Context, note notestore.Note, fields []notestore.NoteField) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		return s.updateFields(ctx, tx, note, fields)
+	})
+}
+
+// updateFields merges the listed fields of the note into its current content and updates it
+func (s *Store) updateFields(ctx context.Context, tx *sql.Tx, note notestore.Note, fields []notestore.NoteField) error {
+	_, err := s.lockNote(ctx, tx, note.ID, note.UserID)
+	if err != nil {
+		return err
+	}
+	current, err := s.readNote(ctx, tx, note.ID, note.UserID)
+	if err != nil {
+		return err
+	}
+	if note.Version != 0 && note.Version != current.Version {
+		return notestore.ErrVersionMismatch
+	}
+
+	current, err = notestore.NormalizeTodo(notestore.MergeFields(current, note, fields))
+	if err != nil {
+		return err
+	}
+	return s.updateNote(ctx, tx, current)
+}
+
+// updateNote updates a normalized note and stores a revision of its previous content.
+// The update is a compare-and-swap on the version of the note when note.Version is set.
+func (s *Store) updateNote(ctx context.Context, tx *sql.Tx, note notestore.Note) error {
+	var sNo int64
+	var version int
+	var title, body string
+	var status notestore.Status
+	sqlQuery := `SELECT s_no, version, coalesce(title, ''), coalesce(body, ''), status FROM notes
+		WHERE id=$1 AND user_id=$2 AND deleted_at IS NULL` + s.dialect.ForUpdate("notes") + ";"
+	err := tx.QueryRowContext(ctx, sqlQuery, note.ID, note.UserID).Scan(&sNo, &version, &title, &body, &status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return notestore.NotFoundf("Note '%s' is not found", note.ID)
+		}
+		return fmt.Errorf("error occurred while locking the note: %s", err)
+	}
+	if note.Version != 0 && note.Version != version {
+		return notestore.ErrVersionMismatch
+	}
+	err = notestore.CheckDone(notestore.Note{Status: status}, note)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	if title != note.Title || body != note.Body {
+		sqlQuery = `INSERT INTO note_revisions(note_s_no, number, title, body, user_id, created_at)
+			SELECT $1, coalesce(max(number)+1, 1), $2, $3, $4, $5 FROM note_revisions WHERE note_s_no=$1;`
+		_, err = tx.ExecContext(ctx, sqlQuery, sNo, title, body, note.UserID, now)
+		if err != nil {
+			return fmt.Errorf("unable to store revision of note '%s': %s", note.ID, err)
+		}
+	}
+
+	// completed_at is kept while the note stays done
+	sqlQuery = `UPDATE notes SET title=$1, body=$2, updated_at=$3, status=$4, priority=$5, due_date=$6,
+		completed_at=CASE WHEN $4='done' THEN coalesce(completed_at, $3) END, recurrence=$7, occurrence=$8,
+		version=version+1 WHERE s_no=$9;`
+	_, err = tx.ExecContext(ctx, sqlQuery, note.Title, note.Body, now, string(note.Status), note.Priority, s.dueDate(note),
+		note.Recurrence, note.Occurrence, sNo)
+	if err != nil {
+		return fmt.Errorf("unable to update note '%s': %s", note.ID, err)
+	}
+	return nil
+}
+
+//Complete sets the status of a note to done. For a recurring note, the next
+//occurrence is created in the same transaction with the tags and an unchecked
+//copy of the checklist of the note.
+func (s *Store) Complete(ctx context.Context, noteID, userID string) (notestore.Note, error) {
+	var next notestore.Note
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		sNo, err := s.lockNote(ctx, tx, noteID, userID)
+		if err != nil {
+			return err
+		}
+		note, err := s.readNote(ctx, tx, noteID, userID)
+		if err != nil {
+			return err
+		}
+		// Completing twice must not spawn another occurrence
+		if note.Status == notestore.StatusDone {
+			return nil
+		}
+
+		now := s.now()
+		sql := "UPDATE notes SET status=$1, completed_at=$2, updated_at=$2, version=version+1 WHERE s_no=$3;"
+		_, err = tx.ExecContext(ctx, sql, string(notestore.StatusDone), now, sNo)
+		if err != nil {
+			return fmt.Errorf("unable to complete note '%s': %s", noteID, err)
+		}
+
+		occurrence, ok := notestore.NextOccurrence(note)
+		if !ok {
+			return nil
+		}
+		occurrence.ID = uuid.New().String()
+		nextSNo, err := s.insertNote(ctx, tx, occurrence)
+		if err != nil {
+			return err
+		}
+
+		sql = `INSERT INTO note_items(id, note_s_no, text, checked, position, created_at)
+			SELECT $1, $2, text, false, position, $3 FROM note_items WHERE id=$4;`
+		rows, err := tx.QueryContext(ctx, "SELECT id FROM note_items WHERE note_s_no=$1 ORDER BY position;", sNo)
+		if err != nil {
+			return fmt.Errorf("error occurred while querying the items: %s", err)
+		}
+		var itemIDs []string
+		for rows.Next() {
+			var id string
+			err := rows.Scan(&id)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("error occurred while scanning the rows: %s", err)
+			}
+			itemIDs = append(itemIDs, id)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("error occurred while reading the items: %s", err)
+		}
+		for _, id := range itemIDs {
+			_, err := tx.ExecContext(ctx, sql, uuid.New().String(), nextSNo, now, id)
+			if err != nil {
+				return fmt.Errorf("unable to copy item '%s': %s", id, err)
+			}
+		}
+
+		next, err = s.readNote(ctx, tx, occurrence.ID, userID)
+		return err
+	})
+	if err != nil {
+		return notestore.Note{}, err
+	}
+	return next, nil
+}
+
+// Delete moves a note to the trash, see Purge for deleting it permanently
+func (s *Store) Delete(ctx context.Context, noteID, userID string, version int) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		return s.deleteNote(ctx, tx, noteID, userID, version)
+	})
+}
+
+// deleteNote moves a note to the trash, when version is set only if the note is at that version
+func (s *Store) deleteNote(ctx context.Context, tx *sql.Tx, noteID, userID string, version int) error {
+	var sNo int64
+	var current int
+	sqlQuery := "SELECT s_no, version FROM notes WHERE id=$1 AND user_id=$2 AND deleted_at IS NULL" + s.dialect.ForUpdate("notes") + ";"
+	err := tx.QueryRowContext(ctx, sqlQuery, noteID, userID).Scan(&sNo, &current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return notestore.NotFoundf("Note '%s' is not found", noteID)
+		}
+		return fmt.Errorf("error occurred while locking the note: %s", err)
+	}
+	if version != 0 && version != current {
+		return notestore.ErrVersionMismatch
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE notes SET deleted_at=$1, version=version+1 WHERE s_no=$2;", s.now(), sNo)
+	if err != nil {
+		return fmt.Errorf("unable to delete note '%s': %s", noteID, err)
+	}
+	return nil
+}
+
+// The store has every feature of a note store
+var (
+	_ notestore.NoteStore        = (*Store)(nil)
+	_ notestore.TagStore         = (*Store)(nil)
+	_ notestore.ItemStore        = (*Store)(nil)
+	_ notestore.ProjectStore     = (*Store)(nil)
+	_ notestore.TrashStore       = (*Store)(nil)
+	_ notestore.RevisionStore    = (*Store)(nil)
+	_ notestore.IdempotencyStore = (*Store)(nil)
+)
@@ -0,0 +1,230 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+// tagID returns the ID of the user's tag, creating the tag if needed
+func tagID(ctx context.Context, q queryer, userID, tag string) (int64, error) {
+	sql := `INSERT INTO tags(user_id, name) VALUES($1, $2)
+		ON CONFLICT (user_id, name) DO UPDATE SET name=EXCLUDED.name RETURNING id;`
+	var id int64
+	err := q.QueryRowContext(ctx, sql, userID, tag).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("unable to store tag '%s': %s", tag, err)
+	}
+	return id, nil
+}
+
+// addTags attaches the normalized tags to the note
+func addTags(ctx context.Context, q queryer, sNo int64, userID string, tags []string) error {
+	for _, tag := range tags {
+		id, err := tagID(ctx, q, userID, tag)
+		if err != nil {
+			return err
+		}
+
+		sql := "INSERT INTO note_tags(note_s_no, tag_id) VALUES($1, $2) ON CONFLICT DO NOTHING;"
+		_, err = q.ExecContext(ctx, sql, sNo, id)
+		if err != nil {
+			return fmt.Errorf("unable to tag note with '%s': %s", tag, err)
+		}
+	}
+	return nil
+}
+
+// bumpTaggedVersions increments the version of the notes having the tag
+func bumpTaggedVersions(ctx context.Context, e execer, tagID int64) error {
+	sql := "UPDATE notes SET version=version+1 WHERE s_no IN (SELECT note_s_no FROM note_tags WHERE tag_id=$1);"
+	_, err := e.ExecContext(ctx, sql, tagID)
+	if err != nil {
+		return fmt.Errorf("unable to update the version of the notes: %s", err)
+	}
+	return nil
+}
+
+// pruneTags deletes the user's tags that are no longer attached to any note
+func pruneTags(ctx context.Context, e execer, userID string) error {
+	sql := "DELETE FROM tags WHERE user_id=$1 AND NOT EXISTS (SELECT 1 FROM note_tags nt WHERE nt.tag_id=tags.id);"
+	_, err := e.ExecContext(ctx, sql, userID)
+	if err != nil {
+		return fmt.Errorf("unable to prune tags: %s", err)
+	}
+	return nil
+}
+
+//AddTags attaches the tags to a note
+func (s *Store) AddTags(ctx context.Context, noteID, userID string, tags []string) error {
+	tags, err := notestore.NormalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		sNo, err := noteSerial(ctx, tx, noteID, userID)
+		if err != nil {
+			return err
+		}
+		err = addTags(ctx, tx, sNo, userID, tags)
+		if err != nil {
+			return err
+		}
+		return bumpVersion(ctx, tx, sNo)
+	})
+}
+
+//RemoveTag detaches a tag from a note
+func (s *Store) RemoveTag(ctx context.Context, noteID, userID, tag string) error {
+	tag, err := notestore.NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		sNo, err := noteSerial(ctx, tx, noteID, userID)
+		if err != nil {
+			return err
+		}
+
+		sql := "DELETE FROM note_tags WHERE note_s_no=$1 AND tag_id=(SELECT id FROM tags WHERE user_id=$2 AND name=$3);"
+		ct, err := tx.ExecContext(ctx, sql, sNo, userID, tag)
+		if err != nil {
+			return fmt.Errorf("unable to remove tag '%s': %s", tag, err)
+		}
+
+		n, err := ct.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error in getting rows affected: %s", err)
+		}
+		if n == 0 {
+			return notestore.NotFoundf("Tag '%s' is not found on note '%s'", tag, noteID)
+		}
+
+		err = bumpVersion(ctx, tx, sNo)
+		if err != nil {
+			return err
+		}
+		return pruneTags(ctx, tx, userID)
+	})
+}
+
+//ListTags lists the tags of the user with the number of notes for each tag
+func (s *Store) ListTags(ctx context.Context, userID string) ([]notestore.Tag, error) {
+	sql := `SELECT t.name, count(nt.note_s_no) FROM tags t JOIN note_tags nt ON nt.tag_id=t.id
+		JOIN notes n ON n.s_no=nt.note_s_no AND n.deleted_at IS NULL
+		WHERE t.user_id=$1 GROUP BY t.name ORDER BY t.name;`
+	rows, err := s.QueryContext(ctx, sql, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while querying the tags: %s", err)
+	}
+	defer rows.Close()
+
+	tags := []notestore.Tag{}
+	for rows.Next() {
+		var tag notestore.Tag
+		err := rows.Scan(&tag.Name, &tag.Count)
+		if err != nil {
+			return nil, fmt.Errorf("error occurred while scanning the rows: %s", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred while iterating the rows: %s", err)
+	}
+
+	return tags, nil
+}
+
+//RenameTag renames a tag of the user
+func (s *Store) RenameTag(ctx context.Context, userID, name, newName string) error {
+	name, err := notestore.NormalizeTag(name)
+	if err != nil {
+		return err
+	}
+	newName, err = notestore.NormalizeTag(newName)
+	if err != nil {
+		return err
+	}
+	if name == newName {
+		return nil
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var exists bool
+		err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tags WHERE user_id=$1 AND name=$2);", userID, newName).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("error occurred while retrieving the tag: %s", err)
+		}
+		if exists {
+			return notestore.Conflictf("Tag '%s' already exists", newName)
+		}
+
+		var id int64
+		sqlQuery := "UPDATE tags SET name=$1 WHERE user_id=$2 AND name=$3 RETURNING id;"
+		err = tx.QueryRowContext(ctx, sqlQuery, newName, userID, name).Scan(&id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return notestore.NotFoundf("Tag '%s' is not found", name)
+			}
+			return fmt.Errorf("unable to rename tag '%s': %s", name, err)
+		}
+		return bumpTaggedVersions(ctx, tx, id)
+	})
+}
+
+//MergeTags moves the notes of the source tags to the target tag and deletes the source tags
+func (s *Store) MergeTags(ctx context.Context, userID string, sources []string, target string) error {
+	sources, err := notestore.NormalizeTags(sources)
+	if err != nil {
+		return err
+	}
+	target, err = notestore.NormalizeTag(target)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		targetID, err := tagID(ctx, tx, userID, target)
+		if err != nil {
+			return err
+		}
+
+		for _, source := range sources {
+			if source == target {
+				continue
+			}
+
+			var sourceID int64
+			err := tx.QueryRowContext(ctx, "SELECT id FROM tags WHERE user_id=$1 AND name=$2;", userID, source).Scan(&sourceID)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					return notestore.NotFoundf("Tag '%s' is not found", source)
+				}
+				return fmt.Errorf("error occurred while retrieving the tag: %s", err)
+			}
+
+			err = bumpTaggedVersions(ctx, tx, sourceID)
+			if err != nil {
+				return err
+			}
+
+			sql := `INSERT INTO note_tags(note_s_no, tag_id) SELECT note_s_no, $1 FROM note_tags WHERE tag_id=$2
+				ON CONFLICT DO NOTHING;`
+			_, err = tx.ExecContext(ctx, sql, targetID, sourceID)
+			if err != nil {
+				return fmt.Errorf("unable to merge tag '%s': %s", source, err)
+			}
+
+			_, err = tx.ExecContext(ctx, "DELETE FROM tags WHERE id=$1;", sourceID)
+			if err != nil {
+				return fmt.Errorf("unable to delete tag '%s': %s", source, err)
+			}
+		}
+
+		return pruneTags(ctx, tx, userID)
+	})
+}
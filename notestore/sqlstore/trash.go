@@ -0,0 +1,112 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"local/sidharthjs/todo/notestore"
+)
+
+//ListTrash lists the deleted notes of the user, most recently deleted first
+func (s *Store) ListTrash(ctx context.Context, userID string) ([]notestore.Note, error) {
+	sql := "SELECT " + s.noteColumns + " FROM notes WHERE user_id=$1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id;"
+	rows, err := s.QueryContext(ctx, sql, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while querying the trash: %s", err)
+	}
+	defer rows.Close()
+
+	notes := []notestore.Note{}
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error occurred while scanning the rows: %s", err)
+		}
+		notes = append(notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred while iterating the rows: %s", err)
+	}
+
+	return notes, nil
+}
+
+//Restore moves a note out of the trash
+func (s *Store) Restore(ctx context.Context, noteID, userID string) error {
+	sql := "UPDATE notes SET deleted_at=NULL, updated_at=$1, version=version+1 WHERE id=$2 AND user_id=$3 AND deleted_at IS NOT NULL;"
+	ct, err := s.ExecContext(ctx, sql, s.now(), noteID, userID)
+	if err != nil {
+		return fmt.Errorf("unable to restore note '%s': %s", noteID, err)
+	}
+
+	n, err := ct.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error in getting rows affected: %s", err)
+	}
+	if n == 0 {
+		return notestore.NotFoundf("Note '%s' is not found in the trash", noteID)
+	}
+	return nil
+}
+
+//Purge permanently deletes a note from the trash, the database cascades the delete
+//to its checklist items, tags and revisions
+func (s *Store) Purge(ctx context.Context, noteID, userID string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		sql := "DELETE FROM notes WHERE id=$1 AND user_id=$2 AND deleted_at IS NOT NULL;"
+		ct, err := tx.ExecContext(ctx, sql, noteID, userID)
+		if err != nil {
+			return fmt.Errorf("unable to purge note '%s': %s", noteID, err)
+		}
+
+		n, err := ct.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error in getting rows affected: %s", err)
+		}
+		if n == 0 {
+			return notestore.NotFoundf("Note '%s' is not found in the trash", noteID)
+		}
+
+		return pruneTags(ctx, tx, userID)
+	})
+}
+
+//PurgeExpired permanently deletes the notes of every user deleted before the given time
+func (s *Store) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "DELETE FROM notes WHERE deleted_at<$1 RETURNING user_id;", s.dialect.Time(before))
+		if err != nil {
+			return fmt.Errorf("unable to purge the trash: %s", err)
+		}
+		users := map[string]bool{}
+		for rows.Next() {
+			var userID string
+			err := rows.Scan(&userID)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("error occurred while scanning the rows: %s", err)
+			}
+			users[userID] = true
+			purged++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error occurred while iterating the rows: %s", err)
+		}
+
+		for userID := range users {
+			err := pruneTags(ctx, tx, userID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
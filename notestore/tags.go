@@ -0,0 +1,65 @@
+package notestore
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTagLength is the maximum length of a tag name in characters
+const MaxTagLength = 50
+
+// Tag is a label with the number of notes it is attached to
+type Tag struct {
+	Name  string
+	Count int
+}
+
+// TagStore is the interface for storing the tags of the notes.
+// Tags belong to a user and only exist while attached to a note.
+type TagStore interface {
+	AddTags(ctx context.Context, noteID, userID string, tags []string) error
+	RemoveTag(ctx context.Context, noteID, userID, tag string) error
+	ListTags(ctx context.Context, userID string) ([]Tag, error)
+	// RenameTag fails when the new name is already used, use MergeTags instead
+	RenameTag(ctx context.Context, userID, name, newName string) error
+	// MergeTags moves the notes of the source tags to the target tag and
+	// removes the source tags
+	MergeTags(ctx context.Context, userID string, sources []string, target string) error
+}
+
+// NormalizeTag trims and lower-cases a tag name and checks that it only contains
+// letters, digits, spaces and the characters - _ . :
+func NormalizeTag(name string) (string, error) {
+	tag := strings.ToLower(strings.TrimSpace(name))
+	if tag == "" {
+		return "", Invalidf("tag name is empty")
+	}
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		return "", Invalidf("tag '%s' is longer than %d characters", tag, MaxTagLength)
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(" -_.:", r) {
+			return "", Invalidf("tag '%s' contains invalid character '%c'", tag, r)
+		}
+	}
+	return tag, nil
+}
+
+// NormalizeTags normalizes the tag names and removes the duplicates
+func NormalizeTags(names []string) ([]string, error) {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		tag, err := NormalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
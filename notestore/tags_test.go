@@ -0,0 +1,54 @@
+package notestore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	testCases := []struct {
+		description   string
+		tags          []string
+		expectedTags  []string
+		expectedError bool
+	}{
+		{
+			description:  "Trimmed, lower-cased and deduplicated",
+			tags:         []string{" Infra ", "infra", "on-call", "v1.2:rc"},
+			expectedTags: []string{"infra", "on-call", "v1.2:rc"},
+		},
+		{
+			description:  "No tags",
+			tags:         nil,
+			expectedTags: []string{},
+		},
+		{
+			description:   "Empty tag",
+			tags:          []string{"infra", "  "},
+			expectedError: true,
+		},
+		{
+			description:   "Invalid character",
+			tags:          []string{"infra/ops"},
+			expectedError: true,
+		},
+		{
+			description:   "Too long",
+			tags:          []string{strings.Repeat("a", MaxTagLength+1)},
+			expectedError: true,
+		},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		tags, err := NormalizeTags(testCase.tags)
+		if testCase.expectedError {
+			assert.NotNil(err, testCase.description)
+			continue
+		}
+		assert.Nil(err, testCase.description)
+		assert.Equal(testCase.expectedTags, tags, testCase.description)
+	}
+}
@@ -0,0 +1,200 @@
+package notestore
+
+import (
+	"time"
+)
+
+// Status is the progress of a note
+type Status string
+
+// Statuses of a note
+const (
+	StatusOpen       Status = "open"
+	StatusInProgress Status = "in-progress"
+	StatusDone       Status = "done"
+)
+
+// Valid reports whether s is a known status
+func (s Status) Valid() bool {
+	return s == StatusOpen || s == StatusInProgress || s == StatusDone
+}
+
+// Priorities of a note, from PriorityNone to PriorityHigh
+const (
+	PriorityNone = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+// ValidatePriority checks that the priority is between PriorityNone and PriorityHigh
+func ValidatePriority(priority int) error {
+	if priority < PriorityNone || priority > PriorityHigh {
+		return Invalidf("priority must be between %d and %d", PriorityNone, PriorityHigh)
+	}
+	return nil
+}
+
+// ParseDueDate parses an RFC 3339 timestamp or a date such as 2021-10-20.
+// A date is due at the start of the day in UTC.
+func ParseDueDate(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, Invalidf("invalid due date '%s', expected RFC 3339 timestamp or date", s)
+	}
+	return t, nil
+}
+
+// DueFilter is a preset range of due dates relative to the current time
+type DueFilter string
+
+// Due filters supported by ReadAll
+const (
+	// DueOverdue matches the notes that are not done and due before now
+	DueOverdue DueFilter = "overdue"
+	// DueToday matches the notes due today
+	DueToday DueFilter = "today"
+	// DueThisWeek matches the notes due from Monday to Sunday of the current week
+	DueThisWeek DueFilter = "this-week"
+)
+
+// applyDueFilter narrows the due date range and statuses of the options down
+// to the due filter, relative to now. The days are UTC days, as the dates are
+// due at the start of the day in UTC whatever the zone of the server.
+func (opts *ReadAllOptions) applyDueFilter(now time.Time) error {
+	var after, before time.Time
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch opts.Due {
+	case "":
+		return nil
+	case DueOverdue:
+		before = now
+		statuses := []Status{}
+		for _, status := range []Status{StatusOpen, StatusInProgress} {
+			if len(opts.Statuses) == 0 || containsStatus(opts.Statuses, status) {
+				statuses = append(statuses, status)
+			}
+		}
+		if len(statuses) == 0 {
+			return Invalidf("notes that are done are never overdue")
+		}
+		opts.Statuses = statuses
+	case DueToday:
+		after, before = day, day.AddDate(0, 0, 1)
+	case DueThisWeek:
+		// Weeks start on Monday
+		offset := (int(day.Weekday()) + 6) % 7
+		after = day.AddDate(0, 0, -offset)
+		before = after.AddDate(0, 0, 7)
+	default:
+		return Invalidf("invalid due filter '%s'", opts.Due)
+	}
+
+	if !after.IsZero() && after.After(opts.DueAfter) {
+		opts.DueAfter = after
+	}
+	if opts.DueBefore.IsZero() || before.Before(opts.DueBefore) {
+		opts.DueBefore = before
+	}
+	opts.Due = ""
+	return nil
+}
+
+func containsStatus(statuses []Status, status Status) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTodo defaults the status of the note to open, checks its priority and
+// formats its due date as an RFC 3339 timestamp in UTC
+func NormalizeTodo(note Note) (Note, error) {
+	if note.Status == "" {
+		note.Status = StatusOpen
+	}
+	if !note.Status.Valid() {
+		return note, Invalidf("invalid status '%s'", note.Status)
+	}
+
+	err := ValidatePriority(note.Priority)
+	if err != nil {
+		return note, err
+	}
+
+	if note.DueDate != "" {
+		due, err := ParseDueDate(note.DueDate)
+		if err != nil {
+			return note, err
+		}
+		note.DueDate = due.UTC().Format(time.RFC3339Nano)
+	}
+
+	if note.Recurrence == "" {
+		note.Occurrence = 0
+		return note, nil
+	}
+	r, err := ParseRecurrence(note.Recurrence)
+	if err != nil {
+		return note, err
+	}
+	if note.DueDate == "" {
+		return note, Invalidf("a recurring note needs a due date")
+	}
+	note.Recurrence = r.String()
+	if note.Occurrence < 1 {
+		note.Occurrence = 1
+	}
+	return note, nil
+}
+
+// CheckDone returns an error when the update of the current note to note sets a
+// recurring note to done: only Complete creates its next occurrence.
+func CheckDone(current, note Note) error {
+	if note.Recurrence != "" && note.Status == StatusDone && current.Status != StatusDone {
+		return Invalidf("a recurring note is done once completed, which creates its next occurrence")
+	}
+	return nil
+}
+
+// NextOccurrence returns the next occurrence of a normalized recurring note,
+// due at the next date of its series. It returns false when the note is not
+// recurring or its series has ended.
+func NextOccurrence(note Note) (Note, bool) {
+	if note.Recurrence == "" {
+		return Note{}, false
+	}
+	r, err := ParseRecurrence(note.Recurrence)
+	if err != nil {
+		return Note{}, false
+	}
+	due, err := time.Parse(time.RFC3339Nano, note.DueDate)
+	if err != nil {
+		return Note{}, false
+	}
+	next, ok := r.Next(due, note.Occurrence)
+	if !ok {
+		return Note{}, false
+	}
+
+	return Note{
+		Title:      note.Title,
+		Body:       note.Body,
+		UserID:     note.UserID,
+		ProjectID:  note.ProjectID,
+		Tags:       note.Tags,
+		Status:     StatusOpen,
+		Priority:   note.Priority,
+		DueDate:    next.UTC().Format(time.RFC3339Nano),
+		Recurrence: note.Recurrence,
+		Occurrence: note.Occurrence + 1,
+	}, true
+}
@@ -0,0 +1,144 @@
+package notestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTodo(t *testing.T) {
+	testCases := []struct {
+		description   string
+		note          Note
+		expectedNote  Note
+		expectedError bool
+	}{
+		{
+			description:  "Status defaults to open",
+			note:         Note{Title: "Todo"},
+			expectedNote: Note{Title: "Todo", Status: StatusOpen},
+		},
+		{
+			description:  "Due date is formatted in UTC",
+			note:         Note{Status: StatusInProgress, Priority: PriorityHigh, DueDate: "2021-10-20T10:00:00+05:30"},
+			expectedNote: Note{Status: StatusInProgress, Priority: PriorityHigh, DueDate: "2021-10-20T04:30:00Z"},
+		},
+		{
+			description:  "Due date without time",
+			note:         Note{Status: StatusDone, DueDate: "2021-10-20"},
+			expectedNote: Note{Status: StatusDone, DueDate: "2021-10-20T00:00:00Z"},
+		},
+		{
+			description:   "Invalid status",
+			note:          Note{Status: "blocked"},
+			expectedError: true,
+		},
+		{
+			description:   "Invalid priority",
+			note:          Note{Priority: PriorityHigh + 1},
+			expectedError: true,
+		},
+		{
+			description:   "Invalid due date",
+			note:          Note{DueDate: "tomorrow"},
+			expectedError: true,
+		},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		note, err := NormalizeTodo(testCase.note)
+		if testCase.expectedError {
+			assert.NotNil(err, testCase.description)
+			continue
+		}
+		assert.Nil(err, testCase.description)
+		assert.Equal(testCase.expectedNote, note, testCase.description)
+	}
+}
+
+func TestApplyDueFilter(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2021, 10, 20, 15, 30, 0, 0, time.UTC)
+	today := time.Date(2021, 10, 20, 0, 0, 0, 0, time.UTC)
+	monday := time.Date(2021, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		description   string
+		opts          ReadAllOptions
+		expectedOpts  ReadAllOptions
+		expectedError bool
+	}{
+		{
+			description:  "Overdue",
+			opts:         ReadAllOptions{Due: DueOverdue},
+			expectedOpts: ReadAllOptions{DueBefore: now, Statuses: []Status{StatusOpen, StatusInProgress}},
+		},
+		{
+			description:  "Overdue keeps the requested statuses that are not done",
+			opts:         ReadAllOptions{Due: DueOverdue, Statuses: []Status{StatusDone, StatusInProgress}},
+			expectedOpts: ReadAllOptions{DueBefore: now, Statuses: []Status{StatusInProgress}},
+		},
+		{
+			description:   "Overdue notes are never done",
+			opts:          ReadAllOptions{Due: DueOverdue, Statuses: []Status{StatusDone}},
+			expectedError: true,
+		},
+		{
+			description:  "Today",
+			opts:         ReadAllOptions{Due: DueToday},
+			expectedOpts: ReadAllOptions{DueAfter: today, DueBefore: today.AddDate(0, 0, 1)},
+		},
+		{
+			description:  "This week",
+			opts:         ReadAllOptions{Due: DueThisWeek},
+			expectedOpts: ReadAllOptions{DueAfter: monday, DueBefore: monday.AddDate(0, 0, 7)},
+		},
+		{
+			description:  "This week narrowed by an explicit range",
+			opts:         ReadAllOptions{Due: DueThisWeek, DueAfter: today, DueBefore: today.AddDate(0, 1, 0)},
+			expectedOpts: ReadAllOptions{DueAfter: today, DueBefore: monday.AddDate(0, 0, 7)},
+		},
+		{
+			description:   "Unknown filter",
+			opts:          ReadAllOptions{Due: "someday"},
+			expectedError: true,
+		},
+	}
+
+	assert := assert.New(t)
+	for _, testCase := range testCases {
+		opts := testCase.opts
+		err := opts.applyDueFilter(now)
+		if testCase.expectedError {
+			assert.NotNil(err, testCase.description)
+			continue
+		}
+		assert.Nil(err, testCase.description)
+		assert.Equal(testCase.expectedOpts, opts, testCase.description)
+	}
+}
+
+func TestApplyDueFilterTimeZone(t *testing.T) {
+	// Wednesday 20 in UTC, but already Thursday 21 in Sydney and still Tuesday 19 in Los Angeles
+	now := time.Date(2021, 10, 20, 15, 30, 0, 0, time.UTC)
+	today := time.Date(2021, 10, 20, 0, 0, 0, 0, time.UTC)
+	dueToday, err := ParseDueDate("2021-10-20")
+
+	assert := assert.New(t)
+	assert.Nil(err)
+	for _, zone := range []*time.Location{time.FixedZone("AEDT", 11*60*60), time.FixedZone("PDT", -7*60*60)} {
+		opts := ReadAllOptions{Due: DueToday}
+		err := opts.applyDueFilter(now.In(zone))
+		assert.Nil(err, zone.String())
+		assert.Equal(ReadAllOptions{DueAfter: today, DueBefore: today.AddDate(0, 0, 1)}, opts, zone.String())
+		assert.False(dueToday.Before(opts.DueAfter), zone.String())
+		assert.True(dueToday.Before(opts.DueBefore), zone.String())
+
+		opts = ReadAllOptions{Due: DueThisWeek}
+		err = opts.applyDueFilter(now.In(zone))
+		assert.Nil(err, zone.String())
+		assert.Equal(time.Date(2021, 10, 18, 0, 0, 0, 0, time.UTC), opts.DueAfter, zone.String())
+	}
+}
@@ -0,0 +1,51 @@
+package notestore
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Default settings of the purger
+const (
+	DefaultTrashRetention = 30 * 24 * time.Hour
+	DefaultPurgeInterval  = time.Hour
+)
+
+// TrashStore is the interface for the notes deleted by NoteStore.Delete.
+// Deleted notes are kept in the trash until they are restored or purged, and are
+// left out of every other method of the stores.
+type TrashStore interface {
+	// ListTrash returns the deleted notes of the user, most recently deleted first
+	ListTrash(ctx context.Context, userID string) ([]Note, error)
+	// Restore moves a deleted note out of the trash
+	Restore(ctx context.Context, noteID, userID string) error
+	// Purge permanently deletes a note from the trash
+	Purge(ctx context.Context, noteID, userID string) error
+	// PurgeExpired permanently deletes the notes of every user deleted before the
+	// given time and returns the number of purged notes
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RunPurger permanently deletes the notes that have been in the trash for longer
+// than retention, every interval until the context is cancelled
+func RunPurger(ctx context.Context, store TrashStore, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := store.PurgeExpired(ctx, time.Now().Add(-retention))
+		if err != nil {
+			log.Errorf("unable to purge the trash: %s", err)
+		} else if n > 0 {
+			log.Infof("%d notes purged from the trash", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
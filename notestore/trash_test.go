@@ -0,0 +1,47 @@
+package notestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// purgeRecorder is a TrashStore recording the calls to PurgeExpired
+type purgeRecorder struct {
+	TrashStore
+	calls chan time.Time
+}
+
+func (p *purgeRecorder) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	p.calls <- before
+	return 1, nil
+}
+
+func TestRunPurger(t *testing.T) {
+	store := &purgeRecorder{calls: make(chan time.Time, 10)}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		RunPurger(ctx, store, 24*time.Hour, 10*time.Millisecond)
+		close(done)
+	}()
+
+	assert := assert.New(t)
+	for i := 0; i < 2; i++ {
+		select {
+		case before := <-store.calls:
+			assert.WithinDuration(time.Now().Add(-24*time.Hour), before, time.Minute)
+		case <-time.After(time.Second):
+			t.Fatal("the trash is not purged")
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the purger does not stop with its context")
+	}
+}
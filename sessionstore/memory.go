@@ -0,0 +1,135 @@
+package sessionstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+//Memory is a thread-safe session store in memory, for the tests and for running the
+//app without a database. The users log in again when the app restarts. The zero value
+//is not usable, see NewMemory.
+type Memory struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+	tokens   map[string]refreshToken
+}
+
+// refreshToken is a refresh token of a session, kept by its hash
+type refreshToken struct {
+	sessionID string
+	// replaced is set once the token has been rotated
+	replaced bool
+}
+
+// NewMemory returns an empty store
+func NewMemory() *Memory {
+	return &Memory{
+		sessions: map[string]Session{},
+		tokens:   map[string]refreshToken{},
+	}
+}
+
+//CreateSession stores a session with the hash of its first refresh token
+func (m *Memory) CreateSession(ctx context.Context, session Session, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// The expired sessions of the user are dropped as their tokens are refused anyway
+	now := time.Now()
+	for id, other := range m.sessions {
+		if other.UserID == session.UserID && !other.ExpiresAt.After(now) {
+			m.revoke(id)
+		}
+	}
+
+	if _, ok := m.sessions[session.ID]; ok {
+		return fmt.Errorf("session '%s' already exists", session.ID)
+	}
+	m.sessions[session.ID] = session
+	m.tokens[tokenHash] = refreshToken{sessionID: session.ID}
+	return nil
+}
+
+//RotateRefreshToken replaces a refresh token of a session with a new one
+func (m *Memory) RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, expiresAt time.Time) (Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, ok := m.tokens[tokenHash]
+	if !ok {
+		return Session{}, ErrNotFound
+	}
+	session := m.sessions[token.sessionID]
+	now := time.Now()
+	if !session.ExpiresAt.After(now) {
+		m.revoke(session.ID)
+		return Session{}, ErrNotFound
+	}
+	if token.replaced {
+		m.revoke(session.ID)
+		return Session{}, ErrTokenReused
+	}
+
+	m.tokens[tokenHash] = refreshToken{sessionID: session.ID, replaced: true}
+	m.tokens[newTokenHash] = refreshToken{sessionID: session.ID}
+	session.LastUsedAt, session.ExpiresAt = now, expiresAt
+	m.sessions[session.ID] = session
+	return session, nil
+}
+
+//ListSessions lists the active sessions of the user
+func (m *Memory) ListSessions(ctx context.Context, userID string) ([]Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := []Session{}
+	now := time.Now()
+	for _, session := range m.sessions {
+		if session.UserID == userID && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+//RevokeSession deletes a session of the user along with its refresh tokens
+func (m *Memory) RevokeSession(ctx context.Context, sessionID, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[sessionID]
+	if !ok || session.UserID != userID || !session.ExpiresAt.After(time.Now()) {
+		return ErrNotFound
+	}
+	m.revoke(sessionID)
+	return nil
+}
+
+//RevokeRefreshToken deletes the session of a refresh token
+func (m *Memory) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, ok := m.tokens[tokenHash]
+	if !ok {
+		return ErrNotFound
+	}
+	m.revoke(token.sessionID)
+	return nil
+}
+
+// revoke deletes a session and its refresh tokens
+func (m *Memory) revoke(sessionID string) {
+	delete(m.sessions, sessionID)
+	for hash, token := range m.tokens {
+		if token.sessionID == sessionID {
+			delete(m.tokens, hash)
+		}
+	}
+}
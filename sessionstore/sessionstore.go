@@ -0,0 +1,56 @@
+// Package sessionstore keeps the login sessions of the users and their refresh tokens.
+// The sessions are not notes: they are kept apart from the note stores, in the
+// Postgres or SQLite database of the app, or in memory for development.
+package sessionstore
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultSessionTTL is how long a session lasts without refreshing its access token
+const DefaultSessionTTL = 30 * 24 * time.Hour
+
+var (
+	//ErrNotFound is returned for unknown refresh tokens and for ended sessions
+	ErrNotFound = errors.New("session not found")
+	//ErrTokenReused is returned when a refresh token that was already rotated is used
+	//again, as it may have been stolen. Its session is revoked.
+	ErrTokenReused = errors.New("refresh token reused")
+)
+
+// Session is a login of a user. The user keeps refreshing its access token with a
+// refresh token, which is replaced at every refresh; the tokens of a session are a
+// family, revoked together.
+type Session struct {
+	ID       string
+	UserID   string
+	Username string
+	// UserAgent is the client that logged in
+	UserAgent  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	// ExpiresAt is the end of the session unless its refresh token is rotated before
+	ExpiresAt time.Time
+}
+
+// Store is the interface for the sessions of the users. The refresh tokens are only
+// given as hashes, the tokens themselves are never stored.
+type Store interface {
+	// CreateSession stores a session along with the hash of its first refresh token.
+	// The expired sessions of the user are dropped.
+	CreateSession(ctx context.Context, session Session, tokenHash string) error
+	// RotateRefreshToken replaces the refresh token of tokenHash with the one of
+	// newTokenHash, extends the session until expiresAt and returns it. Unknown tokens
+	// and ended sessions are ErrNotFound. A token that was already replaced returns
+	// ErrTokenReused after the session is revoked.
+	RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, expiresAt time.Time) (Session, error)
+	// ListSessions returns the active sessions of the user, the most recently used first
+	ListSessions(ctx context.Context, userID string) ([]Session, error)
+	// RevokeSession ends a session of the user along with its refresh tokens, it is
+	// ErrNotFound when the user has no such active session
+	RevokeSession(ctx context.Context, sessionID, userID string) error
+	// RevokeRefreshToken ends the session of a refresh token, current or replaced
+	RevokeRefreshToken(ctx context.Context, tokenHash string) error
+}
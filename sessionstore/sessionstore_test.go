@@ -0,0 +1,113 @@
+package sessionstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	migrate "local/sidharthjs/todo/db"
+	"local/sidharthjs/todo/notestore/sqlite"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	testStore(t, NewMemory())
+}
+
+func TestSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	err := migrate.Migrate("sqlite://"+path, "file://../db/migrations/sqlite")
+	if err != nil {
+		t.Fatalf("error performing db migration: %s", err)
+	}
+	db, err := sqlite.NewClient(path)
+	if err != nil {
+		t.Fatalf("error creating db client: %s", err)
+	}
+	defer db.Close()
+
+	testStore(t, NewSQL(db.DB, db.Dialect()))
+}
+
+// testStore verifies the contract of Store
+func testStore(t *testing.T, store Store) {
+	userID := "user_" + uuid.NewString()
+	ctx := context.Background()
+	// newSession creates a session of the user whose first refresh token has the hash
+	newSession := func(hash string, expiresAt time.Time) Session {
+		now := time.Now()
+		session := Session{ID: uuid.NewString(), UserID: userID, Username: "john101", UserAgent: "curl/7.79.1",
+			CreatedAt: now, LastUsedAt: now, ExpiresAt: expiresAt}
+		err := store.CreateSession(ctx, session, hash)
+		if err != nil {
+			t.Fatalf("error creating the session: %s", err)
+		}
+		return session
+	}
+
+	assert := assert.New(t)
+	first := newSession("hash-1", time.Now().Add(time.Hour))
+	sessions, err := store.ListSessions(ctx, userID)
+	assert.Nil(err)
+	if assert.Len(sessions, 1) {
+		assert.Equal(first.ID, sessions[0].ID)
+		assert.Equal("john101", sessions[0].Username)
+		assert.Equal("curl/7.79.1", sessions[0].UserAgent)
+		assert.WithinDuration(first.ExpiresAt, sessions[0].ExpiresAt, time.Millisecond)
+	}
+
+	// Every refresh replaces the token and extends the session
+	session, err := store.RotateRefreshToken(ctx, "hash-1", "hash-2", time.Now().Add(2*time.Hour))
+	assert.Nil(err)
+	assert.Equal(first.ID, session.ID)
+	assert.Equal(userID, session.UserID)
+	assert.Equal("john101", session.Username)
+	assert.True(session.ExpiresAt.After(first.ExpiresAt))
+	_, err = store.RotateRefreshToken(ctx, "hash-2", "hash-3", time.Now().Add(2*time.Hour))
+	assert.Nil(err)
+
+	// The most recently used sessions are listed first
+	second := newSession("hash-4", time.Now().Add(time.Hour))
+	sessions, err = store.ListSessions(ctx, userID)
+	assert.Nil(err)
+	if assert.Len(sessions, 2) {
+		assert.Equal(second.ID, sessions[0].ID)
+		assert.Equal(first.ID, sessions[1].ID)
+	}
+
+	// Reusing a replaced token revokes its whole session
+	_, err = store.RotateRefreshToken(ctx, "hash-1", "hash-5", time.Now().Add(2*time.Hour))
+	assert.True(errors.Is(err, ErrTokenReused), err)
+	_, err = store.RotateRefreshToken(ctx, "hash-3", "hash-6", time.Now().Add(2*time.Hour))
+	assert.True(errors.Is(err, ErrNotFound), err)
+	_, err = store.RotateRefreshToken(ctx, "hash-unknown", "hash-7", time.Now().Add(2*time.Hour))
+	assert.True(errors.Is(err, ErrNotFound), err)
+
+	// The sessions are revoked by their ID, only by their user, or by a refresh token
+	err = store.RevokeSession(ctx, second.ID, "user_"+uuid.NewString())
+	assert.True(errors.Is(err, ErrNotFound), err)
+	err = store.RevokeSession(ctx, second.ID, userID)
+	assert.Nil(err)
+	_, err = store.RotateRefreshToken(ctx, "hash-4", "hash-8", time.Now().Add(2*time.Hour))
+	assert.True(errors.Is(err, ErrNotFound), err)
+	err = store.RevokeSession(ctx, second.ID, userID)
+	assert.True(errors.Is(err, ErrNotFound), err)
+
+	newSession("hash-9", time.Now().Add(time.Hour))
+	err = store.RevokeRefreshToken(ctx, "hash-9")
+	assert.Nil(err)
+	err = store.RevokeRefreshToken(ctx, "hash-9")
+	assert.True(errors.Is(err, ErrNotFound), err)
+
+	// The expired sessions are not listed and cannot be refreshed
+	newSession("hash-10", time.Now().Add(-time.Minute))
+	sessions, err = store.ListSessions(ctx, userID)
+	assert.Nil(err)
+	assert.Empty(sessions)
+	_, err = store.RotateRefreshToken(ctx, "hash-10", "hash-11", time.Now().Add(2*time.Hour))
+	assert.True(errors.Is(err, ErrNotFound), err)
+}
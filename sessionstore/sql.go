@@ -0,0 +1,178 @@
+package sessionstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"local/sidharthjs/todo/notestore/sqlstore"
+)
+
+//SQL is a session store kept in the sessions and refresh_tokens tables of a Postgres
+//or SQLite database, migrated along with the note store of the database
+type SQL struct {
+	db      *sql.DB
+	dialect sqlstore.Dialect
+}
+
+// NewSQL returns the session store of the database, queried with the dialect of its note store
+func NewSQL(db *sql.DB, dialect sqlstore.Dialect) *SQL {
+	return &SQL{db: db, dialect: dialect}
+}
+
+// sessionColumns are the columns scanned by scanSession
+const sessionColumns = "s.id, s.user_id, s.username, s.user_agent, s.created_at, s.last_used_at, s.expires_at"
+
+// scanSession scans the sessionColumns of a row, followed by the extra destinations
+func scanSession(row interface{ Scan(...interface{}) error }, extra ...interface{}) (Session, error) {
+	var session Session
+	dest := []interface{}{&session.ID, &session.UserID, &session.Username, &session.UserAgent,
+		sqlstore.TimeColumn(&session.CreatedAt), sqlstore.TimeColumn(&session.LastUsedAt), sqlstore.TimeColumn(&session.ExpiresAt)}
+	err := row.Scan(append(dest, extra...)...)
+	return session, err
+}
+
+//CreateSession stores a session with the hash of its first refresh token
+func (s *SQL) CreateSession(ctx context.Context, session Session, tokenHash string) error {
+	return sqlstore.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		// The expired sessions of the user are dropped as their tokens are refused anyway
+		_, err := tx.ExecContext(ctx, "DELETE FROM sessions WHERE user_id=$1 AND expires_at<=$2;", session.UserID, s.dialect.Time(time.Now()))
+		if err != nil {
+			return fmt.Errorf("unable to delete the expired sessions: %s", err)
+		}
+
+		sqlQuery := `INSERT INTO sessions(id, user_id, username, user_agent, created_at, last_used_at, expires_at)
+			VALUES($1, $2, $3, $4, $5, $6, $7);`
+		_, err = tx.ExecContext(ctx, sqlQuery, session.ID, session.UserID, session.Username, session.UserAgent,
+			s.dialect.Time(session.CreatedAt), s.dialect.Time(session.LastUsedAt), s.dialect.Time(session.ExpiresAt))
+		if err != nil {
+			return fmt.Errorf("unable to create session '%s': %s", session.ID, err)
+		}
+		sqlQuery = "INSERT INTO refresh_tokens(token_hash, session_id, created_at) VALUES($1, $2, $3);"
+		_, err = tx.ExecContext(ctx, sqlQuery, tokenHash, session.ID, s.dialect.Time(session.CreatedAt))
+		if err != nil {
+			return fmt.Errorf("unable to store the refresh token of session '%s': %s", session.ID, err)
+		}
+		return nil
+	})
+}
+
+//RotateRefreshToken replaces a refresh token of a session with a new one
+func (s *SQL) RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, expiresAt time.Time) (Session, error) {
+	var session Session
+	// A session revoked in the transaction is committed before its error is returned
+	var revokedErr error
+	err := sqlstore.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		// A concurrent rotation of the same token waits here and then finds it replaced
+		sqlQuery := `SELECT ` + sessionColumns + `, rt.replaced_at IS NOT NULL FROM refresh_tokens rt
+			JOIN sessions s ON s.id=rt.session_id WHERE rt.token_hash=$1` + s.dialect.ForUpdate("rt") + ";"
+		var replaced bool
+		var err error
+		session, err = scanSession(tx.QueryRowContext(ctx, sqlQuery, tokenHash), &replaced)
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("error occurred while retrieving the refresh token: %s", err)
+		}
+
+		now := time.Now()
+		switch {
+		case !session.ExpiresAt.After(now):
+			revokedErr = ErrNotFound
+		case replaced:
+			revokedErr = ErrTokenReused
+		}
+		if revokedErr != nil {
+			_, err = tx.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1;", session.ID)
+			if err != nil {
+				return fmt.Errorf("unable to revoke session '%s': %s", session.ID, err)
+			}
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE refresh_tokens SET replaced_at=$1 WHERE token_hash=$2;", s.dialect.Time(now), tokenHash)
+		if err != nil {
+			return fmt.Errorf("unable to replace the refresh token: %s", err)
+		}
+		sqlQuery = "INSERT INTO refresh_tokens(token_hash, session_id, created_at) VALUES($1, $2, $3);"
+		_, err = tx.ExecContext(ctx, sqlQuery, newTokenHash, session.ID, s.dialect.Time(now))
+		if err != nil {
+			return fmt.Errorf("unable to store the refresh token of session '%s': %s", session.ID, err)
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE sessions SET last_used_at=$1, expires_at=$2 WHERE id=$3;",
+			s.dialect.Time(now), s.dialect.Time(expiresAt), session.ID)
+		if err != nil {
+			return fmt.Errorf("unable to extend session '%s': %s", session.ID, err)
+		}
+		session.LastUsedAt, session.ExpiresAt = now, expiresAt
+		return nil
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	if revokedErr != nil {
+		return Session{}, revokedErr
+	}
+	return session, nil
+}
+
+//ListSessions lists the active sessions of the user
+func (s *SQL) ListSessions(ctx context.Context, userID string) ([]Session, error) {
+	sqlQuery := "SELECT " + sessionColumns + " FROM sessions s WHERE s.user_id=$1 AND s.expires_at>$2 ORDER BY s.last_used_at DESC;"
+	rows, err := s.db.QueryContext(ctx, sqlQuery, userID, s.dialect.Time(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while retrieving sessions: %s", err)
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error occurred while scanning sessions: %s", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+//RevokeSession deletes a session of the user along with its refresh tokens
+func (s *SQL) RevokeSession(ctx context.Context, sessionID, userID string) error {
+	ct, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1 AND user_id=$2 AND expires_at>$3;", sessionID, userID, s.dialect.Time(time.Now()))
+	if err != nil {
+		return fmt.Errorf("unable to revoke session '%s': %s", sessionID, err)
+	}
+	n, err := ct.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error in getting rows affected: %s", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+//RevokeRefreshToken deletes the session of a refresh token
+func (s *SQL) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
+	sqlQuery := "DELETE FROM sessions WHERE id=(SELECT session_id FROM refresh_tokens WHERE token_hash=$1);"
+	ct, err := s.db.ExecContext(ctx, sqlQuery, tokenHash)
+	if err != nil {
+		return fmt.Errorf("unable to revoke the session of the refresh token: %s", err)
+	}
+	n, err := ct.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error in getting rows affected: %s", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// The stores have every method of a session store
+var (
+	_ Store = (*SQL)(nil)
+	_ Store = (*Memory)(nil)
+)